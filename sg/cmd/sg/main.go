@@ -5,6 +5,8 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/Azure/ShieldGuard/sg/internal/cli/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/cli/serve"
 	"github.com/Azure/ShieldGuard/sg/internal/cli/test"
 )
 
@@ -25,6 +27,8 @@ func createMainCmd() *cobra.Command {
 
 	rv.AddCommand(
 		test.CreateCLI(),
+		policy.CreateCLI(),
+		serve.CreateCLI(),
 	)
 
 	return rv
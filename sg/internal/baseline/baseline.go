@@ -0,0 +1,180 @@
+package baseline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+)
+
+// Fingerprint returns the stable fingerprint of a result reported by the source.
+// The fingerprint is computed from the policy package, the rule query, the source name,
+// the document identity and the message. For documents without identity, the document index
+// is used instead.
+func Fingerprint(sourceName string, r result.Result) string {
+	if r.Location.File != "" {
+		sourceName = r.Location.File
+	}
+	identity := documentIdentity(r)
+
+	h := sha256.New()
+	for _, v := range []string{r.Package, r.Query, filepath.ToSlash(sourceName), identity, r.Message} {
+		// NOTE: values are separated by NUL to avoid ambiguity
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func documentIdentity(r result.Result) string {
+	switch {
+	case r.Location.Identity != "":
+		return r.Location.Identity
+	case r.Location.File != "":
+		return fmt.Sprintf("#%d", r.Location.Document)
+	default:
+		return ""
+	}
+}
+
+// FromQueryResults creates the baseline from the failures and warnings of the query results.
+// The findings are deduplicated and ordered by the fingerprint, so the baseline is stable
+// regardless of the order of the query results.
+func FromQueryResults(queryResultsList []result.QueryResults) File {
+	findingsByFingerprint := map[string]Finding{}
+	for _, queryResults := range queryResultsList {
+		sourceName := queryResults.Source.Name()
+		for _, results := range [][]result.Result{queryResults.Failures, queryResults.Warnings} {
+			for _, r := range results {
+				fingerprint := Fingerprint(sourceName, r)
+				if _, exists := findingsByFingerprint[fingerprint]; exists {
+					continue
+				}
+				source := sourceName
+				if r.Location.File != "" {
+					source = r.Location.File
+				}
+				findingsByFingerprint[fingerprint] = Finding{
+					Fingerprint: fingerprint,
+					Package:     r.Package,
+					Rule:        r.Query,
+					Source:      filepath.ToSlash(source),
+					Identity:    documentIdentity(r),
+				}
+			}
+		}
+	}
+
+	findings := make([]Finding, 0, len(findingsByFingerprint))
+	for _, finding := range findingsByFingerprint {
+		findings = append(findings, finding)
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Fingerprint < findings[j].Fingerprint
+	})
+
+	return File{Findings: findings}
+}
+
+// ReadFromJSON reads the baseline from JSON.
+func ReadFromJSON(src io.Reader) (File, error) {
+	var f File
+	if err := json.NewDecoder(src).Decode(&f); err != nil {
+		if err == io.EOF {
+			// empty file
+			return File{}, nil
+		}
+		return File{}, fmt.Errorf("decode json: %w", err)
+	}
+
+	for idx, finding := range f.Findings {
+		if strings.TrimSpace(finding.Fingerprint) == "" {
+			return File{}, fmt.Errorf("invalid finding #%d: fingerprint is required", idx)
+		}
+	}
+
+	return f, nil
+}
+
+// ReadFromFile reads the baseline from a file.
+func ReadFromFile(p string) (File, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return File{}, fmt.Errorf("read file %q: %w", p, err)
+	}
+	defer f.Close()
+
+	return ReadFromJSON(f)
+}
+
+// WriteTo writes the baseline as JSON.
+func (f File) WriteTo(w io.Writer) (int64, error) {
+	if f.Findings == nil {
+		f.Findings = []Finding{}
+	}
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("encode json: %w", err)
+	}
+	b = append(b, '\n')
+
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// WriteToFile writes the baseline to a file.
+func (f File) WriteToFile(p string) error {
+	out, err := os.Create(p)
+	if err != nil {
+		return fmt.Errorf("create file %q: %w", p, err)
+	}
+	defer out.Close()
+
+	if _, err := f.WriteTo(out); err != nil {
+		return fmt.Errorf("write file %q: %w", p, err)
+	}
+	return out.Close()
+}
+
+// Apply moves the failures and warnings recorded in the baseline to the baselined results.
+func Apply(queryResultsList []result.QueryResults, f File) []result.QueryResults {
+	if len(f.Findings) < 1 {
+		return queryResultsList
+	}
+
+	fingerprints := make(map[string]struct{}, len(f.Findings))
+	for _, finding := range f.Findings {
+		fingerprints[finding.Fingerprint] = struct{}{}
+	}
+
+	rv := make([]result.QueryResults, 0, len(queryResultsList))
+	for _, queryResults := range queryResultsList {
+		sourceName := queryResults.Source.Name()
+
+		// apply returns the results not recorded in the baseline.
+		apply := func(results []result.Result) []result.Result {
+			var kept []result.Result
+			for _, r := range results {
+				if _, baselined := fingerprints[Fingerprint(sourceName, r)]; baselined {
+					queryResults.Baselined = append(queryResults.Baselined, r)
+					continue
+				}
+				kept = append(kept, r)
+			}
+			return kept
+		}
+
+		queryResults.Failures = apply(queryResults.Failures)
+		queryResults.Warnings = apply(queryResults.Warnings)
+		rv = append(rv, queryResults)
+	}
+	return rv
+}
@@ -0,0 +1,181 @@
+package baseline
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+	"github.com/Azure/ShieldGuard/sg/internal/source/testsource"
+)
+
+func testSource(name string) *testsource.TestSource {
+	return &testsource.TestSource{NameFunc: func() string { return name }}
+}
+
+func testResult(name string, identity string, message string) result.Result {
+	return result.Result{
+		Query:   "data.main.deny_" + name,
+		Rule:    policy.Rule{Kind: policy.QueryKindDeny, Name: name, Namespace: policy.NamespaceMain},
+		Message: message,
+		Location: result.Location{
+			File:     "configurations/pods.yaml",
+			Document: 1,
+			Line:     3,
+			Identity: identity,
+		},
+	}
+}
+
+func Test_Fingerprint(t *testing.T) {
+	r := testResult("foo", "Pod/foo", "foo is not allowed")
+	fingerprint := Fingerprint("configurations/pods.yaml", r)
+	assert.Len(t, fingerprint, 64)
+
+	moved := r
+	moved.Location.Line = 10
+	moved.Location.Document = 2
+	moved.Metadata = map[string]interface{}{"foo": "bar"}
+	assert.Equal(t, fingerprint, Fingerprint("configurations/pods.yaml", moved), "should ignore the position in the source")
+
+	for name, other := range map[string]result.Result{
+		"rule":     testResult("bar", "Pod/foo", "foo is not allowed"),
+		"identity": testResult("foo", "Pod/bar", "foo is not allowed"),
+		"message":  testResult("foo", "Pod/foo", "bar is not allowed"),
+	} {
+		assert.NotEqual(t, fingerprint, Fingerprint("configurations/pods.yaml", other), "should depend on the %s", name)
+	}
+
+	otherPackage := r
+	otherPackage.Package = "builtin:pss/baseline"
+	assert.NotEqual(t, fingerprint, Fingerprint("configurations/pods.yaml", otherPackage), "should depend on the package")
+
+	withoutIdentity := testResult("foo", "", "foo is not allowed")
+	withoutIdentityMoved := withoutIdentity
+	withoutIdentityMoved.Location.Document = 2
+	assert.NotEqual(
+		t,
+		Fingerprint("configurations/pods.yaml", withoutIdentity),
+		Fingerprint("configurations/pods.yaml", withoutIdentityMoved),
+		"should use the document index for documents without identity",
+	)
+
+	withoutLocation := testResult("foo", "", "foo is not allowed")
+	withoutLocation.Location = result.Location{}
+	assert.NotEqual(
+		t,
+		Fingerprint("a.yaml", withoutLocation),
+		Fingerprint("b.yaml", withoutLocation),
+		"should fallback to the source name",
+	)
+}
+
+func Test_FromQueryResults(t *testing.T) {
+	queryResultsList := []result.QueryResults{
+		{
+			Source: testSource("configurations/pods.yaml"),
+			Failures: []result.Result{
+				testResult("foo", "Pod/foo", "foo is not allowed"),
+				testResult("foo", "Pod/foo", "foo is not allowed"),
+			},
+			Warnings: []result.Result{
+				testResult("bar", "Pod/bar", "bar is not recommended"),
+			},
+			Exceptions: []result.Result{
+				testResult("baz", "Pod/baz", "baz is excepted"),
+			},
+		},
+	}
+
+	f := FromQueryResults(queryResultsList)
+	if assert.Len(t, f.Findings, 2, "should deduplicate findings and ignore exceptions") {
+		assert.Less(t, f.Findings[0].Fingerprint, f.Findings[1].Fingerprint, "should order by fingerprint")
+		for _, finding := range f.Findings {
+			assert.Equal(t, "configurations/pods.yaml", finding.Source)
+		}
+	}
+
+	reversed := []result.QueryResults{
+		{
+			Source:   testSource("configurations/pods.yaml"),
+			Failures: []result.Result{queryResultsList[0].Failures[0]},
+		},
+		{
+			Source:   testSource("configurations/pods.yaml"),
+			Warnings: queryResultsList[0].Warnings,
+		},
+	}
+	reversed[0], reversed[1] = reversed[1], reversed[0]
+	assert.Equal(t, f, FromQueryResults(reversed), "should not depend on the order of the results")
+}
+
+func Test_File_roundTrip(t *testing.T) {
+	f := FromQueryResults([]result.QueryResults{
+		{
+			Source:   testSource("configurations/pods.yaml"),
+			Failures: []result.Result{testResult("foo", "Pod/foo", "foo is not allowed")},
+		},
+	})
+
+	b := new(bytes.Buffer)
+	_, err := f.WriteTo(b)
+	assert.NoError(t, err)
+
+	read, err := ReadFromJSON(b)
+	assert.NoError(t, err)
+	assert.Equal(t, f, read)
+
+	b.Reset()
+	_, err = File{}.WriteTo(b)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"findings": []}`, b.String())
+
+	read, err = ReadFromJSON(strings.NewReader(""))
+	assert.NoError(t, err)
+	assert.Empty(t, read.Findings)
+
+	_, err = ReadFromJSON(strings.NewReader(`{"findings": [{"rule": "data.main.deny_foo"}]}`))
+	assert.Error(t, err, "should require fingerprint")
+}
+
+func Test_Apply(t *testing.T) {
+	existing := testResult("foo", "Pod/foo", "foo is not allowed")
+	existingWarning := testResult("bar", "Pod/bar", "bar is not recommended")
+	existingWarning.Rule.Kind = policy.QueryKindWarn
+	f := FromQueryResults([]result.QueryResults{
+		{
+			Source:   testSource("configurations/pods.yaml"),
+			Failures: []result.Result{existing},
+			Warnings: []result.Result{existingWarning},
+		},
+	})
+
+	newFailure := testResult("foo", "Pod/new", "foo is not allowed")
+	queryResultsList := Apply(
+		[]result.QueryResults{
+			{
+				Source:   testSource("configurations/pods.yaml"),
+				Failures: []result.Result{existing, newFailure},
+				Warnings: []result.Result{existingWarning},
+			},
+		},
+		f,
+	)
+	if assert.Len(t, queryResultsList, 1) {
+		queryResults := queryResultsList[0]
+		assert.Equal(t, []result.Result{newFailure}, queryResults.Failures)
+		assert.Empty(t, queryResults.Warnings)
+		assert.Equal(t, []result.Result{existing, existingWarning}, queryResults.Baselined)
+	}
+
+	unchanged := []result.QueryResults{
+		{
+			Source:   testSource("configurations/pods.yaml"),
+			Failures: []result.Result{existing},
+		},
+	}
+	assert.Equal(t, unchanged, Apply(unchanged, File{}), "should not change results without baseline")
+}
@@ -0,0 +1,21 @@
+package baseline
+
+// Finding specifies a failure or warning recorded in the baseline.
+type Finding struct {
+	// Fingerprint is the stable fingerprint of the finding (see Fingerprint).
+	Fingerprint string `json:"fingerprint"`
+	// Package is the qualified id of the policy package defining the rule (e.g. `builtin:pss/baseline`).
+	Package string `json:"package,omitempty"`
+	// Rule is the query of the rule reporting the finding (e.g. `data.main.deny_foo`).
+	Rule string `json:"rule"`
+	// Source is the name of the source reporting the finding.
+	Source string `json:"source"`
+	// Identity is the identity of the document reporting the finding.
+	Identity string `json:"identity,omitempty"`
+}
+
+// File specifies the baseline file.
+type File struct {
+	// Findings is the list of findings, ordered by the fingerprint.
+	Findings []Finding `json:"findings"`
+}
@@ -0,0 +1,21 @@
+package policy
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/ShieldGuard/sg/internal/cli/policy/test"
+)
+
+// CreateCLI creates the CLI for the policy subcommand.
+func CreateCLI() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Manage policy packages.",
+	}
+
+	cmd.AddCommand(
+		test.CreateCLI(),
+	)
+
+	return cmd
+}
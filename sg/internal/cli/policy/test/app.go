@@ -0,0 +1,133 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/ShieldGuard/sg/internal/engine"
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+	"github.com/Azure/ShieldGuard/sg/internal/result/presenter"
+	"github.com/Azure/ShieldGuard/sg/internal/utils"
+	"github.com/spf13/pflag"
+)
+
+var errTestFailure = errors.New("policy test failed")
+
+// cliApp is the CLI application for the policy test subcommand.
+type cliApp struct {
+	policyPaths  []string
+	dataPaths    []string
+	outputFormat string
+	runFilter    string
+	coverage     bool
+
+	stdout io.Writer
+}
+
+func newCliApp(ms ...func(*cliApp)) *cliApp {
+	rv := &cliApp{
+		outputFormat: presenter.FormatText,
+	}
+
+	for _, m := range ms {
+		m(rv)
+	}
+
+	return rv
+}
+
+func (cliApp *cliApp) BindCLIFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(
+		&cliApp.outputFormat, "output", "o", cliApp.outputFormat,
+		fmt.Sprintf("Output format. Available formats: %s", presenter.AvailableFormatsHelp()),
+	)
+	fs.StringArrayVarP(
+		&cliApp.dataPaths, "data", "d", nil,
+		"Data file or directory to load into the data document, in the form of [<key>=]<path>. Can be specified multiple times.",
+	)
+	fs.StringVarP(&cliApp.runFilter, "run", "r", "", "Run only the tests matching the regular expression.")
+	fs.BoolVarP(
+		&cliApp.coverage, "coverage", "", false,
+		"Report the coverage of the policy modules. Except for the text format, the coverage report replaces the test results.",
+	)
+}
+
+func (cliApp *cliApp) defaults() error {
+	if len(cliApp.policyPaths) < 1 {
+		return fmt.Errorf("policy paths are not specified")
+	}
+	if _, exists := presenter.AvailableFormats[cliApp.outputFormat]; !exists {
+		return fmt.Errorf(
+			"output format %q is not supported. Supported formats are: %s",
+			cliApp.outputFormat, presenter.AvailableFormatsHelp(),
+		)
+	}
+
+	return nil
+}
+
+func (cliApp *cliApp) Run() error {
+	if err := cliApp.defaults(); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	report, err := engine.RunPolicyTests(
+		ctx,
+		cliApp.policyPaths,
+		utils.Map(cliApp.dataPaths, policy.ParseDataPath),
+		engine.PolicyTestOptions{
+			Filter:   cliApp.runFilter,
+			Coverage: cliApp.coverage,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("run policy tests: %w", err)
+	}
+
+	if err := cliApp.writeReport(report); err != nil {
+		return err
+	}
+
+	return checkPolicyTestResults(report.Results)
+}
+
+func (cliApp *cliApp) writeReport(report engine.PolicyTestReport) error {
+	format := strings.ToLower(cliApp.outputFormat)
+
+	if report.Coverage == nil || format == presenter.FormatText {
+		if err := presenter.QueryResultsList(format, report.Results).
+			WriteQueryResultTo(cliApp.stdout); err != nil {
+			return fmt.Errorf("write test results: %w", err)
+		}
+	}
+
+	if report.Coverage != nil {
+		if err := presenter.CoverageReport(format, *report.Coverage).
+			WriteQueryResultTo(cliApp.stdout); err != nil {
+			return fmt.Errorf("write coverage report: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func checkPolicyTestResults(results []result.QueryResults) error {
+	countFailures := 0
+	countErrors := 0
+	for _, result := range results {
+		countFailures += len(result.Failures)
+		countErrors += len(result.Errors)
+	}
+	if countFailures < 1 && countErrors < 1 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: found %d failure(s), %d error(s)", errTestFailure, countFailures, countErrors)
+}
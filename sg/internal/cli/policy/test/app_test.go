@@ -0,0 +1,111 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/ShieldGuard/sg/internal/result/presenter"
+)
+
+func resolveTestdataPath(t *testing.T, paths ...string) string {
+	t.Helper()
+	absPath, err := filepath.Abs(filepath.Join(paths...))
+	if err != nil {
+		t.Fatalf("resolve testdata path: %v", err)
+	}
+	return absPath
+}
+
+func withDebugOutput(w io.Writer) io.Writer {
+	return io.MultiWriter(w, os.Stderr)
+}
+
+func newTestCliApp(t *testing.T, ms ...func(*cliApp)) (*cliApp, *bytes.Buffer) {
+	output := new(bytes.Buffer)
+
+	cliApp := newCliApp(func(cliApp *cliApp) {
+		cliApp.policyPaths = []string{resolveTestdataPath(t, "testdata", "basic", "policy")}
+		cliApp.dataPaths = []string{resolveTestdataPath(t, "testdata", "basic", "data")}
+		cliApp.outputFormat = presenter.FormatJSON
+		cliApp.stdout = withDebugOutput(output)
+	})
+	for _, m := range ms {
+		m(cliApp)
+	}
+
+	return cliApp, output
+}
+
+func Test_cliApp_defaults(t *testing.T) {
+	app, _ := newTestCliApp(t, func(cliApp *cliApp) {
+		cliApp.policyPaths = nil
+	})
+	assert.Error(t, app.defaults(), "should require policy paths")
+
+	app, _ = newTestCliApp(t, func(cliApp *cliApp) {
+		cliApp.outputFormat = "unknown"
+	})
+	assert.Error(t, app.defaults(), "should reject unknown output format")
+}
+
+func Test_cliApp_Run(t *testing.T) {
+	cliApp, output := newTestCliApp(t)
+
+	err := cliApp.Run()
+	assert.ErrorIs(t, err, errTestFailure)
+	assert.Equal(t, "policy test failed: found 1 failure(s), 0 error(s)", err.Error())
+
+	var parsed []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	assert.Len(t, parsed, 1)
+	assert.EqualValues(t, 3, parsed[0]["success"])
+}
+
+func Test_cliApp_Run_coverage(t *testing.T) {
+	cliApp, output := newTestCliApp(t, func(cliApp *cliApp) {
+		cliApp.runFilter = "latest_tag"
+		cliApp.coverage = true
+	})
+
+	assert.NoError(t, cliApp.Run())
+
+	var report struct {
+		Coverage float64 `json:"coverage"`
+		Packages []struct {
+			Files []struct {
+				Rules []struct {
+					Name  string `json:"name"`
+					Fired bool   `json:"fired"`
+				} `json:"rules"`
+			} `json:"files"`
+		} `json:"packages"`
+	}
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &report), "should output coverage report in JSON")
+	assert.Greater(t, report.Coverage, 0.0)
+	assert.Less(t, report.Coverage, 100.0)
+	assert.Len(t, report.Packages, 1)
+	assert.Len(t, report.Packages[0].Files, 1, "test modules should be excluded")
+	rules := report.Packages[0].Files[0].Rules
+	assert.Len(t, rules, 2)
+	assert.Equal(t, "latest_tag", rules[0].Name)
+	assert.True(t, rules[0].Fired)
+	assert.Equal(t, "unapproved_registry", rules[1].Name)
+	assert.False(t, rules[1].Fired, "filtered tests should not fire the rule")
+}
+
+func Test_cliApp_Run_coverageText(t *testing.T) {
+	cliApp, output := newTestCliApp(t, func(cliApp *cliApp) {
+		cliApp.outputFormat = presenter.FormatText
+		cliApp.coverage = true
+	})
+
+	assert.ErrorIs(t, cliApp.Run(), errTestFailure)
+	assert.Contains(t, output.String(), "(deny_unapproved_registry_broken) test failed")
+	assert.Contains(t, output.String(), "coverage: 100.00%")
+}
@@ -0,0 +1,33 @@
+package test
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+)
+
+// CreateCLI creates the CLI for the policy test subcommand.
+func CreateCLI() *cobra.Command {
+	app := newCliApp()
+
+	cmd := &cobra.Command{
+		Use:   "test POLICY-PATH...",
+		Short: "Run the rego unit tests (test_* rules) in the policy packages.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app.policyPaths = args
+			app.stdout = cmd.OutOrStdout()
+
+			appRunErr := app.Run()
+			if errors.Is(appRunErr, errTestFailure) {
+				// the test has ran and failed, but we don't want to show help message
+				cmd.SilenceUsage = true
+			}
+			return appRunErr
+		},
+	}
+
+	app.BindCLIFlags(cmd.Flags())
+
+	return cmd
+}
@@ -0,0 +1,20 @@
+package main
+
+deny_latest_tag[msg] {
+	input.kind == "Deployment"
+	container := input.spec.template.spec.containers[_]
+	endswith(container.image, ":latest")
+	msg := sprintf("container %s uses latest tag", [container.name])
+}
+
+deny_unapproved_registry[msg] {
+	input.kind == "Deployment"
+	container := input.spec.template.spec.containers[_]
+	not approved(container.image)
+	msg := sprintf("container %s uses unapproved registry", [container.name])
+}
+
+approved(image) {
+	registry := data.registries[_]
+	startswith(image, registry)
+}
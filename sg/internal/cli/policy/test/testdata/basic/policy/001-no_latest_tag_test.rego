@@ -0,0 +1,26 @@
+package main
+
+deployment(image) := {
+	"kind": "Deployment",
+	"spec": {"template": {"spec": {"containers": [{"name": "app", "image": image}]}}},
+}
+
+test_deny_latest_tag {
+	deny_latest_tag["container app uses latest tag"] with input as deployment("mcr.microsoft.com/app:latest")
+}
+
+test_allow_pinned_tag {
+	count(deny_latest_tag) == 0 with input as deployment("mcr.microsoft.com/app:v1")
+}
+
+test_allow_approved_registry {
+	count(deny_unapproved_registry) == 0 with input as deployment("mcr.microsoft.com/app:v1")
+}
+
+test_deny_unapproved_registry_broken {
+	count(deny_unapproved_registry) == 0 with input as deployment("docker.io/app:v1")
+}
+
+todo_test_deny_init_containers {
+	false
+}
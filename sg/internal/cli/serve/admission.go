@@ -0,0 +1,114 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Azure/ShieldGuard/sg/internal/engine"
+	"github.com/Azure/ShieldGuard/sg/internal/server"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// admissionPath is the path of the validating admission webhook.
+	admissionPath = "/validate"
+	// healthzPath is the path of the health check.
+	healthzPath = "/healthz"
+	// statusPath is the path of the status of the served policies.
+	statusPath = "/v1/status"
+)
+
+// admissionApp is the CLI application for the serve admission subcommand.
+type admissionApp struct {
+	projectSettings *projectSettings
+	httpSettings    *httpSettings
+	targetName      string
+	denyOnErrors    bool
+
+	stdout io.Writer
+}
+
+func newAdmissionApp(ms ...func(*admissionApp)) *admissionApp {
+	rv := &admissionApp{
+		projectSettings: new(projectSettings),
+		httpSettings: &httpSettings{
+			listenAddr: ":8443",
+		},
+	}
+
+	for _, m := range ms {
+		m(rv)
+	}
+
+	return rv
+}
+
+func (app *admissionApp) BindCLIFlags(fs *pflag.FlagSet) {
+	app.projectSettings.BindCLIFlags(fs)
+	app.httpSettings.BindCLIFlags(fs)
+	fs.StringVarP(
+		&app.targetName, "target", "t", "",
+		"Name of the target in the project spec to serve the policies of. Required if the project has more than one target.",
+	)
+	fs.BoolVarP(
+		&app.denyOnErrors, "fail-on-error", "", false,
+		"Deny the request if any rule fails to evaluate. By default, evaluation errors are returned as warnings.",
+	)
+}
+
+func (app *admissionApp) defaults() error {
+	if err := app.projectSettings.defaults(); err != nil {
+		return err
+	}
+	return app.httpSettings.defaults()
+}
+
+// handler creates the HTTP handler serving the webhook, and returns the queryers keyed by the target name.
+func (app *admissionApp) handler() (http.Handler, map[string]*engine.ReloadableQueryer, error) {
+	projectSpec, err := app.projectSettings.readProjectSpec()
+	if err != nil {
+		return nil, nil, err
+	}
+	target, err := resolveTarget(projectSpec, app.targetName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	queryer, err := app.projectSettings.newQueryer(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	queryers := map[string]*engine.ReloadableQueryer{target.Name: queryer}
+
+	mux := http.NewServeMux()
+	mux.Handle(admissionPath, server.Admission(queryer, server.AdmissionOptions{
+		DenyOnErrors: app.denyOnErrors,
+	}))
+	mux.Handle(statusPath, server.Status(queryers))
+	mux.Handle(healthzPath, server.Healthz())
+
+	return mux, queryers, nil
+}
+
+func (app *admissionApp) Run(ctx context.Context) error {
+	if err := app.defaults(); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+
+	handler, queryers, err := app.handler()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	app.projectSettings.watchQueryers(ctx, queryers, app.stdout)
+
+	return app.httpSettings.serve(ctx, handler, app.stdout)
+}
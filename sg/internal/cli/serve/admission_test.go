@@ -0,0 +1,141 @@
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testAdmissionApp(t *testing.T, ms ...func(*admissionApp)) *admissionApp {
+	contextRoot, err := filepath.Abs(filepath.Join("testdata", "project"))
+	assert.NoError(t, err)
+
+	return newAdmissionApp(append([]func(*admissionApp){
+		func(app *admissionApp) {
+			app.projectSettings.contextRoot = contextRoot
+			app.projectSettings.projectSpecFile = filepath.Join(contextRoot, "sg-project.yaml")
+			app.targetName = "kubernetes"
+			app.stdout = io.Discard
+		},
+	}, ms...)...)
+}
+
+const testAdmissionReview = `{
+  "apiVersion": "admission.k8s.io/v1",
+  "kind": "AdmissionReview",
+  "request": {
+    "uid": "705ab4f5-6393-11e8-b7cc-42010a800002",
+    "kind": {"group": "", "version": "v1", "kind": "Pod"},
+    "name": "debug",
+    "namespace": "default",
+    "operation": "CREATE",
+    "object": {
+      "apiVersion": "v1",
+      "kind": "Pod",
+      "metadata": {"name": "debug", "namespace": "default"},
+      "spec": {
+        "containers": [
+          {"name": "debug", "image": "busybox:latest", "securityContext": {"privileged": true}}
+        ]
+      }
+    }
+  }
+}`
+
+func Test_admissionApp_defaults(t *testing.T) {
+	cases := []*admissionApp{
+		testAdmissionApp(t, func(app *admissionApp) {
+			app.projectSettings.projectSpecFile = ""
+		}),
+		testAdmissionApp(t, func(app *admissionApp) {
+			app.httpSettings.listenAddr = ""
+		}),
+		testAdmissionApp(t, func(app *admissionApp) {
+			app.httpSettings.tlsCertFile = "tls.crt"
+		}),
+	}
+
+	for idx := range cases {
+		t.Run(fmt.Sprintf("case #%d", idx), func(t *testing.T) {
+			assert.Error(t, cases[idx].defaults())
+		})
+	}
+}
+
+func Test_resolveTarget(t *testing.T) {
+	app := testAdmissionApp(t)
+	projectSpec, err := app.projectSettings.readProjectSpec()
+	assert.NoError(t, err)
+
+	target, err := resolveTarget(projectSpec, "kubernetes")
+	assert.NoError(t, err)
+	assert.Equal(t, "kubernetes", target.Name)
+
+	_, err = resolveTarget(projectSpec, "foobar")
+	assert.Error(t, err)
+
+	_, err = resolveTarget(projectSpec, "")
+	assert.Error(t, err, "target is required for project with multiple targets")
+
+	projectSpec.Files = projectSpec.Files[:1]
+	target, err = resolveTarget(projectSpec, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "kubernetes", target.Name)
+}
+
+func Test_admissionApp_handler(t *testing.T) {
+	app := testAdmissionApp(t)
+	assert.NoError(t, app.defaults())
+
+	handler, _, err := app.handler()
+	assert.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := server.Client().Post(server.URL+admissionPath, "application/json", strings.NewReader(testAdmissionReview))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var review struct {
+		Response struct {
+			UID      string   `json:"uid"`
+			Allowed  bool     `json:"allowed"`
+			Warnings []string `json:"warnings"`
+			Status   struct {
+				Message string `json:"message"`
+			} `json:"status"`
+		} `json:"response"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&review))
+	assert.Equal(t, "705ab4f5-6393-11e8-b7cc-42010a800002", review.Response.UID)
+	assert.False(t, review.Response.Allowed)
+	assert.Equal(
+		t,
+		"denied by ShieldGuard: [deny_privileged] container debug must not run in privileged mode",
+		review.Response.Status.Message,
+	)
+	assert.Equal(t, []string{"[warn_latest_tag] container debug uses latest tag"}, review.Response.Warnings)
+
+	healthzResp, err := server.Client().Get(server.URL + healthzPath)
+	assert.NoError(t, err)
+	healthzResp.Body.Close()
+	assert.Equal(t, http.StatusOK, healthzResp.StatusCode)
+}
+
+func Test_httpSettings_serve(t *testing.T) {
+	s := &httpSettings{listenAddr: "127.0.0.1:0"}
+	assert.NoError(t, s.defaults())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, s.serve(ctx, http.NotFoundHandler(), io.Discard), "should shutdown when the context is cancelled")
+}
@@ -0,0 +1,54 @@
+package serve
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// CreateCLI creates the CLI for the serve subcommand.
+func CreateCLI() *cobra.Command {
+	app := newEvaluateApp()
+
+	cmd := &cobra.Command{
+		Use:   "serve [PROJECT-PATH]",
+		Short: "Serve policy checks of the project targets over HTTP.",
+		Long: `Serve policy checks of the project targets over HTTP.
+
+Configurations posted to ` + evaluatePath + `?target=<name> are tested against the policies of the target,
+and the results are returned in the JSON output format of the test subcommand.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app.projectSettings.contextRoot = args[0]
+			app.stdout = cmd.OutOrStdout()
+
+			return app.Run(cmd.Context())
+		},
+	}
+
+	app.BindCLIFlags(cmd.Flags())
+
+	cmd.AddCommand(
+		createAdmissionCLI(),
+	)
+
+	return cmd
+}
+
+func createAdmissionCLI() *cobra.Command {
+	app := newAdmissionApp()
+
+	cmd := &cobra.Command{
+		Use:   "admission [PROJECT-PATH]",
+		Short: "Serve the policies of a target as a Kubernetes validating admission webhook.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app.projectSettings.contextRoot = args[0]
+			app.stdout = cmd.OutOrStdout()
+
+			return app.Run(cmd.Context())
+		},
+	}
+
+	app.BindCLIFlags(cmd.Flags())
+
+	return cmd
+}
@@ -0,0 +1,103 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Azure/ShieldGuard/sg/internal/engine"
+	"github.com/Azure/ShieldGuard/sg/internal/server"
+	"github.com/spf13/pflag"
+)
+
+// evaluatePath is the path of the evaluation API.
+const evaluatePath = "/v1/evaluate"
+
+// evaluateApp is the CLI application for the serve subcommand.
+type evaluateApp struct {
+	projectSettings *projectSettings
+	httpSettings    *httpSettings
+
+	stdout io.Writer
+}
+
+func newEvaluateApp(ms ...func(*evaluateApp)) *evaluateApp {
+	rv := &evaluateApp{
+		projectSettings: new(projectSettings),
+		httpSettings: &httpSettings{
+			listenAddr: ":8080",
+		},
+	}
+
+	for _, m := range ms {
+		m(rv)
+	}
+
+	return rv
+}
+
+func (app *evaluateApp) BindCLIFlags(fs *pflag.FlagSet) {
+	app.projectSettings.BindCLIFlags(fs)
+	app.httpSettings.BindCLIFlags(fs)
+}
+
+func (app *evaluateApp) defaults() error {
+	if err := app.projectSettings.defaults(); err != nil {
+		return err
+	}
+	return app.httpSettings.defaults()
+}
+
+// handler creates the HTTP handler serving the evaluation API, and returns the queryers keyed by the target name.
+func (app *evaluateApp) handler() (http.Handler, map[string]*engine.ReloadableQueryer, error) {
+	projectSpec, err := app.projectSettings.readProjectSpec()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	queryers := make(map[string]*engine.ReloadableQueryer, len(projectSpec.Files))
+	evaluateQueryers := make(map[string]engine.Queryer, len(projectSpec.Files))
+	for _, target := range projectSpec.Files {
+		if _, exists := queryers[target.Name]; exists {
+			return nil, nil, fmt.Errorf("target %q is defined more than once", target.Name)
+		}
+		queryer, err := app.projectSettings.newQueryer(target)
+		if err != nil {
+			return nil, nil, err
+		}
+		queryers[target.Name] = queryer
+		evaluateQueryers[target.Name] = &relativeQueryer{
+			Queryer:     queryer,
+			contextRoot: app.projectSettings.contextRoot,
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(evaluatePath, server.Evaluate(evaluateQueryers))
+	mux.Handle(statusPath, server.Status(queryers))
+	mux.Handle(healthzPath, server.Healthz())
+
+	return mux, queryers, nil
+}
+
+func (app *evaluateApp) Run(ctx context.Context) error {
+	if err := app.defaults(); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+
+	handler, queryers, err := app.handler()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	app.projectSettings.watchQueryers(ctx, queryers, app.stdout)
+
+	return app.httpSettings.serve(ctx, handler, app.stdout)
+}
@@ -0,0 +1,110 @@
+package serve
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testEvaluateApp(t *testing.T, ms ...func(*evaluateApp)) *evaluateApp {
+	contextRoot, err := filepath.Abs(filepath.Join("testdata", "project"))
+	assert.NoError(t, err)
+
+	return newEvaluateApp(append([]func(*evaluateApp){
+		func(app *evaluateApp) {
+			app.projectSettings.contextRoot = contextRoot
+			app.projectSettings.projectSpecFile = filepath.Join(contextRoot, "sg-project.yaml")
+			app.stdout = io.Discard
+		},
+	}, ms...)...)
+}
+
+const testPrivilegedPodYAML = `apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+  namespace: default
+spec:
+  containers:
+  - name: debug
+    image: busybox:latest
+    securityContext:
+      privileged: true
+`
+
+func Test_evaluateApp_handler(t *testing.T) {
+	app := testEvaluateApp(t)
+	assert.NoError(t, app.defaults())
+
+	handler, _, err := app.handler()
+	assert.NoError(t, err)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	for target, expectedFailure := range map[string]struct{ query, pkg string }{
+		"kubernetes": {"data.main.deny_privileged", "fs:policy"},
+		"pss":        {"data.main.deny_privileged_containers", "builtin:pss/baseline"},
+	} {
+		t.Run(target, func(t *testing.T) {
+			resp, err := server.Client().Post(
+				server.URL+evaluatePath+"?target="+target+"&name=debug.yaml",
+				"application/yaml",
+				strings.NewReader(testPrivilegedPodYAML),
+			)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var results []struct {
+				Filename string `json:"filename"`
+				Failures []struct {
+					Query   string `json:"query"`
+					Package string `json:"package"`
+				} `json:"failures"`
+			}
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&results))
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, "debug.yaml", results[0].Filename)
+				failures := make(map[string]string, len(results[0].Failures))
+				for _, failure := range results[0].Failures {
+					failures[failure.Query] = failure.Package
+				}
+				assert.Equal(t, expectedFailure.pkg, failures[expectedFailure.query], "package should be relative to the context root")
+			}
+		})
+	}
+
+	t.Run("status", func(t *testing.T) {
+		resp, err := server.Client().Get(server.URL + statusPath)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var status struct {
+			Targets map[string]struct {
+				CompilerKey string `json:"compiler_key"`
+			} `json:"targets"`
+		}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+		assert.Len(t, status.Targets, 2)
+		for target, targetStatus := range status.Targets {
+			assert.NotEmpty(t, targetStatus.CompilerKey, target)
+		}
+	})
+}
+
+func Test_evaluateApp_handler_invalidProject(t *testing.T) {
+	app := testEvaluateApp(t, func(app *evaluateApp) {
+		app.projectSettings.projectSpecFile = filepath.Join(app.projectSettings.contextRoot, "not-found.yaml")
+	})
+	assert.NoError(t, app.defaults())
+
+	_, _, err := app.handler()
+	assert.Error(t, err)
+}
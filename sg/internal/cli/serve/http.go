@@ -0,0 +1,85 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 10 * time.Second
+)
+
+// httpSettings controls the HTTP(S) listener.
+type httpSettings struct {
+	listenAddr  string
+	tlsCertFile string
+	tlsKeyFile  string
+}
+
+func (s *httpSettings) BindCLIFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&s.listenAddr, "listen", "", s.listenAddr, "Address to listen on.")
+	fs.StringVarP(
+		&s.tlsCertFile, "tls-cert-file", "", "",
+		"Path to the TLS certificate file. When specified with --tls-key-file, the server is served over HTTPS.",
+	)
+	fs.StringVarP(&s.tlsKeyFile, "tls-key-file", "", "", "Path to the TLS private key file.")
+}
+
+func (s *httpSettings) defaults() error {
+	if s.listenAddr == "" {
+		return fmt.Errorf("listen address is not specified")
+	}
+	if (s.tlsCertFile == "") != (s.tlsKeyFile == "") {
+		return fmt.Errorf("--tls-cert-file and --tls-key-file must be specified together")
+	}
+	return nil
+}
+
+// serve serves the handler until the context is cancelled.
+func (s *httpSettings) serve(ctx context.Context, handler http.Handler, stdout io.Writer) error {
+	listener, err := net.Listen("tcp", s.listenAddr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", s.listenAddr, err)
+	}
+
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if s.tlsCertFile != "" {
+			fmt.Fprintf(stdout, "serving on https://%s\n", listener.Addr())
+			serveErr <- server.ServeTLS(listener, s.tlsCertFile, s.tlsKeyFile)
+			return
+		}
+		fmt.Fprintf(stdout, "serving on http://%s\n", listener.Addr())
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve: %w", err)
+	}
+
+	return nil
+}
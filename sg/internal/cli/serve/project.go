@@ -0,0 +1,138 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+
+	"github.com/Azure/ShieldGuard/sg/internal/engine"
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/project"
+	"github.com/spf13/pflag"
+)
+
+// projectSettings specifies the project to serve the policies of.
+type projectSettings struct {
+	projectSpecFile          string
+	contextRoot              string
+	parseArmTemplateDefaults bool
+	watch                    bool
+}
+
+func (s *projectSettings) BindCLIFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&s.projectSpecFile, "config", "c", project.SpecFileName, "Path to the project spec file.")
+	fs.BoolVarP(&s.parseArmTemplateDefaults, "parse-defaults", "p", false, "Parse default values from arm templates (experimental).")
+	fs.BoolVarP(
+		&s.watch, "watch", "w", false,
+		"Reload the policies of the targets when the policy packages or the data files change. Failed reloads keep serving the previous policies.",
+	)
+}
+
+func (s *projectSettings) defaults() error {
+	var err error
+
+	if s.projectSpecFile == "" {
+		return fmt.Errorf("project spec file is not specified")
+	}
+	s.projectSpecFile, err = filepath.Abs(s.projectSpecFile)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path of the project spec file: %w", err)
+	}
+
+	if s.contextRoot == "" {
+		s.contextRoot = "."
+	}
+	s.contextRoot, err = filepath.Abs(s.contextRoot)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path of the context root: %w", err)
+	}
+
+	return nil
+}
+
+func (s *projectSettings) readProjectSpec() (project.Spec, error) {
+	projectSpec, err := project.ReadFromFile(s.projectSpecFile)
+	if err != nil {
+		return project.Spec{}, fmt.Errorf("read project spec: %w", err)
+	}
+	return projectSpec, nil
+}
+
+// newQueryer creates the long-lived queryer of the target, the policies are compiled once at start
+// and recompiled on changes when watching (see watchQueryers).
+func (s *projectSettings) newQueryer(target project.FileTargetSpec) (*engine.ReloadableQueryer, error) {
+	queryer, err := engine.NewReloadableQueryer(
+		func() *engine.QueryerBuilder { return s.queryerBuilder(target) },
+		project.PolicyFilePaths(s.contextRoot, target),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create queryer for target (%s): %w", target.Name, err)
+	}
+	return queryer, nil
+}
+
+// watchQueryers reloads the queryers on changes in background until the context is cancelled.
+// It's a no-op if watching is not enabled.
+func (s *projectSettings) watchQueryers(
+	ctx context.Context,
+	queryers map[string]*engine.ReloadableQueryer,
+	stdout io.Writer,
+) {
+	if !s.watch {
+		return
+	}
+
+	var mu sync.Mutex
+	logf := func(format string, args ...any) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(stdout, format+"\n", args...)
+	}
+
+	for name, queryer := range queryers {
+		go func() {
+			err := queryer.Watch(ctx, func(status engine.ReloadStatus) {
+				if status.LastReloadError != nil {
+					logf("reload target (%s) failed, keep serving %s: %s", name, status.CompilerKey, status.LastReloadError)
+					return
+				}
+				logf("reloaded target (%s): %s", name, status.CompilerKey)
+			})
+			if err != nil {
+				logf("watch target (%s) failed: %s", name, err)
+			}
+		}()
+	}
+}
+
+// queryerBuilder creates the builder for the queryer of the target.
+func (s *projectSettings) queryerBuilder(target project.FileTargetSpec) *engine.QueryerBuilder {
+	return engine.QueryWithPolicy(project.ResolvePolicyPaths(s.contextRoot, target)).
+		WithData(project.ResolveDataPaths(s.contextRoot, target)).
+		WithNamespaces(target.Namespaces).
+		WithRuleSelector(policy.RuleSelector{
+			Include: target.Rules.Include,
+			Exclude: target.Rules.Exclude,
+		}).
+		QueryWithParsingArmTemplateDefaults(s.parseArmTemplateDefaults)
+}
+
+// resolveTarget returns the target with the name from the project spec.
+// The name can be omitted if the project has only one target.
+func resolveTarget(projectSpec project.Spec, name string) (project.FileTargetSpec, error) {
+	if name == "" {
+		if len(projectSpec.Files) != 1 {
+			return project.FileTargetSpec{}, fmt.Errorf("project has %d targets, --target is required", len(projectSpec.Files))
+		}
+		return projectSpec.Files[0], nil
+	}
+
+	for _, target := range projectSpec.Files {
+		if target.Name == name {
+			return target, nil
+		}
+	}
+	return project.FileTargetSpec{}, fmt.Errorf("target %q is not found in the project spec", name)
+}
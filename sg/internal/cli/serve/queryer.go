@@ -0,0 +1,41 @@
+package serve
+
+import (
+	"context"
+
+	"github.com/Azure/ShieldGuard/sg/internal/engine"
+	"github.com/Azure/ShieldGuard/sg/internal/project"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+	"github.com/Azure/ShieldGuard/sg/internal/source"
+)
+
+// relativeQueryer updates the policy package ids of the results to be relative to the context root,
+// so the paths of the server are not exposed in the responses.
+type relativeQueryer struct {
+	engine.Queryer
+	contextRoot string
+}
+
+var _ engine.Queryer = (*relativeQueryer)(nil)
+
+func (q *relativeQueryer) Query(
+	ctx context.Context,
+	source source.Source,
+	opts ...*engine.QueryOptions,
+) (result.QueryResults, error) {
+	queryResults, err := q.Queryer.Query(ctx, source, opts...)
+	if err != nil {
+		return queryResults, err
+	}
+
+	for _, results := range [][]result.Result{
+		queryResults.Passes, queryResults.Failures, queryResults.Warnings, queryResults.Exceptions,
+		queryResults.Skipped, queryResults.Errors,
+	} {
+		for idx := range results {
+			results[idx].Package = project.RelativePackageID(q.contextRoot, results[idx].Package)
+		}
+	}
+
+	return queryResults, nil
+}
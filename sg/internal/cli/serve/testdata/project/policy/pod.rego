@@ -0,0 +1,17 @@
+package main
+
+deny_privileged[msg] {
+	input.kind == "Pod"
+	container := input.spec.containers[_]
+	container.securityContext.privileged == true
+
+	msg := sprintf("container %s must not run in privileged mode", [container.name])
+}
+
+warn_latest_tag[msg] {
+	input.kind == "Pod"
+	container := input.spec.containers[_]
+	endswith(container.image, ":latest")
+
+	msg := sprintf("container %s uses latest tag", [container.name])
+}
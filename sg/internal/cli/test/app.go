@@ -5,14 +5,22 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/Azure/ShieldGuard/sg/internal/baseline"
 	"github.com/Azure/ShieldGuard/sg/internal/engine"
+	"github.com/Azure/ShieldGuard/sg/internal/gitdiff"
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
 	"github.com/Azure/ShieldGuard/sg/internal/project"
 	"github.com/Azure/ShieldGuard/sg/internal/result"
 	"github.com/Azure/ShieldGuard/sg/internal/result/presenter"
 	"github.com/Azure/ShieldGuard/sg/internal/source"
 	"github.com/Azure/ShieldGuard/sg/internal/utils"
+	"github.com/Azure/ShieldGuard/sg/internal/waiver"
 	"github.com/sourcegraph/conc/iter"
 	"github.com/spf13/pflag"
 )
@@ -22,6 +30,8 @@ var errTestFailure = errors.New("test failed")
 type failSettings struct {
 	noFail         bool
 	failOnWarnings bool
+	failOnErrors   bool
+	failOnSeverity string
 }
 
 func (s *failSettings) BindCLIFlags(fs *pflag.FlagSet) {
@@ -29,12 +39,36 @@ func (s *failSettings) BindCLIFlags(fs *pflag.FlagSet) {
 		&s.failOnWarnings, "fail-on-warn", false,
 		"Fail the command if any query returns warnings.",
 	)
+	fs.BoolVar(
+		&s.failOnErrors, "fail-on-error", false,
+		"Fail the command if any rule fails to evaluate.",
+	)
+	fs.StringVar(
+		&s.failOnSeverity, "fail-on-severity", "",
+		fmt.Sprintf(
+			"Fail the command if any warning is at or above the severity. Failures always fail the command. Available levels: %s",
+			policy.SeveritiesHelp(),
+		),
+	)
 	fs.BoolVar(
 		&s.noFail, "no-fail", false,
-		"Do not fail the command if any query fails. When specified to true, it suppress the --fail-on-warn flag.",
+		"Do not fail the command if any query fails. When specified to true, it suppress the --fail-on-warn, --fail-on-error and --fail-on-severity flags.",
 	)
 }
 
+func (s *failSettings) defaults() error {
+	if s.failOnSeverity == "" {
+		return nil
+	}
+
+	severity, err := policy.ParseSeverity(s.failOnSeverity)
+	if err != nil {
+		return fmt.Errorf("parse --fail-on-severity: %w", err)
+	}
+	s.failOnSeverity = string(severity)
+	return nil
+}
+
 func (s *failSettings) CheckQueryResults(results []result.QueryResults) error {
 	if s.noFail {
 		return nil
@@ -42,24 +76,46 @@ func (s *failSettings) CheckQueryResults(results []result.QueryResults) error {
 
 	countFailures := 0
 	countWarnings := 0
+	countErrors := 0
 	for _, result := range results {
 		countFailures += len(result.Failures)
 		countWarnings += len(result.Warnings)
+		countErrors += len(result.Errors)
 	}
-	if countFailures < 1 && countWarnings < 1 {
+	if countFailures < 1 && countWarnings < 1 && countErrors < 1 {
 		return nil
 	}
 
-	err := fmt.Errorf(
-		"%w: found %d failure(s), %d warning(s)",
-		errTestFailure, countFailures, countWarnings,
-	)
+	errMsg := fmt.Sprintf("found %d failure(s), %d warning(s)", countFailures, countWarnings)
+	if countErrors > 0 {
+		errMsg += fmt.Sprintf(", %d error(s)", countErrors)
+	}
+	err := fmt.Errorf("%w: %s", errTestFailure, errMsg)
+
 	if countFailures > 0 {
 		return err
 	}
 	if s.failOnWarnings && countWarnings > 0 {
 		return err
 	}
+	if s.failOnSeverity != "" {
+		// NOTE: severity threshold only applies to warnings, failures always fail the command
+		threshold := policy.Severity(s.failOnSeverity)
+		countAboveThreshold := 0
+		for _, queryResults := range results {
+			for _, r := range queryResults.Warnings {
+				if r.Severity.AtLeast(threshold) {
+					countAboveThreshold += 1
+				}
+			}
+		}
+		if countAboveThreshold > 0 {
+			return fmt.Errorf("%w, %d warning(s) at or above %s severity", err, countAboveThreshold, threshold)
+		}
+	}
+	if s.failOnErrors && countErrors > 0 {
+		return err
+	}
 
 	return nil
 }
@@ -71,15 +127,27 @@ type cliApp struct {
 	outputFormat             string
 	failSettings             *failSettings
 	enableQueryCache         bool
+	queryCacheDir            string
+	queryCacheMaxSizeMB      int64
 	parseArmTemplateDefaults bool
+	coverage                 bool
+	coverageOutput           string
+	explainRules             []string
+	baselineFile             string
+	writeBaseline            bool
+	watch                    bool
+	changedSince             string
 
 	stdout io.Writer
+	// now returns the current time for checking the waivers expiry.
+	now func() time.Time
 }
 
 func newCliApp(ms ...func(*cliApp)) *cliApp {
 	rv := &cliApp{
 		outputFormat: presenter.FormatJSON,
 		failSettings: new(failSettings),
+		now:          time.Now,
 	}
 
 	for _, m := range ms {
@@ -94,6 +162,10 @@ func (cliApp *cliApp) Run() error {
 		return fmt.Errorf("defaults: %w", err)
 	}
 
+	if cliApp.watch {
+		return cliApp.runWatch()
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -102,20 +174,56 @@ func (cliApp *cliApp) Run() error {
 		return fmt.Errorf("read project spec: %w", err)
 	}
 
-	queryCache := engine.NewQueryCache()
+	queryCache, err := cliApp.newQueryCache()
+	if err != nil {
+		return err
+	}
+
+	var coverageTracer *engine.CoverageTracer
+	if cliApp.coverage {
+		coverageTracer = engine.NewCoverageTracer()
+	}
+
+	waivers, err := cliApp.readWaivers(projectSpec)
+	if err != nil {
+		return err
+	}
+
+	var changedFiles []string
+	if cliApp.changedSince != "" {
+		changedFiles, err = gitdiff.ChangedFiles(cliApp.contextRoot, cliApp.changedSince, cliApp.changedSincePaths(projectSpec))
+		if err != nil {
+			return fmt.Errorf("read changed files: %w", err)
+		}
+	}
 
 	var queryResultsList []result.QueryResults
 	for _, target := range projectSpec.Files {
-		queryResult, err := cliApp.queryFileTarget(ctx, cliApp.contextRoot, target, queryCache)
+		sourcePaths := utils.Map(target.Paths, project.ResolveToContextRootFn(cliApp.contextRoot))
+		if cliApp.changedSince != "" {
+			sourcePaths = cliApp.changedSourcePaths(target, changedFiles)
+			if len(sourcePaths) < 1 {
+				// no changes to test for the target
+				continue
+			}
+		}
+
+		queryResult, err := cliApp.queryFileTarget(ctx, cliApp.contextRoot, target, sourcePaths, queryCache, coverageTracer)
 		if err != nil {
 			return fmt.Errorf("run target (%s): %w", target.Name, err)
 		}
 		queryResultsList = append(queryResultsList, queryResult...)
 	}
 
-	if err := presenter.QueryResultsList(cliApp.outputFormat, queryResultsList).
-		WriteQueryResultTo(cliApp.stdout); err != nil {
-		return fmt.Errorf("write query results: %w", err)
+	queryResultsList, err = cliApp.presentResults(queryResultsList, waivers)
+	if err != nil {
+		return err
+	}
+
+	if coverageTracer != nil {
+		if err := cliApp.writeCoverageReport(coverageTracer.Report()); err != nil {
+			return fmt.Errorf("write coverage report: %w", err)
+		}
 	}
 
 	if err := cliApp.failSettings.CheckQueryResults(queryResultsList); err != nil {
@@ -132,6 +240,42 @@ func (cliApp *cliApp) BindCLIFlags(fs *pflag.FlagSet) {
 		fmt.Sprintf("Output format. Available formats: %s", presenter.AvailableFormatsHelp()),
 	)
 	fs.BoolVarP(&cliApp.enableQueryCache, "enable-query-cache", "", false, "Enable query cache (experimental).")
+	fs.StringVarP(
+		&cliApp.queryCacheDir, "query-cache-dir", "", "",
+		"Directory to persist the query cache across runs. When specified, it implies --enable-query-cache (experimental).",
+	)
+	fs.Int64VarP(
+		&cliApp.queryCacheMaxSizeMB, "query-cache-max-size", "", engine.DefaultDiskQueryCacheMaxSize/1024/1024,
+		"Size bound (in MiB) of the query cache directory. Least recently used entries are evicted when exceeded.",
+	)
+	fs.BoolVarP(
+		&cliApp.coverage, "coverage", "", false,
+		"Report the policy lines and rules evaluated against the sources. Query cache is bypassed when enabled.",
+	)
+	fs.StringVarP(
+		&cliApp.coverageOutput, "coverage-output", "", "",
+		"Path to write the coverage report to. Defaults to write after the query results. When specified, it implies --coverage.",
+	)
+	fs.StringArrayVarP(
+		&cliApp.explainRules, "explain", "", nil,
+		"Explain the results of the rule (e.g. deny_foo or foo) with the evaluated expressions and input values. Can be specified multiple times.",
+	)
+	fs.StringVarP(
+		&cliApp.baselineFile, "baseline", "", "",
+		"Path to the baseline file. Failures and warnings recorded in the baseline are reported as baselined and don't fail the command.",
+	)
+	fs.BoolVarP(
+		&cliApp.writeBaseline, "write-baseline", "", false,
+		"Record the current failures and warnings to the baseline file specified by --baseline.",
+	)
+	fs.BoolVarP(
+		&cliApp.watch, "watch", "w", false,
+		"Watch the target paths, the policies and the project spec, and re-run the affected targets on changes. It implies --enable-query-cache.",
+	)
+	fs.StringVarP(
+		&cliApp.changedSince, "changed-since", "", "",
+		"Only test the files changed since the git revision (e.g. main or HEAD~1), including uncommitted changes. Targets with changed policies or data are tested fully.",
+	)
 	fs.BoolVarP(&cliApp.parseArmTemplateDefaults, "parse-defaults", "p", false, "Parse default values from arm templates (experimental).")
 	cliApp.failSettings.BindCLIFlags(fs)
 }
@@ -155,6 +299,42 @@ func (cliApp *cliApp) defaults() error {
 		return fmt.Errorf("failed to get absolute path of the context root: %w", err)
 	}
 
+	if cliApp.queryCacheDir != "" {
+		cliApp.enableQueryCache = true
+	}
+
+	if cliApp.coverageOutput != "" {
+		cliApp.coverage = true
+	}
+
+	if cliApp.writeBaseline && cliApp.baselineFile == "" {
+		return fmt.Errorf("--write-baseline requires --baseline to be specified")
+	}
+
+	if cliApp.changedSince != "" && cliApp.writeBaseline {
+		// NOTE: the baseline would miss the results of the unchanged files
+		return fmt.Errorf("--changed-since can't be used with --write-baseline")
+	}
+
+	if cliApp.watch {
+		if cliApp.coverage {
+			return fmt.Errorf("--watch can't be used with --coverage")
+		}
+		if cliApp.writeBaseline {
+			return fmt.Errorf("--watch can't be used with --write-baseline")
+		}
+		if cliApp.changedSince != "" {
+			return fmt.Errorf("--watch can't be used with --changed-since")
+		}
+		// NOTE: re-running a target with unchanged policies (e.g. after the project spec changes)
+		//       queries the unchanged sources from the cache
+		cliApp.enableQueryCache = true
+	}
+
+	if err := cliApp.failSettings.defaults(); err != nil {
+		return err
+	}
+
 	if _, exists := presenter.AvailableFormats[cliApp.outputFormat]; !exists {
 		return fmt.Errorf(
 			"output format %q is not supported. Supported formats are: %s",
@@ -169,47 +349,233 @@ func (cliApp *cliApp) queryFileTarget(
 	ctx context.Context,
 	contextRoot string,
 	target project.FileTargetSpec,
+	sourcePaths []string,
 	queryCache engine.QueryCache,
+	coverageTracer *engine.CoverageTracer,
 ) ([]result.QueryResults, error) {
-	resolveToContextRoot := resolveToContextRootFn(contextRoot)
-
-	policyPaths := utils.Map(target.Policies, resolveToContextRoot)
-	paths := utils.Map(target.Paths, resolveToContextRoot)
-	// TODO: load data paths
-	// dataPaths := utils.Map(target.Data, resolveToContextRoot)
-
-	sources, err := source.FromPath(paths).ContextRoot(contextRoot).Complete()
+	sources, err := source.FromPath(sourcePaths).ContextRoot(contextRoot).Complete()
 	if err != nil {
 		return nil, fmt.Errorf("load sources failed: %w", err)
 	}
 
-	qb := engine.QueryWithPolicy(policyPaths)
+	queryer, err := cliApp.newFileTargetQueryer(contextRoot, target, queryCache, coverageTracer)
+	if err != nil {
+		return nil, err
+	}
+
+	return cliApp.querySources(ctx, queryer, sources)
+}
+
+// changedSincePaths returns the paths to check for changes, which are the project spec,
+// and the target paths, policies and data of the targets.
+func (cliApp *cliApp) changedSincePaths(projectSpec project.Spec) []string {
+	rv := []string{cliApp.projectSpecFile}
+	for _, target := range projectSpec.Files {
+		rv = append(rv, utils.Map(target.Paths, project.ResolveToContextRootFn(cliApp.contextRoot))...)
+		rv = append(rv, project.PolicyFilePaths(cliApp.contextRoot, target)...)
+	}
+
+	// NOTE: paths might be shared by targets
+	slices.Sort(rv)
+	return slices.Compact(rv)
+}
+
+// changedSourcePaths returns the source files of the target in the changed files.
+// All paths of the target are returned if the project spec, or any policy or data file of the target is changed.
+func (cliApp *cliApp) changedSourcePaths(target project.FileTargetSpec, changedFiles []string) []string {
+	paths := utils.Map(target.Paths, project.ResolveToContextRootFn(cliApp.contextRoot))
+	if slices.Contains(changedFiles, cliApp.projectSpecFile) ||
+		anyUnderPaths(changedFiles, project.PolicyFilePaths(cliApp.contextRoot, target)) {
+		return paths
+	}
+
+	var rv []string
+	for _, p := range changedFiles {
+		if !anyUnderPaths([]string{p}, paths) || !source.FileSupported(p) {
+			continue
+		}
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			// removed files have nothing to test
+			continue
+		}
+		rv = append(rv, p)
+	}
+	return rv
+}
+
+// newFileTargetQueryer compiles the policies of the target.
+func (cliApp *cliApp) newFileTargetQueryer(
+	contextRoot string,
+	target project.FileTargetSpec,
+	queryCache engine.QueryCache,
+	coverageTracer *engine.CoverageTracer,
+) (engine.Queryer, error) {
+	qb := engine.QueryWithPolicy(project.ResolvePolicyPaths(contextRoot, target)).
+		WithData(project.ResolveDataPaths(contextRoot, target)).
+		WithNamespaces(target.Namespaces).
+		WithRuleSelector(policy.RuleSelector{
+			Include: target.Rules.Include,
+			Exclude: target.Rules.Exclude,
+		})
 	if cliApp.enableQueryCache {
 		qb.WithQueueCache(queryCache)
 	}
+	if coverageTracer != nil {
+		qb.WithCoverage(coverageTracer)
+	}
 	qb.QueryWithParsingArmTemplateDefaults(cliApp.parseArmTemplateDefaults)
 
 	queryer, err := qb.Complete()
 	if err != nil {
 		return nil, fmt.Errorf("create queryer failed: %w", err)
 	}
+	return queryer, nil
+}
 
+func (cliApp *cliApp) querySources(
+	ctx context.Context,
+	queryer engine.Queryer,
+	sources []source.Source,
+) ([]result.QueryResults, error) {
 	queryMapper := iter.Mapper[source.Source, result.QueryResults]{
 		MaxGoroutines: len(sources),
 	}
 
 	return queryMapper.MapErr(sources, func(s *source.Source) (result.QueryResults, error) {
-		return queryer.Query(ctx, *s, &engine.QueryOptions{})
+		return queryer.Query(ctx, *s, &engine.QueryOptions{
+			ExplainRules: cliApp.explainRules,
+		})
 	})
 }
 
-func resolveToContextRootFn(contextRoot string) func(string) string {
-	return func(path string) string {
-		// FIXME(hbc): handle absolute paths input
-		//             We should limit the input to be relative to the context root.
+func (cliApp *cliApp) newQueryCache() (engine.QueryCache, error) {
+	if cliApp.queryCacheDir == "" {
+		return engine.NewQueryCache(), nil
+	}
+
+	queryCache, err := engine.NewDiskQueryCache(cliApp.queryCacheDir, cliApp.queryCacheMaxSizeMB*1024*1024)
+	if err != nil {
+		return nil, fmt.Errorf("create query cache: %w", err)
+	}
+	return queryCache, nil
+}
+
+// readWaivers reads the waivers file of the project, if specified.
+func (cliApp *cliApp) readWaivers(projectSpec project.Spec) ([]waiver.Waiver, error) {
+	if projectSpec.Waivers == "" {
+		return nil, nil
+	}
+
+	waivers, err := waiver.ReadFromFile(project.ResolveToContextRootFn(cliApp.contextRoot)(projectSpec.Waivers))
+	if err != nil {
+		return nil, fmt.Errorf("read waivers: %w", err)
+	}
+	return waivers, nil
+}
+
+// presentResults applies the waivers and the baseline to the query results, and writes them to the output.
+// It returns the query results as presented.
+func (cliApp *cliApp) presentResults(
+	queryResultsList []result.QueryResults,
+	waivers []waiver.Waiver,
+) ([]result.QueryResults, error) {
+	// NOTE: waivers and baseline match the package ids relative to the context root
+	cliApp.relativizeResults(queryResultsList)
+	queryResultsList = waiver.Apply(queryResultsList, waivers, cliApp.now())
+	if cliApp.baselineFile != "" {
+		var err error
+		queryResultsList, err = cliApp.applyBaseline(queryResultsList)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := presenter.QueryResultsList(cliApp.outputFormat, queryResultsList).
+		WriteQueryResultTo(cliApp.stdout); err != nil {
+		return nil, fmt.Errorf("write query results: %w", err)
+	}
+
+	return queryResultsList, nil
+}
+
+// applyBaseline moves the results recorded in the baseline to the baselined results.
+// When writing the baseline, the current failures and warnings are recorded first.
+func (cliApp *cliApp) applyBaseline(queryResultsList []result.QueryResults) ([]result.QueryResults, error) {
+	if cliApp.writeBaseline {
+		b := baseline.FromQueryResults(queryResultsList)
+		if err := b.WriteToFile(cliApp.baselineFile); err != nil {
+			return nil, fmt.Errorf("write baseline: %w", err)
+		}
+		return baseline.Apply(queryResultsList, b), nil
+	}
+
+	b, err := baseline.ReadFromFile(cliApp.baselineFile)
+	if err != nil {
+		return nil, fmt.Errorf("read baseline: %w", err)
+	}
+	return baseline.Apply(queryResultsList, b), nil
+}
+
+// writeCoverageReport writes the coverage report with paths relative to the context root.
+func (cliApp *cliApp) writeCoverageReport(report result.CoverageReport) error {
+	relativeToContextRoot := project.RelativeToContextRootFn(cliApp.contextRoot)
+	for i := range report.Packages {
+		p := &report.Packages[i]
+		p.Package = project.RelativePackageID(cliApp.contextRoot, p.Package)
+		for j := range p.Files {
+			p.Files[j].File = relativeToContextRoot(p.Files[j].File)
+		}
+	}
+
+	w := cliApp.stdout
+	if cliApp.coverageOutput != "" {
+		f, err := os.Create(cliApp.coverageOutput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return presenter.CoverageReport(cliApp.outputFormat, report).WriteQueryResultTo(w)
+}
+
+// relativizeResults updates the policy package ids and the policy file paths in the explanations
+// to be relative to the context root.
+func (cliApp *cliApp) relativizeResults(queryResultsList []result.QueryResults) {
+	relativeToContextRoot := project.RelativeToContextRootFn(cliApp.contextRoot)
+	for _, queryResults := range queryResultsList {
+		for _, results := range [][]result.Result{
+			queryResults.Passes, queryResults.Failures, queryResults.Warnings, queryResults.Exceptions,
+			queryResults.Skipped, queryResults.Baselined, queryResults.Errors,
+		} {
+			for idx := range results {
+				results[idx].Package = project.RelativePackageID(cliApp.contextRoot, results[idx].Package)
+				if results[idx].Explanation == nil {
+					continue
+				}
+				steps := results[idx].Explanation.Steps
+				for stepIdx := range steps {
+					steps[stepIdx].File = relativeToContextRoot(steps[stepIdx].File)
+				}
+			}
+		}
+	}
+}
+
+// isUnderPath tells if the path is the parent path or under the parent path.
+func isUnderPath(path string, parent string) bool {
+	return path == parent || strings.HasPrefix(path, parent+string(filepath.Separator))
+}
 
-		fullPath := filepath.Join(contextRoot, path)
-		fullPath = filepath.Clean(fullPath)
-		return fullPath
+// anyUnderPaths tells if any of the paths is under any of the parent paths.
+func anyUnderPaths(paths []string, parents []string) bool {
+	for _, p := range paths {
+		for _, parent := range parents {
+			if isUnderPath(p, parent) {
+				return true
+			}
+		}
 	}
+	return false
 }
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,9 +11,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
 	"github.com/Azure/ShieldGuard/sg/internal/project"
 	"github.com/Azure/ShieldGuard/sg/internal/result"
 	"github.com/Azure/ShieldGuard/sg/internal/result/presenter"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -31,6 +35,31 @@ func Test_failSettings_CheckQueryResults(t *testing.T) {
 			},
 		}
 	}
+	queryResultError := func() result.QueryResults {
+		return result.QueryResults{
+			Errors: []result.Result{
+				{Query: "error"},
+			},
+		}
+	}
+	queryResultWithSeverity := func(failure policy.Severity, warning policy.Severity) result.QueryResults {
+		return result.QueryResults{
+			Failures: []result.Result{
+				{Query: "failure", Severity: failure},
+			},
+			Warnings: []result.Result{
+				{Query: "warning", Severity: warning},
+			},
+		}
+	}
+
+	queryResultWarnWithSeverity := func(warning policy.Severity) result.QueryResults {
+		return result.QueryResults{
+			Warnings: []result.Result{
+				{Query: "warning", Severity: warning},
+			},
+		}
+	}
 
 	cases := []struct {
 		failSettings *failSettings
@@ -78,6 +107,35 @@ func Test_failSettings_CheckQueryResults(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		// 0 failures, 1 error
+		{
+			failSettings: &failSettings{},
+			results: []result.QueryResults{
+				queryResultError(),
+			},
+			expectErr: false,
+		},
+		// failOnErrors = true, 0 failures, 1 error
+		{
+			failSettings: &failSettings{
+				failOnErrors: true,
+			},
+			results: []result.QueryResults{
+				queryResultError(),
+			},
+			expectErr: true,
+		},
+		// noFail = true, failOnErrors = true
+		{
+			failSettings: &failSettings{
+				noFail:       true,
+				failOnErrors: true,
+			},
+			results: []result.QueryResults{
+				queryResultError(),
+			},
+			expectErr: false,
+		},
 		// failOnWarnings = true, 0 failures, 1 warning
 		{
 			failSettings: &failSettings{
@@ -89,6 +147,88 @@ func Test_failSettings_CheckQueryResults(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		// failOnSeverity = high, failure below the threshold
+		{
+			failSettings: &failSettings{
+				failOnSeverity: string(policy.SeverityHigh),
+			},
+			results: []result.QueryResults{
+				queryResultWithSeverity(policy.SeverityLow, policy.SeverityUnspecified),
+			},
+			expectErr: true,
+		},
+		// failOnSeverity = high, warning below the threshold
+		{
+			failSettings: &failSettings{
+				failOnSeverity: string(policy.SeverityHigh),
+			},
+			results: []result.QueryResults{
+				queryResultWarnWithSeverity(policy.SeverityMedium),
+			},
+			expectErr: false,
+		},
+		// failOnSeverity = high, warning at the threshold
+		{
+			failSettings: &failSettings{
+				failOnSeverity: string(policy.SeverityHigh),
+			},
+			results: []result.QueryResults{
+				queryResultWithSeverity(policy.SeverityLow, policy.SeverityHigh),
+			},
+			expectErr: true,
+		},
+		// failOnSeverity = high, failure above the threshold
+		{
+			failSettings: &failSettings{
+				failOnSeverity: string(policy.SeverityHigh),
+			},
+			results: []result.QueryResults{
+				queryResultWithSeverity(policy.SeverityCritical, policy.SeverityInfo),
+			},
+			expectErr: true,
+		},
+		// failOnSeverity = high, failure without severity
+		{
+			failSettings: &failSettings{
+				failOnSeverity: string(policy.SeverityHigh),
+			},
+			results: []result.QueryResults{
+				queryResultFailed(),
+			},
+			expectErr: true,
+		},
+		// failOnSeverity = high, warning without severity
+		{
+			failSettings: &failSettings{
+				failOnSeverity: string(policy.SeverityHigh),
+			},
+			results: []result.QueryResults{
+				queryResultWarn(),
+			},
+			expectErr: false,
+		},
+		// failOnSeverity = high, failOnWarnings = true, warning without severity
+		{
+			failSettings: &failSettings{
+				failOnSeverity: string(policy.SeverityHigh),
+				failOnWarnings: true,
+			},
+			results: []result.QueryResults{
+				queryResultWarn(),
+			},
+			expectErr: true,
+		},
+		// noFail = true, failOnSeverity = info
+		{
+			failSettings: &failSettings{
+				noFail:         true,
+				failOnSeverity: string(policy.SeverityInfo),
+			},
+			results: []result.QueryResults{
+				queryResultWithSeverity(policy.SeverityCritical, policy.SeverityCritical),
+			},
+			expectErr: false,
+		},
 	}
 
 	for idx := range cases {
@@ -148,6 +288,48 @@ func Test_cliApp_defaults(t *testing.T) {
 				cliApp.outputFormat = "foobar"
 			},
 		),
+		newCliApp(
+			validCliApp,
+			func(cliApp *cliApp) {
+				cliApp.failSettings.failOnSeverity = "foobar"
+			},
+		),
+		newCliApp(
+			validCliApp,
+			func(cliApp *cliApp) {
+				cliApp.writeBaseline = true
+			},
+		),
+		newCliApp(
+			validCliApp,
+			func(cliApp *cliApp) {
+				cliApp.watch = true
+				cliApp.coverage = true
+			},
+		),
+		newCliApp(
+			validCliApp,
+			func(cliApp *cliApp) {
+				cliApp.watch = true
+				cliApp.changedSince = "HEAD"
+			},
+		),
+		newCliApp(
+			validCliApp,
+			func(cliApp *cliApp) {
+				cliApp.changedSince = "HEAD"
+				cliApp.baselineFile = "baseline.json"
+				cliApp.writeBaseline = true
+			},
+		),
+		newCliApp(
+			validCliApp,
+			func(cliApp *cliApp) {
+				cliApp.watch = true
+				cliApp.baselineFile = "baseline.json"
+				cliApp.writeBaseline = true
+			},
+		),
 	}
 
 	for idx := range cases {
@@ -240,3 +422,236 @@ exception[rules] {
 
 	assert.NoError(t, runErr)
 }
+
+func Test_cliApp_queryCacheDir(t *testing.T) {
+	queryCacheDir := t.TempDir()
+
+	run := func(t *testing.T) string {
+		output := new(bytes.Buffer)
+		cliApp := newCliApp(
+			func(cliApp *cliApp) {
+				cliApp.contextRoot = resolveTestdataPath(t, "./testdata/basic")
+				cliApp.projectSpecFile = resolveTestdataPath(t, "./testdata/basic/sg-project.yaml")
+				cliApp.queryCacheDir = queryCacheDir
+				cliApp.stdout = output
+			},
+		)
+		assert.ErrorIs(t, cliApp.Run(), errTestFailure)
+		return output.String()
+	}
+
+	firstRunOutput := run(t)
+	entries, err := os.ReadDir(queryCacheDir)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, entries, "query results should be persisted")
+
+	assert.Equal(t, firstRunOutput, run(t), "cached results should produce the same output")
+}
+
+func Test_cliApp_coverage(t *testing.T) {
+	coverageOutput := filepath.Join(t.TempDir(), "coverage.json")
+
+	output := new(bytes.Buffer)
+	cliApp := newCliApp(
+		func(cliApp *cliApp) {
+			cliApp.contextRoot = resolveTestdataPath(t, "./testdata/basic")
+			cliApp.projectSpecFile = resolveTestdataPath(t, "./testdata/basic/sg-project.yaml")
+			cliApp.coverageOutput = coverageOutput
+			cliApp.stdout = output
+		},
+	)
+	assert.ErrorIs(t, cliApp.Run(), errTestFailure)
+	assert.True(t, cliApp.coverage, "coverage output implies coverage")
+
+	var queryResults []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &queryResults), "stdout should only contain query results")
+
+	b, err := os.ReadFile(coverageOutput)
+	assert.NoError(t, err)
+	var report struct {
+		Coverage float64 `json:"coverage"`
+		Packages []struct {
+			Package string `json:"package"`
+			Files   []struct {
+				File  string `json:"file"`
+				Rules []struct {
+					Name  string `json:"name"`
+					Fired bool   `json:"fired"`
+				} `json:"rules"`
+			} `json:"files"`
+		} `json:"packages"`
+	}
+	assert.NoError(t, json.Unmarshal(b, &report))
+	assert.Equal(t, 100.0, report.Coverage)
+	assert.Len(t, report.Packages, 1)
+	assert.Equal(t, "fs:policy", report.Packages[0].Package, "paths should be relative to the context root")
+	assert.Len(t, report.Packages[0].Files, 1)
+	assert.Equal(t, "policy/001-foo.rego", report.Packages[0].Files[0].File)
+	assert.Len(t, report.Packages[0].Files[0].Rules, 2)
+	for _, rule := range report.Packages[0].Files[0].Rules {
+		assert.True(t, rule.Fired, "rule %s should fire", rule.Name)
+	}
+}
+
+func Test_cliApp_explain(t *testing.T) {
+	output := new(bytes.Buffer)
+	cliApp := newCliApp(
+		func(cliApp *cliApp) {
+			cliApp.contextRoot = resolveTestdataPath(t, "./testdata/basic")
+			cliApp.projectSpecFile = resolveTestdataPath(t, "./testdata/basic/sg-project.yaml")
+			cliApp.explainRules = []string{"deny_foo"}
+			cliApp.stdout = output
+		},
+	)
+	assert.ErrorIs(t, cliApp.Run(), errTestFailure)
+
+	var queryResults []struct {
+		Failures []struct {
+			Explanation *struct {
+				Steps []struct {
+					File       string                 `json:"file"`
+					Line       int                    `json:"line"`
+					Expression string                 `json:"expression"`
+					Input      map[string]interface{} `json:"input"`
+				} `json:"steps"`
+			} `json:"explanation"`
+		} `json:"failures"`
+		Warnings []struct {
+			Explanation interface{} `json:"explanation"`
+		} `json:"warnings"`
+	}
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &queryResults))
+	assert.Len(t, queryResults, 1)
+	assert.Len(t, queryResults[0].Failures, 1)
+	explanation := queryResults[0].Failures[0].Explanation
+	if assert.NotNil(t, explanation) && assert.Len(t, explanation.Steps, 2) {
+		assert.Equal(t, "policy/001-foo.rego", explanation.Steps[0].File, "should be relative to the context root")
+		assert.Equal(t, 4, explanation.Steps[0].Line)
+		assert.Equal(t, `input.name = "foo"`, explanation.Steps[0].Expression)
+		assert.Equal(t, map[string]interface{}{"input.name": "foo"}, explanation.Steps[0].Input)
+	}
+	assert.Len(t, queryResults[0].Warnings, 1)
+	assert.Nil(t, queryResults[0].Warnings[0].Explanation, "warn_foo is not explained")
+}
+
+func Test_cliApp_baseline(t *testing.T) {
+	baselineFile := filepath.Join(t.TempDir(), "baseline.json")
+
+	run := func(t *testing.T, writeBaseline bool) ([]map[string]interface{}, error) {
+		output := new(bytes.Buffer)
+		cliApp := newCliApp(
+			func(cliApp *cliApp) {
+				cliApp.contextRoot = resolveTestdataPath(t, "./testdata/basic")
+				cliApp.projectSpecFile = resolveTestdataPath(t, "./testdata/basic/sg-project.yaml")
+				cliApp.baselineFile = baselineFile
+				cliApp.writeBaseline = writeBaseline
+				cliApp.stdout = output
+			},
+		)
+		runErr := cliApp.Run()
+
+		var queryResults []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(output.Bytes(), &queryResults))
+		return queryResults, runErr
+	}
+
+	queryResults, runErr := run(t, true)
+	assert.NoError(t, runErr, "recorded results should not fail the command")
+	if assert.Len(t, queryResults, 1) {
+		assert.Empty(t, queryResults[0]["failures"])
+		assert.Empty(t, queryResults[0]["warnings"])
+		assert.Len(t, queryResults[0]["baselined"], 2)
+	}
+
+	b, err := os.ReadFile(baselineFile)
+	assert.NoError(t, err)
+	var recorded struct {
+		Findings []struct {
+			Fingerprint string `json:"fingerprint"`
+			Package     string `json:"package"`
+			Source      string `json:"source"`
+		} `json:"findings"`
+	}
+	assert.NoError(t, json.Unmarshal(b, &recorded))
+	if assert.Len(t, recorded.Findings, 2) {
+		assert.Equal(t, "configurations/data.yaml", recorded.Findings[0].Source, "should be relative to the context root")
+		assert.Equal(t, "fs:policy", recorded.Findings[0].Package, "should be relative to the context root")
+	}
+
+	queryResults, runErr = run(t, false)
+	assert.NoError(t, runErr)
+	if assert.Len(t, queryResults, 1) {
+		assert.Len(t, queryResults[0]["baselined"], 2)
+	}
+
+	rewritten, err := os.ReadFile(baselineFile)
+	assert.NoError(t, err)
+	assert.Equal(t, string(b), string(rewritten), "baseline should not be modified when reading")
+}
+
+func Test_cliApp_changedSince(t *testing.T) {
+	contextRoot := t.TempDir()
+	repo, err := git.PlainInit(contextRoot, false)
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	writeFile := func(name string, content string) {
+		p := filepath.Join(contextRoot, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		assert.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+	writeFile("sg-project.yaml", `files:
+- name: test
+  paths:
+  - configurations
+  policies:
+  - policy
+`)
+	writeFile("configurations/a.yaml", "name: foo\n")
+	writeFile("configurations/b.yaml", "name: foo\n")
+	writeFile("policy/main.rego", testWatchPolicy)
+	assert.NoError(t, worktree.AddWithOptions(&git.AddOptions{All: true}))
+	_, err = worktree.Commit("initial", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	assert.NoError(t, err)
+
+	run := func(t *testing.T) []string {
+		output := new(bytes.Buffer)
+		cliApp := newCliApp(
+			func(cliApp *cliApp) {
+				cliApp.contextRoot = contextRoot
+				cliApp.projectSpecFile = filepath.Join(contextRoot, "sg-project.yaml")
+				cliApp.changedSince = "HEAD"
+				cliApp.failSettings.noFail = true
+				cliApp.stdout = output
+			},
+		)
+		assert.NoError(t, cliApp.Run())
+
+		var queryResults []struct {
+			Filename string `json:"filename"`
+		}
+		assert.NoError(t, json.Unmarshal(output.Bytes(), &queryResults))
+		var rv []string
+		for _, r := range queryResults {
+			rv = append(rv, filepath.ToSlash(r.Filename))
+		}
+		return rv
+	}
+
+	assert.Empty(t, run(t), "should not test unchanged files")
+
+	writeFile("configurations/a.yaml", "name: bar\n")
+	writeFile("configurations/README.md", "not a source")
+	assert.Equal(t, []string{"configurations/a.yaml"}, run(t), "should test changed files only")
+
+	writeFile("policy/main.rego", testWatchPolicy+"\n# changed\n")
+	assert.Equal(
+		t,
+		[]string{"configurations/a.yaml", "configurations/b.yaml"},
+		run(t),
+		"should test all files of the target with changed policies",
+	)
+}
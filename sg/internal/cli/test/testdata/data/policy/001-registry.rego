@@ -0,0 +1,16 @@
+package main
+
+deny_unapproved_registry[msg] {
+	not approved(input.registry)
+
+	msg = sprintf("registry %s is not approved", [input.registry])
+}
+
+approved(registry) {
+	data.approved_registries[_] == registry
+}
+
+exception[rules] {
+	data.exceptions.teams[input.team].allow_any_registry
+	rules = ["unapproved_registry"]
+}
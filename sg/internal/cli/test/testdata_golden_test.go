@@ -112,6 +112,27 @@ func Test_cliApp_testdataTestSuites(t *testing.T) {
 				expectGoldenOutput("golden-output.json"),
 			},
 		},
+		{
+			Name: "data",
+			Checkers: []testSuiteRunCheckFunc{
+				expectRunErrorWith(1, 0),
+				expectGoldenOutput("golden-output.json"),
+			},
+		},
+		{
+			Name: "builtin",
+			Checkers: []testSuiteRunCheckFunc{
+				expectRunErrorWith(1, 0),
+				expectGoldenOutput("golden-output.json"),
+			},
+		},
+		{
+			Name: "waivers",
+			Checkers: []testSuiteRunCheckFunc{
+				expectRunErrorWith(1, 1),
+				expectGoldenOutput("golden-output.json"),
+			},
+		},
 	}
 
 	for idx := range testSuites {
@@ -0,0 +1,329 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"syscall"
+
+	"github.com/Azure/ShieldGuard/sg/internal/engine"
+	"github.com/Azure/ShieldGuard/sg/internal/project"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+	"github.com/Azure/ShieldGuard/sg/internal/source"
+	"github.com/Azure/ShieldGuard/sg/internal/utils"
+	"github.com/Azure/ShieldGuard/sg/internal/watch"
+)
+
+// watchTarget is the state of a target kept between the watch iterations.
+type watchTarget struct {
+	spec    project.FileTargetSpec
+	queryer engine.Queryer
+	// results is the query results of the target keyed by the source name.
+	results map[string]result.QueryResults
+}
+
+// watchSession re-runs the targets affected by the changes. The compiled policies of the targets
+// and the query cache are reused between the iterations.
+type watchSession struct {
+	cliApp      *cliApp
+	queryCache  engine.QueryCache
+	projectSpec project.Spec
+	targets     []*watchTarget
+}
+
+// runWatch runs the targets, then re-runs the affected targets on changes until interrupted.
+// Results don't fail the command in watch mode.
+func (cliApp *cliApp) runWatch() error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	queryCache, err := cliApp.newQueryCache()
+	if err != nil {
+		return err
+	}
+
+	session := &watchSession{cliApp: cliApp, queryCache: queryCache}
+	if err := session.loadProject(ctx, nil); err != nil {
+		return err
+	}
+	session.present()
+
+	for {
+		w, err := watch.New(session.watchPaths(), watch.DefaultDebounce)
+		if err != nil {
+			return err
+		}
+
+		// NOTE: the watcher is restarted when the project spec changes, as the paths to watch might change
+		watchCtx, stopWatch := context.WithCancel(ctx)
+		projectChanged := false
+		err = w.Run(watchCtx, func(paths []string) {
+			if session.onChange(ctx, paths) {
+				projectChanged = true
+				stopWatch()
+			}
+		})
+		stopWatch()
+		if err != nil {
+			return err
+		}
+		if !projectChanged || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// watchPaths returns the paths to watch, which are the project spec, the waivers,
+// the baseline, and the target paths, policies and data of the targets.
+func (s *watchSession) watchPaths() []string {
+	resolveToContextRoot := project.ResolveToContextRootFn(s.cliApp.contextRoot)
+
+	rv := []string{s.cliApp.projectSpecFile}
+	if s.projectSpec.Waivers != "" {
+		rv = append(rv, resolveToContextRoot(s.projectSpec.Waivers))
+	}
+	if s.cliApp.baselineFile != "" {
+		rv = append(rv, s.cliApp.baselineFile)
+	}
+	for _, t := range s.targets {
+		rv = append(rv, utils.Map(t.spec.Paths, resolveToContextRoot)...)
+		rv = append(rv, project.PolicyFilePaths(s.cliApp.contextRoot, t.spec)...)
+	}
+
+	// NOTE: paths might be shared by targets
+	slices.Sort(rv)
+	return slices.Compact(rv)
+}
+
+// loadProject reads the project spec and runs the targets. The compiled policies of the targets
+// with unchanged spec and policies are reused. The session is updated only when all targets run successfully.
+func (s *watchSession) loadProject(ctx context.Context, changedPaths []string) error {
+	projectSpec, err := project.ReadFromFile(s.cliApp.projectSpecFile)
+	if err != nil {
+		return fmt.Errorf("read project spec: %w", err)
+	}
+
+	targets := make([]*watchTarget, 0, len(projectSpec.Files))
+	for _, spec := range projectSpec.Files {
+		t := &watchTarget{spec: spec}
+		if prev := s.lookupTarget(spec); prev != nil && !anyUnderPaths(changedPaths, project.PolicyFilePaths(s.cliApp.contextRoot, spec)) {
+			t.queryer = prev.queryer
+		}
+		if err := s.runTarget(ctx, t); err != nil {
+			return fmt.Errorf("run target (%s): %w", spec.Name, err)
+		}
+		targets = append(targets, t)
+	}
+
+	s.projectSpec = projectSpec
+	s.targets = targets
+	return nil
+}
+
+// lookupTarget finds the target with the same spec from the current targets.
+func (s *watchSession) lookupTarget(spec project.FileTargetSpec) *watchTarget {
+	for _, t := range s.targets {
+		if reflect.DeepEqual(t.spec, spec) {
+			return t
+		}
+	}
+	return nil
+}
+
+// runTarget runs all sources of the target, the policies are compiled if not compiled yet.
+func (s *watchSession) runTarget(ctx context.Context, t *watchTarget) error {
+	contextRoot := s.cliApp.contextRoot
+
+	sources, err := source.FromPath(utils.Map(t.spec.Paths, project.ResolveToContextRootFn(contextRoot))).
+		ContextRoot(contextRoot).
+		Complete()
+	if err != nil {
+		return fmt.Errorf("load sources failed: %w", err)
+	}
+
+	queryer := t.queryer
+	if queryer == nil {
+		queryer, err = s.cliApp.newFileTargetQueryer(contextRoot, t.spec, s.queryCache, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	queryResultsList, err := s.cliApp.querySources(ctx, queryer, sources)
+	if err != nil {
+		return err
+	}
+
+	t.queryer = queryer
+	t.results = make(map[string]result.QueryResults, len(queryResultsList))
+	for _, queryResults := range queryResultsList {
+		t.results[queryResults.Source.Name()] = queryResults
+	}
+	return nil
+}
+
+// runChangedSources re-runs the changed sources of the target with the compiled policies.
+// Results of the removed sources are dropped.
+func (s *watchSession) runChangedSources(ctx context.Context, t *watchTarget, changedPaths []string) error {
+	relativeToContextRoot := project.RelativeToContextRootFn(s.cliApp.contextRoot)
+
+	var files []string
+	for _, p := range changedPaths {
+		name := relativeToContextRoot(p)
+		for sourceName := range t.results {
+			if isUnderPath(sourceName, name) {
+				delete(t.results, sourceName)
+			}
+		}
+
+		changedFiles, err := sourceFiles(p)
+		if err != nil {
+			return err
+		}
+		files = append(files, changedFiles...)
+	}
+	if len(files) < 1 {
+		return nil
+	}
+
+	sources, err := source.FromPath(files).ContextRoot(s.cliApp.contextRoot).Complete()
+	if err != nil {
+		return fmt.Errorf("load sources failed: %w", err)
+	}
+	queryResultsList, err := s.cliApp.querySources(ctx, t.queryer, sources)
+	if err != nil {
+		return err
+	}
+	for _, queryResults := range queryResultsList {
+		t.results[queryResults.Source.Name()] = queryResults
+	}
+	return nil
+}
+
+// onChange re-runs the targets affected by the changed paths and presents the results.
+// It returns true if the project spec is changed.
+func (s *watchSession) onChange(ctx context.Context, changedPaths []string) bool {
+	stdout := s.cliApp.stdout
+
+	if slices.Contains(changedPaths, s.cliApp.projectSpecFile) {
+		if err := s.loadProject(ctx, changedPaths); err != nil {
+			fmt.Fprintf(stdout, "reload project failed: %s\n", err)
+			return true
+		}
+		s.present()
+		return true
+	}
+
+	for _, t := range s.targets {
+		if err := s.updateTarget(ctx, t, changedPaths); err != nil {
+			fmt.Fprintf(stdout, "run target (%s) failed: %s\n", t.spec.Name, err)
+		}
+	}
+	// NOTE: changes to the waivers or the baseline only need to present the results again
+	s.present()
+
+	return false
+}
+
+// updateTarget re-runs the target if affected by the changed paths:
+//
+//   - when the policies changed, all sources are re-run with the recompiled policies;
+//   - when the sources changed, only the changed sources are re-run.
+//
+// On failure, the previous compiled policies are kept.
+func (s *watchSession) updateTarget(ctx context.Context, t *watchTarget, changedPaths []string) error {
+	if anyUnderPaths(changedPaths, project.PolicyFilePaths(s.cliApp.contextRoot, t.spec)) {
+		prevQueryer := t.queryer
+		t.queryer = nil
+		if err := s.runTarget(ctx, t); err != nil {
+			t.queryer = prevQueryer
+			return err
+		}
+		return nil
+	}
+
+	targetPaths := utils.Map(t.spec.Paths, project.ResolveToContextRootFn(s.cliApp.contextRoot))
+	if anyUnderPaths(targetPaths, changedPaths) {
+		// the target path itself is replaced
+		return s.runTarget(ctx, t)
+	}
+
+	var changedSourcePaths []string
+	for _, p := range changedPaths {
+		if anyUnderPaths([]string{p}, targetPaths) {
+			changedSourcePaths = append(changedSourcePaths, p)
+		}
+	}
+	if len(changedSourcePaths) < 1 {
+		return nil
+	}
+	return s.runChangedSources(ctx, t, changedSourcePaths)
+}
+
+// queryResultsList returns the results of the targets, in the order of the targets and the source names.
+func (s *watchSession) queryResultsList() []result.QueryResults {
+	var rv []result.QueryResults
+	for _, t := range s.targets {
+		names := make([]string, 0, len(t.results))
+		for name := range t.results {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			// NOTE: the results are updated when presenting, so we present a copy to keep the kept results intact
+			queryResults := t.results[name]
+			queryResults.Passes = slices.Clone(queryResults.Passes)
+			queryResults.Failures = slices.Clone(queryResults.Failures)
+			queryResults.Warnings = slices.Clone(queryResults.Warnings)
+			queryResults.Exceptions = slices.Clone(queryResults.Exceptions)
+			queryResults.Skipped = slices.Clone(queryResults.Skipped)
+			queryResults.Baselined = slices.Clone(queryResults.Baselined)
+			queryResults.Errors = slices.Clone(queryResults.Errors)
+			rv = append(rv, queryResults)
+		}
+	}
+	return rv
+}
+
+// present writes the current results of the targets.
+func (s *watchSession) present() {
+	stdout := s.cliApp.stdout
+
+	waivers, err := s.cliApp.readWaivers(s.projectSpec)
+	if err != nil {
+		fmt.Fprintf(stdout, "%s\n", err)
+		return
+	}
+	if _, err := s.cliApp.presentResults(s.queryResultsList(), waivers); err != nil {
+		fmt.Fprintf(stdout, "%s\n", err)
+	}
+}
+
+// sourceFiles returns the supported source files of the path. It returns nil if the path doesn't exist.
+func sourceFiles(p string) ([]string, error) {
+	if _, err := os.Stat(p); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var rv []string
+	err := filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && source.FileSupported(path) {
+			rv = append(rv, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk path %q: %w", p, err)
+	}
+	return rv, nil
+}
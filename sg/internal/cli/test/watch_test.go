@@ -0,0 +1,142 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/ShieldGuard/sg/internal/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+const testWatchPolicy = `package main
+
+deny_foo[msg] {
+	input.name = "foo"
+
+	msg = "name cannot be foo"
+}
+`
+
+func testWatchSession(t *testing.T) (*watchSession, *bytes.Buffer) {
+	contextRoot := t.TempDir()
+	for name, content := range map[string]string{
+		"sg-project.yaml": `files:
+- name: test
+  paths:
+  - configurations
+  policies:
+  - policy
+`,
+		"configurations/foo.yaml": "name: foo\n",
+		"configurations/bar.yaml": "name: bar\n",
+		"policy/main.rego":        testWatchPolicy,
+	} {
+		p := filepath.Join(contextRoot, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		assert.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+
+	output := new(bytes.Buffer)
+	cliApp := newCliApp(func(cliApp *cliApp) {
+		cliApp.contextRoot = contextRoot
+		cliApp.projectSpecFile = filepath.Join(contextRoot, "sg-project.yaml")
+		cliApp.watch = true
+		cliApp.stdout = output
+	})
+	assert.NoError(t, cliApp.defaults())
+
+	session := &watchSession{cliApp: cliApp, queryCache: engine.NewQueryCache()}
+	assert.NoError(t, session.loadProject(context.Background(), nil))
+
+	return session, output
+}
+
+func Test_watchSession_onChange(t *testing.T) {
+	ctx := context.Background()
+	session, output := testWatchSession(t)
+	contextRoot := session.cliApp.contextRoot
+	writeFile := func(name string, content string) string {
+		p := filepath.Join(contextRoot, name)
+		assert.NoError(t, os.WriteFile(p, []byte(content), 0644))
+		return p
+	}
+	failuresOf := func(name string) int {
+		return len(session.targets[0].results[filepath.FromSlash(name)].Failures)
+	}
+
+	if assert.Len(t, session.targets, 1) {
+		assert.Len(t, session.targets[0].results, 2)
+		assert.Equal(t, 1, failuresOf("configurations/foo.yaml"))
+		assert.Equal(t, 0, failuresOf("configurations/bar.yaml"))
+	}
+	queryer := session.targets[0].queryer
+
+	t.Run("source changed", func(t *testing.T) {
+		changed := writeFile("configurations/bar.yaml", "name: foo\n")
+		assert.False(t, session.onChange(ctx, []string{changed}))
+		assert.Same(t, queryer, session.targets[0].queryer, "policies should not be recompiled")
+		assert.Equal(t, 1, failuresOf("configurations/bar.yaml"))
+	})
+
+	t.Run("source removed", func(t *testing.T) {
+		removed := filepath.Join(contextRoot, "configurations", "foo.yaml")
+		assert.NoError(t, os.Remove(removed))
+		assert.False(t, session.onChange(ctx, []string{removed}))
+		assert.Len(t, session.targets[0].results, 1)
+		assert.Contains(t, session.targets[0].results, filepath.Join("configurations", "bar.yaml"))
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		output.Reset()
+		changed := writeFile("policy/main.rego", "package main\n\ndeny_foo[msg] {")
+		assert.False(t, session.onChange(ctx, []string{changed}))
+		assert.Same(t, queryer, session.targets[0].queryer, "should keep the previous policies")
+		assert.Contains(t, output.String(), "run target (test) failed")
+	})
+
+	t.Run("policy changed", func(t *testing.T) {
+		changed := writeFile("policy/main.rego", "package main\n")
+		assert.False(t, session.onChange(ctx, []string{changed}))
+		assert.NotSame(t, queryer, session.targets[0].queryer, "policies should be recompiled")
+		assert.Equal(t, 0, failuresOf("configurations/bar.yaml"))
+		queryer = session.targets[0].queryer
+	})
+
+	t.Run("project spec changed", func(t *testing.T) {
+		changed := writeFile("sg-project.yaml", `files:
+- name: test
+  paths:
+  - configurations
+  policies:
+  - policy
+- name: test-2
+  paths:
+  - configurations
+  policies:
+  - policy
+`)
+		assert.True(t, session.onChange(ctx, []string{changed}))
+		if assert.Len(t, session.targets, 2) {
+			assert.Same(t, queryer, session.targets[0].queryer, "should reuse policies of the unchanged target")
+			assert.Len(t, session.targets[1].results, 1)
+		}
+	})
+}
+
+func Test_watchSession_watchPaths(t *testing.T) {
+	session, _ := testWatchSession(t)
+	contextRoot := session.cliApp.contextRoot
+
+	assert.Equal(
+		t,
+		[]string{
+			filepath.Join(contextRoot, "configurations"),
+			filepath.Join(contextRoot, "policy"),
+			filepath.Join(contextRoot, "sg-project.yaml"),
+		},
+		session.watchPaths(),
+	)
+}
@@ -3,15 +3,21 @@ package engine
 import (
 	"fmt"
 
+	"github.com/open-policy-agent/opa/storage/inmem"
+
 	"github.com/Azure/ShieldGuard/sg/internal/policy"
 )
 
 // QueryerBuilder constructs a Queryer.
 type QueryerBuilder struct {
 	packages                 []policy.Package
+	dataDocuments            []policy.DataDocument
 	queryCache               QueryCache
+	coverageTracer           *CoverageTracer
 	err                      error
 	parseArmTemplateDefaults bool
+	namespaces               []string
+	ruleSelector             policy.RuleSelector
 }
 
 // QueryWithPolicy creates a QueryerBuilder with loading packages from the given paths.
@@ -28,6 +34,16 @@ func QueryWithPolicy(policyPaths []string) *QueryerBuilder {
 	return qb
 }
 
+// WithData loads extra data documents from the given paths for the queryer.
+func (qb *QueryerBuilder) WithData(dataPaths []policy.DataPath) *QueryerBuilder {
+	if qb.err != nil {
+		return qb
+	}
+
+	qb.dataDocuments, qb.err = policy.LoadDataFromPaths(dataPaths)
+	return qb
+}
+
 // QueryWithParsingArmTemplateDefaults creates a QueryerBuilder that parses arm template default values
 func (qb *QueryerBuilder) QueryWithParsingArmTemplateDefaults(shouldParseDefaults bool) *QueryerBuilder {
 	qb.parseArmTemplateDefaults = shouldParseDefaults
@@ -40,27 +56,106 @@ func (qb *QueryerBuilder) WithQueueCache(cache QueryCache) *QueryerBuilder {
 	return qb
 }
 
+// WithCoverage records the policy lines evaluated by the queryer to the tracer.
+// Query cache is bypassed when coverage is enabled, as cached results are not evaluated.
+func (qb *QueryerBuilder) WithCoverage(tracer *CoverageTracer) *QueryerBuilder {
+	qb.coverageTracer = tracer
+	return qb
+}
+
+// WithNamespaces sets the rego namespaces to query rules from.
+// When specified, it overrides the namespaces declared by the policy packages.
+func (qb *QueryerBuilder) WithNamespaces(namespaces []string) *QueryerBuilder {
+	if qb.err != nil {
+		return qb
+	}
+
+	for _, namespace := range namespaces {
+		if err := policy.ValidateNamespace(namespace); err != nil {
+			qb.err = err
+			return qb
+		}
+	}
+	qb.namespaces = namespaces
+	return qb
+}
+
+// WithRuleSelector sets the rules to query. Rules not selected are reported as skipped.
+func (qb *QueryerBuilder) WithRuleSelector(selector policy.RuleSelector) *QueryerBuilder {
+	if qb.err != nil {
+		return qb
+	}
+
+	if err := selector.Validate(); err != nil {
+		qb.err = err
+		return qb
+	}
+	qb.ruleSelector = selector
+	return qb
+}
+
 // Complete constructs the Queryer.
 func (qb *QueryerBuilder) Complete() (Queryer, error) {
 	if qb.err != nil {
 		return nil, qb.err
 	}
 
-	compiler, compilerKey, err := policy.NewRegoCompiler(qb.packages)
+	if err := policy.CheckPackageConflicts(qb.packages); err != nil {
+		return nil, err
+	}
+
+	if err := policy.CheckDataConflicts(qb.packages, qb.dataDocuments); err != nil {
+		return nil, err
+	}
+	data, err := policy.MergeDataDocuments(qb.dataDocuments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge data documents: %w", err)
+	}
+	dataKey, err := policy.DataKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve data key: %w", err)
+	}
+	store := inmem.NewFromObject(data)
+
+	compilerKey, err := policy.RegoCompilerKey(qb.packages)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create compiler from packages: %w", err)
+		return nil, fmt.Errorf("failed to resolve compiler key: %w", err)
+	}
+	// NOTE: data documents affect the query results, so they are part of the compiler key
+	compilerKey = compilerKey + "-" + dataKey
+
+	// NOTE: packages are compiled in isolation, so rules from different packages can't merge
+	//       into the same virtual document.
+	compiledPackages := make([]compiledPackage, 0, len(qb.packages))
+	for _, p := range qb.packages {
+		compiler, packageCompilerKey, err := policy.NewRegoCompiler([]policy.Package{p})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create compiler from package (%s): %w", p.QualifiedID(), err)
+		}
+		compiledPackages = append(compiledPackages, compiledPackage{
+			policyPackage: p,
+			compilerKey:   packageCompilerKey + "-" + dataKey,
+			// NOTE: queries are prepared once per compiler and reused across inputs
+			preparedQueries: newPreparedQueries(compiler, store),
+		})
+	}
+
+	if qb.coverageTracer != nil {
+		qb.coverageTracer.addPackages(qb.packages)
 	}
 
 	rv := &RegoEngine{
-		policyPackages: qb.packages,
-		compiler:       compiler,
-		compilerKey:    compilerKey,
+		compiledPackages: compiledPackages,
+		compilerKey:      compilerKey,
 		// NOTE: we limit the actual query by CPU count as policy evaluation is CPU bounded.
 		//       For input actions like reading policy files / source code, we allow them to run unbounded,
 		//       as the actual limiting is done by this limiter.
 		limiter:                  newLimiterFromMaxProcs(),
 		queryCache:               qb.queryCache,
+		coverageTracer:           qb.coverageTracer,
 		parseArmTemplateDefaults: qb.parseArmTemplateDefaults,
+		namespaces:               qb.namespaces,
+		ruleSelector:             qb.ruleSelector,
 	}
 	return rv, nil
 }
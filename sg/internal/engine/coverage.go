@@ -0,0 +1,205 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/cover"
+	"github.com/open-policy-agent/opa/topdown"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+)
+
+// CoverageTracer records the policy lines evaluated by the queries.
+// A tracer can be shared by multiple queryers and is safe for concurrent use.
+type CoverageTracer struct {
+	mu       sync.Mutex
+	cover    *cover.Cover
+	firedBy  map[string]map[int]struct{}
+	packages []policy.Package
+	seen     map[string]struct{}
+}
+
+// NewCoverageTracer creates a CoverageTracer.
+func NewCoverageTracer() *CoverageTracer {
+	return &CoverageTracer{
+		cover:   cover.New(),
+		firedBy: map[string]map[int]struct{}{},
+		seen:    map[string]struct{}{},
+	}
+}
+
+var _ topdown.QueryTracer = (*CoverageTracer)(nil)
+
+func (t *CoverageTracer) Enabled() bool {
+	return true
+}
+
+func (t *CoverageTracer) Config() topdown.TraceConfig {
+	return t.cover.Config()
+}
+
+func (t *CoverageTracer) TraceEvent(event topdown.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cover.TraceEvent(event)
+
+	// NOTE: a rule exits only when its body is satisfied, which means the rule has fired
+	if event.Op != topdown.ExitOp {
+		return
+	}
+	rule, ok := event.Node.(*ast.Rule)
+	if !ok || rule.Location == nil || rule.Location.File == "" {
+		return
+	}
+	rows, ok := t.firedBy[rule.Location.File]
+	if !ok {
+		rows = map[int]struct{}{}
+		t.firedBy[rule.Location.File] = rows
+	}
+	rows[rule.Location.Row] = struct{}{}
+}
+
+// addPackages registers the packages to report. Packages with the same qualified id are added once.
+func (t *CoverageTracer) addPackages(packages []policy.Package) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, p := range packages {
+		if _, ok := t.seen[p.QualifiedID()]; ok {
+			continue
+		}
+		t.seen[p.QualifiedID()] = struct{}{}
+		t.packages = append(t.packages, p)
+	}
+}
+
+// Report creates the coverage report of the registered packages.
+// Test modules (`*_test.rego`) are excluded from the report.
+func (t *CoverageTracer) Report() result.CoverageReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var rv result.CoverageReport
+	for _, p := range t.packages {
+		packageCoverage := t.packageCoverage(p)
+		rv.Lines = rv.Lines.Add(packageCoverage.Lines)
+		rv.Packages = append(rv.Packages, packageCoverage)
+	}
+	sort.Slice(rv.Packages, func(i, j int) bool {
+		return rv.Packages[i].Package < rv.Packages[j].Package
+	})
+
+	return rv
+}
+
+func (t *CoverageTracer) packageCoverage(p policy.Package) result.PackageCoverage {
+	modules := map[string]*ast.Module{}
+	for file, module := range p.ParsedModules() {
+		if strings.HasSuffix(file, policyTestFileSuffix) {
+			continue
+		}
+		modules[file] = module
+	}
+	coverReport := t.cover.Report(modules)
+
+	rulesByFile := map[string][]policy.Rule{}
+	for _, rule := range p.Rules() {
+		if rule.SourceLocation == nil {
+			continue
+		}
+		rulesByFile[rule.SourceLocation.File] = append(rulesByFile[rule.SourceLocation.File], rule)
+	}
+
+	files := make([]string, 0, len(modules))
+	for file := range modules {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	rv := result.PackageCoverage{
+		Package: p.QualifiedID(),
+	}
+	for _, file := range files {
+		fileCoverage := t.fileCoverage(file, modules[file], coverReport.Files[file], rulesByFile[file])
+		rv.Lines = rv.Lines.Add(fileCoverage.Lines)
+		rv.Files = append(rv.Files, fileCoverage)
+	}
+
+	return rv
+}
+
+func (t *CoverageTracer) fileCoverage(
+	file string,
+	module *ast.Module,
+	fileReport *cover.FileReport,
+	rules []policy.Rule,
+) result.FileCoverage {
+	rv := result.FileCoverage{File: file}
+
+	covered := map[int]struct{}{}
+	notCovered := map[int]struct{}{}
+	if fileReport != nil {
+		rv.Lines = result.LineCoverage{
+			Covered:    fileReport.CoveredLines,
+			NotCovered: fileReport.NotCoveredLines,
+		}
+		for _, r := range fileReport.Covered {
+			for row := r.Start.Row; row <= r.End.Row; row++ {
+				covered[row] = struct{}{}
+			}
+		}
+		for _, r := range fileReport.NotCovered {
+			rv.NotCovered = append(rv.NotCovered, result.LineRange{Start: r.Start.Row, End: r.End.Row})
+			for row := r.Start.Row; row <= r.End.Row; row++ {
+				notCovered[row] = struct{}{}
+			}
+		}
+	}
+
+	// NOTE: a rule can be defined multiple times (incremental rules), so we group
+	//       the definitions by the rule query.
+	seenRules := map[string]struct{}{}
+	for _, rule := range rules {
+		if _, ok := seenRules[rule.Query()]; ok {
+			continue
+		}
+		seenRules[rule.Query()] = struct{}{}
+		rv.Rules = append(rv.Rules, result.RuleCoverage{Rule: rule})
+	}
+	sort.Slice(rv.Rules, func(i, j int) bool {
+		return rv.Rules[i].Rule.SourceLocation.Row < rv.Rules[j].Rule.SourceLocation.Row
+	})
+	ruleIndexes := make(map[string]int, len(rv.Rules))
+	for idx := range rv.Rules {
+		ruleIndexes[rv.Rules[idx].Rule.Query()] = idx
+	}
+
+	for _, regoRule := range module.Rules {
+		idx, ok := ruleIndexes[regoRule.Head.Name.String()]
+		if !ok || regoRule.Location == nil {
+			continue
+		}
+		ruleCoverage := &rv.Rules[idx]
+
+		if _, fired := t.firedBy[file][regoRule.Location.Row]; fired {
+			ruleCoverage.Fired = true
+		}
+
+		startRow := regoRule.Location.Row
+		endRow := startRow + strings.Count(string(regoRule.Location.Text), "\n")
+		for row := startRow; row <= endRow; row++ {
+			if _, ok := covered[row]; ok {
+				ruleCoverage.Lines.Covered++
+			} else if _, ok := notCovered[row]; ok {
+				ruleCoverage.Lines.NotCovered++
+			}
+		}
+	}
+
+	return rv
+}
@@ -0,0 +1,230 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/topdown"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+)
+
+// shouldExplain tells if the rule should be explained.
+// A rule can be referenced by the query name (e.g. `deny_foo`) or the rule name (e.g. `foo`).
+func (opts *QueryOptions) shouldExplain(rule policy.Rule) bool {
+	for _, r := range opts.ExplainRules {
+		if r == rule.Query() || r == rule.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeQueryOptions merges the query options. Later options take precedence.
+func mergeQueryOptions(opts []*QueryOptions) *QueryOptions {
+	rv := &QueryOptions{}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		rv.ExplainRules = append(rv.ExplainRules, opt.ExplainRules...)
+	}
+	return rv
+}
+
+type ruleExplanation struct {
+	// value is the value produced by the rule head (e.g. the `msg` in `deny[msg]`).
+	value ast.Value
+	// explanation is the explanation for producing the value.
+	explanation result.Explanation
+}
+
+// explainTracer captures the evaluated expressions of a rule.
+//
+// The rule body is evaluated in depth first order with backtracking, so when the rule exits,
+// the latest evaluation of each expression in the body forms the path producing the value.
+// Variables are bound after the expression is evaluated, so the bindings are resolved on exit.
+type explainTracer struct {
+	// rulePath is the path of the rule to explain (e.g. `data.main.deny_foo`).
+	rulePath string
+
+	exprs        map[uint64]map[int]*ast.Expr
+	explanations []ruleExplanation
+}
+
+func newExplainTracer(rulePath string) *explainTracer {
+	return &explainTracer{
+		rulePath: rulePath,
+		exprs:    map[uint64]map[int]*ast.Expr{},
+	}
+}
+
+var _ topdown.QueryTracer = (*explainTracer)(nil)
+
+func (t *explainTracer) Enabled() bool {
+	return true
+}
+
+func (t *explainTracer) Config() topdown.TraceConfig {
+	return topdown.TraceConfig{PlugLocalVars: true}
+}
+
+func (t *explainTracer) TraceEvent(event topdown.Event) {
+	switch event.Op {
+	case topdown.EvalOp:
+		expr, ok := event.Node.(*ast.Expr)
+		if !ok || expr.Location == nil {
+			return
+		}
+		exprs, ok := t.exprs[event.QueryID]
+		if !ok {
+			exprs = map[int]*ast.Expr{}
+			t.exprs[event.QueryID] = exprs
+		}
+		exprs[expr.Index] = expr
+	case topdown.ExitOp:
+		rule, ok := event.Node.(*ast.Rule)
+		if !ok || rule.Path().String() != t.rulePath {
+			return
+		}
+
+		head := rule.Head.Key
+		if head == nil {
+			head = rule.Head.Value
+		}
+		var value ast.Value
+		if head != nil {
+			value = event.Plug(head).Value
+		}
+
+		t.explanations = append(t.explanations, ruleExplanation{
+			value:       value,
+			explanation: explainExprs(event, t.exprs[event.QueryID]),
+		})
+	}
+}
+
+// explainExprs resolves the explanation of the evaluated expressions with the bindings at the event.
+func explainExprs(event topdown.Event, exprsByIndex map[int]*ast.Expr) result.Explanation {
+	exprs := make([]*ast.Expr, 0, len(exprsByIndex))
+	for _, expr := range exprsByIndex {
+		exprs = append(exprs, expr)
+	}
+	sort.Slice(exprs, func(i, j int) bool {
+		return exprs[i].Index < exprs[j].Index
+	})
+
+	var rv result.Explanation
+
+	// NOTE: the compiler rewrites terms like `input.x` in calls into generated expressions
+	//       before the expression using them, so we fold them into the next expression.
+	var generated []*ast.Expr
+	for _, expr := range exprs {
+		if expr.Generated {
+			generated = append(generated, expr)
+			continue
+		}
+
+		step := result.ExplanationStep{
+			File:       expr.Location.File,
+			Line:       expr.Location.Row,
+			Expression: string(expr.Location.Text),
+		}
+		for _, e := range append(generated, expr) {
+			explainExpr(event, e, &step)
+		}
+		generated = nil
+
+		rv.Steps = append(rv.Steps, step)
+	}
+
+	return rv
+}
+
+// explainExpr resolves the bindings and input values referenced by the expression.
+func explainExpr(event topdown.Event, expr *ast.Expr, step *result.ExplanationStep) {
+	ast.WalkVars(expr, func(v ast.Var) bool {
+		if event.Locals == nil {
+			return true
+		}
+		name := string(v)
+		if metadata, ok := event.LocalMetadata[v]; ok {
+			name = string(metadata.Name)
+		}
+		if name == "" || strings.HasPrefix(name, "$") || strings.HasPrefix(name, "__") {
+			// generated variables
+			return false
+		}
+		value := event.Locals.Get(v)
+		if value == nil {
+			return false
+		}
+		if jsonValue, err := ast.JSON(value); err == nil {
+			if step.Bindings == nil {
+				step.Bindings = map[string]interface{}{}
+			}
+			step.Bindings[name] = jsonValue
+		}
+		return false
+	})
+
+	input := event.Input()
+	ast.WalkRefs(expr, func(ref ast.Ref) bool {
+		if input == nil || !ref.HasPrefix(ast.InputRootRef) {
+			return false
+		}
+		plugged, ok := event.Plug(ast.NewTerm(ref)).Value.(ast.Ref)
+		if !ok || !plugged.IsGround() {
+			return false
+		}
+		value, err := input.Value.Find(plugged[1:])
+		if err != nil {
+			return false
+		}
+		if jsonValue, err := ast.JSON(value); err == nil {
+			if step.Input == nil {
+				step.Input = map[string]interface{}{}
+			}
+			step.Input[plugged.String()] = jsonValue
+		}
+		return false
+	})
+}
+
+// explainedResultMessage resolves the result message from the rule head value.
+// See result.FromRegoExpression for the supported values.
+func explainedResultMessage(value ast.Value) (string, bool) {
+	switch v := value.(type) {
+	case ast.String:
+		return string(v), true
+	case ast.Object:
+		msg := v.Get(ast.StringTerm("msg"))
+		if msg == nil {
+			return "", false
+		}
+		s, ok := msg.Value.(ast.String)
+		return string(s), ok
+	default:
+		return "", false
+	}
+}
+
+// attachExplanations attaches the explanations to the results with the same message.
+func attachExplanations(results []result.Result, explanations []ruleExplanation) {
+	for idx := range results {
+		if results[idx].Passed() {
+			continue
+		}
+		for _, e := range explanations {
+			msg, ok := explainedResultMessage(e.value)
+			if !ok || msg != results[idx].Message {
+				continue
+			}
+			explanation := e.explanation
+			results[idx].Explanation = &explanation
+			break
+		}
+	}
+}
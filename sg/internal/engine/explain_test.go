@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+)
+
+func Test_QueryOptions_shouldExplain(t *testing.T) {
+	opts := mergeQueryOptions([]*QueryOptions{
+		nil,
+		{ExplainRules: []string{"deny_foo"}},
+		{ExplainRules: []string{"bar"}},
+	})
+
+	assert.True(t, opts.shouldExplain(policy.Rule{Kind: policy.QueryKindDeny, Name: "foo"}))
+	assert.False(t, opts.shouldExplain(policy.Rule{Kind: policy.QueryKindWarn, Name: "foo"}))
+	assert.True(t, opts.shouldExplain(policy.Rule{Kind: policy.QueryKindDeny, Name: "bar"}))
+	assert.True(t, opts.shouldExplain(policy.Rule{Kind: policy.QueryKindWarn, Name: "bar"}))
+	assert.False(t, mergeQueryOptions(nil).shouldExplain(policy.Rule{Kind: policy.QueryKindDeny, Name: "foo"}))
+}
+
+func Test_attachExplanations(t *testing.T) {
+	explanations := []ruleExplanation{
+		{
+			value: ast.String("name cannot be foo"),
+			explanation: result.Explanation{
+				Steps: []result.ExplanationStep{{Line: 1}},
+			},
+		},
+		{
+			value: ast.MustParseTerm(`{"msg": "name cannot be bar", "path": "metadata.name"}`).Value,
+			explanation: result.Explanation{
+				Steps: []result.ExplanationStep{{Line: 2}},
+			},
+		},
+	}
+	results := []result.Result{
+		{Message: "name cannot be bar"},
+		{Message: "name cannot be foo"},
+		{Message: "name cannot be baz"},
+		{},
+	}
+
+	attachExplanations(results, explanations)
+
+	if assert.NotNil(t, results[0].Explanation) {
+		assert.Equal(t, 2, results[0].Explanation.Steps[0].Line)
+	}
+	if assert.NotNil(t, results[1].Explanation) {
+		assert.Equal(t, 1, results[1].Explanation.Steps[0].Line)
+	}
+	assert.Nil(t, results[2].Explanation, "no matching explanation")
+	assert.Nil(t, results[3].Explanation, "passed result should not be explained")
+}
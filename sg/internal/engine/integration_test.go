@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
 	"github.com/Azure/ShieldGuard/sg/internal/source"
 	"github.com/stretchr/testify/assert"
 )
@@ -41,6 +42,7 @@ func Test_Integration_Basic(t *testing.T) {
 	assert.NotNil(t, queryResult)
 	assert.Equal(t, queryResult.Source, dataYAMLSource)
 	assert.Equal(t, queryResult.Successes, 2, "one document passes the test")
+	assert.Len(t, queryResult.Passes, queryResult.Successes, "passed rules should match the successes")
 
 	assert.Len(t, queryResult.Exceptions, 2, "one document emits two exceptions")
 	{
@@ -201,3 +203,504 @@ func Test_Integration_Basic_QueryCacheEnabled(t *testing.T) {
 		round()
 	}
 }
+
+func Test_Integration_Data(t *testing.T) {
+	t.Parallel()
+
+	queryer, err := QueryWithPolicy([]string{
+		"./testdata/data/policy",
+	}).
+		WithData([]policy.DataPath{
+			{Path: "./testdata/data/data"},
+		}).
+		Complete()
+	assert.NoError(t, err)
+	assert.NotNil(t, queryer)
+
+	sources, err := source.FromPath([]string{
+		"./testdata/data/configurations",
+	}).Complete()
+	assert.NoError(t, err)
+	assert.Len(t, sources, 1)
+
+	ctx := context.Background()
+	queryResult, err := queryer.Query(ctx, sources[0])
+	assert.NoError(t, err)
+	assert.Equal(t, queryResult.Successes, 1, "one document uses approved registry")
+	assert.Len(t, queryResult.Failures, 1, "one document uses unapproved registry")
+	assert.Equal(t, queryResult.Failures[0].Message, "registry docker.io is not approved")
+	assert.NotEmpty(t, queryResult.CompilerKey)
+
+	queryerWithoutData, err := QueryWithPolicy([]string{
+		"./testdata/data/policy",
+	}).Complete()
+	assert.NoError(t, err)
+	queryResultWithoutData, err := queryerWithoutData.Query(ctx, sources[0])
+	assert.NoError(t, err)
+	assert.NotEqual(
+		t, queryResult.CompilerKey, queryResultWithoutData.CompilerKey,
+		"data documents should be part of the compiler key",
+	)
+}
+
+func Test_Integration_Data_Conflict(t *testing.T) {
+	t.Parallel()
+
+	_, err := QueryWithPolicy([]string{
+		"./testdata/data/policy",
+	}).
+		WithData([]policy.DataPath{
+			{Path: "./testdata/data/data"},
+			{Key: "approved_registries", Path: "./testdata/data/data/approved_registries.yaml"},
+		}).
+		Complete()
+	assert.Error(t, err)
+}
+
+func Test_Integration_Data_QueryCacheEnabled(t *testing.T) {
+	t.Parallel()
+
+	queryCache := NewQueryCache()
+
+	sources, err := source.FromPath([]string{
+		"./testdata/data/configurations",
+	}).Complete()
+	assert.NoError(t, err)
+	assert.Len(t, sources, 1)
+
+	ctx := context.Background()
+
+	queryer, err := QueryWithPolicy([]string{
+		"./testdata/data/policy",
+	}).
+		WithData([]policy.DataPath{
+			{Path: "./testdata/data/data"},
+		}).
+		WithQueueCache(queryCache).
+		Complete()
+	assert.NoError(t, err)
+	queryResult, err := queryer.Query(ctx, sources[0])
+	assert.NoError(t, err)
+	assert.Len(t, queryResult.Failures, 1)
+
+	// same policy without data should not reuse the cached results
+	queryer, err = QueryWithPolicy([]string{
+		"./testdata/data/policy",
+	}).
+		WithQueueCache(queryCache).
+		Complete()
+	assert.NoError(t, err)
+	queryResult, err = queryer.Query(ctx, sources[0])
+	assert.NoError(t, err)
+	assert.Len(t, queryResult.Failures, 2)
+}
+
+func Test_Integration_Location(t *testing.T) {
+	t.Parallel()
+
+	queryer, err := QueryWithPolicy([]string{
+		"./testdata/location/policy",
+	}).Complete()
+	assert.NoError(t, err)
+
+	sources, err := source.FromPath([]string{
+		"./testdata/location/configurations",
+	}).ContextRoot("./testdata/location").Complete()
+	assert.NoError(t, err)
+	assert.Len(t, sources, 1)
+
+	ctx := context.Background()
+	queryResult, err := queryer.Query(ctx, sources[0])
+	assert.NoError(t, err)
+	assert.Equal(t, queryResult.Successes, 1)
+	assert.Len(t, queryResult.Failures, 1)
+
+	failureResult := queryResult.Failures[0]
+	assert.Equal(t, failureResult.Message, "container bar-2 uses latest image")
+	assert.Equal(t, result.Location{
+		File:     "configurations/pods.yaml",
+		Document: 1,
+		Line:     17,
+		Column:   5,
+		Identity: "Pod/bar",
+	}, failureResult.Location)
+	assert.Equal(t, "configurations/pods.yaml:17:5", failureResult.Location.String())
+
+	assert.Equal(t, []result.Document{
+		{Index: 0, Identity: "Pod/foo"},
+		{Index: 1, Identity: "Pod/bar"},
+	}, queryResult.Documents)
+}
+
+func Test_Integration_BuiltinPackages(t *testing.T) {
+	t.Parallel()
+
+	queryer, err := QueryWithPolicy([]string{
+		"builtin:pss/baseline",
+		"builtin:pss/restricted",
+	}).Complete()
+	assert.NoError(t, err)
+	assert.NotNil(t, queryer)
+
+	sources, err := source.FromPath([]string{
+		"./testdata/builtin/configurations",
+	}).Complete()
+	assert.NoError(t, err)
+	assert.Len(t, sources, 3)
+
+	ctx := context.Background()
+
+	failedRules := func(t *testing.T, queryResult result.QueryResults) []string {
+		var rv []string
+		for _, r := range queryResult.Failures {
+			assert.NotEmpty(t, r.RuleDocLink)
+			assert.NotZero(t, r.Location.Line, "builtin rules should report the path")
+			rv = append(rv, r.Rule.Query())
+		}
+		return rv
+	}
+
+	t.Run("compliant", func(t *testing.T) {
+		queryResult, err := queryer.Query(ctx, sources[0])
+		assert.NoError(t, err)
+		assert.Empty(t, queryResult.Failures)
+		assert.NotZero(t, queryResult.Successes)
+	})
+
+	t.Run("noncompliant workload", func(t *testing.T) {
+		queryResult, err := queryer.Query(ctx, sources[1])
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{
+			"deny_app_armor",
+			"deny_app_armor",
+			"deny_privilege_escalation",
+			"deny_privilege_escalation",
+			"deny_proc_mount_type",
+			"deny_seccomp_profile",
+			"deny_seccomp_profile",
+			"deny_selinux",
+			"deny_windows_host_process",
+		}, failedRules(t, queryResult))
+	})
+
+	t.Run("noncompliant pod", func(t *testing.T) {
+		queryResult, err := queryer.Query(ctx, sources[2])
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{
+			"deny_capabilities",
+			"deny_host_namespaces",
+			"deny_host_path_volumes",
+			"deny_host_ports",
+			"deny_min_capabilities",
+			"deny_min_capabilities",
+			"deny_non_root_users",
+			"deny_non_root_users",
+			"deny_privilege_escalation",
+			"deny_privileged_containers",
+			"deny_seccomp",
+			"deny_seccomp_profile",
+			"deny_sysctls",
+			"deny_volume_types",
+		}, failedRules(t, queryResult))
+	})
+}
+
+func Test_Integration_EvalError(t *testing.T) {
+	t.Parallel()
+
+	queryer, err := QueryWithPolicy([]string{
+		"./testdata/eval-error/policy",
+	}).Complete()
+	assert.NoError(t, err)
+	assert.NotNil(t, queryer)
+
+	sources, err := source.FromPath([]string{
+		"./testdata/eval-error/configurations",
+	}).Complete()
+	assert.NoError(t, err)
+	assert.Len(t, sources, 1)
+
+	ctx := context.Background()
+	queryResult, err := queryer.Query(ctx, sources[0])
+	assert.NoError(t, err)
+	assert.Equal(t, 0, queryResult.Successes, "errored rule should not be counted as success")
+	assert.Len(t, queryResult.Failures, 1, "other rules should still be evaluated")
+	assert.Equal(t, "name cannot be foo", queryResult.Failures[0].Message)
+
+	assert.Len(t, queryResult.Errors, 1)
+	evalErr := queryResult.Errors[0]
+	assert.Equal(t, "data.main.deny_missing_owner", evalErr.Query)
+	assert.Equal(t, "missing_owner", evalErr.Rule.Name)
+	assert.Equal(t, policy.QueryKindDeny, evalErr.Rule.Kind)
+	assert.Contains(t, evalErr.Message, "conflict")
+	assert.Equal(t, "Pod/foo", evalErr.Location.Identity)
+}
+
+func Test_Integration_Coverage(t *testing.T) {
+	t.Parallel()
+
+	coverageTracer := NewCoverageTracer()
+	queryer, err := QueryWithPolicy([]string{
+		"builtin:pss/baseline",
+	}).
+		WithQueueCache(NewQueryCache()).
+		WithCoverage(coverageTracer).
+		Complete()
+	assert.NoError(t, err)
+
+	// the same package used by another target should be reported once
+	_, err = QueryWithPolicy([]string{
+		"builtin:pss/baseline",
+	}).
+		WithCoverage(coverageTracer).
+		Complete()
+	assert.NoError(t, err)
+
+	sources, err := source.FromPath([]string{
+		"./testdata/builtin/configurations/noncompliant.yaml",
+	}).Complete()
+	assert.NoError(t, err)
+	assert.Len(t, sources, 1)
+
+	ctx := context.Background()
+	_, err = queryer.Query(ctx, sources[0])
+	assert.NoError(t, err)
+
+	report := coverageTracer.Report()
+	assert.Len(t, report.Packages, 1)
+	assert.Equal(t, "builtin:pss/baseline", report.Packages[0].Package)
+	assert.Greater(t, report.Lines.Covered, 0)
+	assert.Greater(t, report.Lines.NotCovered, 0)
+
+	firedRules := map[string]bool{}
+	for _, fileCoverage := range report.Packages[0].Files {
+		for _, ruleCoverage := range fileCoverage.Rules {
+			assert.Equal(t, fileCoverage.File, ruleCoverage.Rule.SourceLocation.File)
+			firedRules[ruleCoverage.Rule.Query()] = ruleCoverage.Fired
+		}
+	}
+	assert.Len(t, firedRules, 11)
+	assert.True(t, firedRules["deny_privileged_containers"])
+	assert.False(t, firedRules["deny_windows_host_process"])
+}
+
+func Test_Integration_Explain(t *testing.T) {
+	t.Parallel()
+
+	queryer, err := QueryWithPolicy([]string{
+		"builtin:pss/baseline",
+	}).
+		WithQueueCache(NewQueryCache()).
+		Complete()
+	assert.NoError(t, err)
+
+	sources, err := source.FromPath([]string{
+		"./testdata/builtin/configurations/noncompliant.yaml",
+	}).Complete()
+	assert.NoError(t, err)
+	assert.Len(t, sources, 1)
+
+	ctx := context.Background()
+
+	// warm up the cache, explained rules should not use cached results
+	_, err = queryer.Query(ctx, sources[0])
+	assert.NoError(t, err)
+
+	queryResult, err := queryer.Query(ctx, sources[0], &QueryOptions{
+		ExplainRules: []string{"deny_privileged_containers"},
+	})
+	assert.NoError(t, err)
+
+	var explained *result.Result
+	for idx := range queryResult.Failures {
+		r := queryResult.Failures[idx]
+		if r.Rule.Query() != "deny_privileged_containers" {
+			assert.Nil(t, r.Explanation, "rule %s should not be explained", r.Rule.Query())
+			continue
+		}
+		explained = &queryResult.Failures[idx]
+	}
+	if !assert.NotNil(t, explained) || !assert.NotNil(t, explained.Explanation) {
+		return
+	}
+
+	steps := explained.Explanation.Steps
+	assert.NotEmpty(t, steps)
+	for _, step := range steps {
+		assert.Equal(t, "builtin/pss/baseline/002-privileged_containers.rego", step.File)
+		assert.NotEmpty(t, step.Expression)
+	}
+	if assert.Len(t, steps, 4) {
+		assert.Equal(t, 7, steps[1].Line)
+		assert.Equal(t, "container.securityContext.privileged == true", steps[1].Expression)
+		assert.Contains(t, steps[1].Bindings, "container")
+
+		assert.Equal(t, explained.Message, steps[2].Bindings["msg"])
+		assert.Equal(t, "Pod", steps[2].Input["input.kind"], "input paths in calls should be resolved")
+	}
+}
+
+func Test_Integration_Namespaces(t *testing.T) {
+	t.Parallel()
+
+	sources, err := source.FromPath([]string{
+		"./testdata/namespaces/configurations",
+	}).Complete()
+	assert.NoError(t, err)
+	assert.Len(t, sources, 1)
+
+	ctx := context.Background()
+
+	t.Run("namespaces from package spec", func(t *testing.T) {
+		queryer, err := QueryWithPolicy([]string{
+			"./testdata/namespaces/policy",
+		}).Complete()
+		assert.NoError(t, err)
+
+		queryResult, err := queryer.Query(ctx, sources[0])
+		assert.NoError(t, err)
+
+		assert.Len(t, queryResult.Failures, 1)
+		assert.Equal(t, "data.kubernetes.pss.baseline.deny_host_network", queryResult.Failures[0].Query)
+		assert.Equal(t, "kubernetes.pss.baseline", queryResult.Failures[0].Rule.Namespace)
+
+		assert.Len(t, queryResult.Exceptions, 1, "exception should be resolved in the same namespace")
+		assert.Equal(t, "privileged", queryResult.Exceptions[0].Rule.Name)
+
+		assert.Len(t, queryResult.Warnings, 1)
+		assert.Equal(t, "kubernetes.labels", queryResult.Warnings[0].Rule.Namespace)
+
+		assert.Equal(t, 1, queryResult.Successes)
+		assert.Equal(t, map[string]int{"kubernetes.labels": 1, "kubernetes.pss.baseline": 0}, queryResult.SuccessesByNamespace)
+		if assert.Len(t, queryResult.Passes, 1) {
+			assert.Equal(t, "kubernetes.labels", queryResult.Passes[0].Rule.Namespace)
+			assert.Empty(t, queryResult.Passes[0].Message)
+			assert.Empty(t, queryResult.Passes[0].RuleDocLink, "doc link is not resolved for passed rules")
+		}
+
+		byNamespace := queryResult.ByNamespace()
+		assert.Len(t, byNamespace, 2)
+		assert.Equal(t, "kubernetes.labels", byNamespace[0].Namespace)
+		assert.Equal(t, "kubernetes.pss.baseline", byNamespace[1].Namespace)
+	})
+
+	t.Run("namespaces from target", func(t *testing.T) {
+		queryer, err := QueryWithPolicy([]string{
+			"./testdata/namespaces/policy",
+		}).WithNamespaces([]string{"main"}).Complete()
+		assert.NoError(t, err)
+
+		queryResult, err := queryer.Query(ctx, sources[0])
+		assert.NoError(t, err)
+
+		assert.Len(t, queryResult.Failures, 1)
+		assert.Equal(t, "data.main.deny_always", queryResult.Failures[0].Query)
+		assert.Empty(t, queryResult.Warnings)
+		assert.Empty(t, queryResult.Exceptions)
+	})
+
+	t.Run("invalid namespace", func(t *testing.T) {
+		_, err := QueryWithPolicy([]string{
+			"./testdata/namespaces/policy",
+		}).WithNamespaces([]string{"kubernetes/pss"}).Complete()
+		assert.Error(t, err)
+	})
+}
+
+func Test_Integration_PackageIsolation(t *testing.T) {
+	t.Parallel()
+
+	sources, err := source.FromPath([]string{
+		"./testdata/isolation/configurations",
+	}).Complete()
+	assert.NoError(t, err)
+	assert.Len(t, sources, 1)
+
+	t.Run("isolated packages", func(t *testing.T) {
+		queryer, err := QueryWithPolicy([]string{
+			"./testdata/isolation/package-a",
+			"./testdata/isolation/package-b",
+		}).Complete()
+		assert.NoError(t, err, "helper rules with the same name in different packages should not conflict")
+
+		queryResult, err := queryer.Query(context.Background(), sources[0])
+		assert.NoError(t, err)
+		assert.Equal(t, 1, queryResult.Successes)
+		assert.Len(t, queryResult.Failures, 1)
+		assert.Equal(t, "data.main.deny_unapproved_registry_b", queryResult.Failures[0].Query)
+		assert.Equal(
+			t,
+			"image registry-a.example.com/app:v1 is not from registry-b.example.com",
+			queryResult.Failures[0].Message,
+		)
+		assert.Equal(t, "fs:./testdata/isolation/package-b", queryResult.Failures[0].Package)
+	})
+
+	t.Run("same rule in different packages", func(t *testing.T) {
+		queryer, err := QueryWithPolicy([]string{
+			"./testdata/isolation/package-a",
+			"./testdata/isolation/conflict",
+		}).Complete()
+		assert.NoError(t, err, "rules with the same name in different packages should not conflict")
+
+		queryResult, err := queryer.Query(context.Background(), sources[0])
+		assert.NoError(t, err)
+		assert.Equal(t, 1, queryResult.Successes)
+		if assert.Len(t, queryResult.Passes, 1) {
+			assert.Equal(t, "data.main.deny_unapproved_registry_a", queryResult.Passes[0].Query)
+			assert.Equal(t, "fs:./testdata/isolation/package-a", queryResult.Passes[0].Package)
+		}
+		if assert.Len(t, queryResult.Failures, 1) {
+			assert.Equal(t, "data.main.deny_unapproved_registry_a", queryResult.Failures[0].Query)
+			assert.Equal(t, "conflicts with package-a", queryResult.Failures[0].Message)
+			assert.Equal(t, "fs:./testdata/isolation/conflict", queryResult.Failures[0].Package)
+		}
+	})
+
+	t.Run("duplicated packages", func(t *testing.T) {
+		_, err := QueryWithPolicy([]string{
+			"./testdata/isolation/package-a",
+			"./testdata/isolation/package-a",
+		}).Complete()
+		assert.ErrorContains(t, err, "is loaded more than once")
+	})
+}
+
+func Test_Integration_RuleSelector(t *testing.T) {
+	t.Parallel()
+
+	queryer, err := QueryWithPolicy([]string{
+		"./testdata/basic/policy",
+	}).WithRuleSelector(policy.RuleSelector{
+		Exclude: []string{"warn_*"},
+	}).Complete()
+	assert.NoError(t, err)
+
+	sources, err := source.FromPath([]string{
+		"./testdata/basic/configurations",
+	}).Complete()
+	assert.NoError(t, err)
+	assert.Len(t, sources, 1)
+
+	queryResult, err := queryer.Query(context.Background(), sources[0])
+	assert.NoError(t, err)
+	assert.Equal(t, 1, queryResult.Successes)
+	assert.Len(t, queryResult.Failures, 1)
+	assert.Len(t, queryResult.Exceptions, 1)
+	assert.Empty(t, queryResult.Warnings)
+
+	assert.Len(t, queryResult.Skipped, 3, "excluded rule should be skipped for each document")
+	for idx, skipped := range queryResult.Skipped {
+		assert.Equal(t, "data.main.warn_foo", skipped.Query)
+		assert.Equal(t, policy.QueryKindWarn, skipped.Rule.Kind)
+		assert.Equal(t, "https://example.com/foo-warn-001-foo", skipped.RuleDocLink)
+		assert.Equal(t, idx, skipped.Location.Document)
+	}
+
+	_, err = QueryWithPolicy([]string{
+		"./testdata/basic/policy",
+	}).WithRuleSelector(policy.RuleSelector{
+		Include: []string{"deny_["},
+	}).Complete()
+	assert.Error(t, err, "invalid pattern")
+}
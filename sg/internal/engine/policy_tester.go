@@ -0,0 +1,178 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/storage/inmem"
+	"github.com/open-policy-agent/opa/tester"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+)
+
+// policyTestFileSuffix is the file name suffix of the policy test modules.
+// Test modules are excluded from the coverage report.
+const policyTestFileSuffix = "_test.rego"
+
+// PolicyTestOptions controls the policy test behavior.
+type PolicyTestOptions struct {
+	// Filter is the regular expression for selecting the tests to run.
+	// Empty value means running all tests.
+	Filter string
+	// Coverage enables the coverage report.
+	Coverage bool
+}
+
+// PolicyTestReport is the report of the policy tests.
+type PolicyTestReport struct {
+	// Results is the list of test results, grouped by the test module files.
+	Results []result.QueryResults
+	// Coverage is the coverage report of the policy packages. It's nil if coverage is not enabled.
+	Coverage *result.CoverageReport
+}
+
+// policyTestSource is the source of the policy test results.
+type policyTestSource struct {
+	name string
+}
+
+func (s policyTestSource) Name() string {
+	return s.name
+}
+
+func (s policyTestSource) ParsedConfigurations() ([]ast.Value, error) {
+	// policy tests don't take configurations as input
+	return nil, nil
+}
+
+// RunPolicyTests runs the `test_*` rules of the policy packages loaded from the given paths.
+// The tests are evaluated with the same (per package) compilers used for querying.
+func RunPolicyTests(
+	ctx context.Context,
+	policyPaths []string,
+	dataPaths []policy.DataPath,
+	opts PolicyTestOptions,
+) (PolicyTestReport, error) {
+	packages, err := policy.LoadPackagesFromPaths(policyPaths)
+	if err != nil {
+		return PolicyTestReport{}, err
+	}
+	if err := policy.CheckPackageConflicts(packages); err != nil {
+		return PolicyTestReport{}, err
+	}
+
+	dataDocuments, err := policy.LoadDataFromPaths(dataPaths)
+	if err != nil {
+		return PolicyTestReport{}, err
+	}
+	if err := policy.CheckDataConflicts(packages, dataDocuments); err != nil {
+		return PolicyTestReport{}, err
+	}
+	data, err := policy.MergeDataDocuments(dataDocuments)
+	if err != nil {
+		return PolicyTestReport{}, fmt.Errorf("failed to merge data documents: %w", err)
+	}
+	store := inmem.NewFromObject(data)
+
+	var coverageTracer *CoverageTracer
+	if opts.Coverage {
+		coverageTracer = NewCoverageTracer()
+		coverageTracer.addPackages(packages)
+	}
+
+	resultsByFile := map[string]*result.QueryResults{}
+	var files []string
+	for _, p := range packages {
+		compiler, _, err := policy.NewRegoCompiler([]policy.Package{p})
+		if err != nil {
+			return PolicyTestReport{}, fmt.Errorf("failed to create compiler from package (%s): %w", p.QualifiedID(), err)
+		}
+
+		runner := tester.NewRunner().
+			SetCompiler(compiler).
+			SetStore(store).
+			Filter(opts.Filter)
+		if coverageTracer != nil {
+			runner.SetCoverageQueryTracer(coverageTracer)
+		}
+
+		ch, err := runner.RunTests(ctx, nil)
+		if err != nil {
+			return PolicyTestReport{}, fmt.Errorf("failed to run tests (%s): %w", p.QualifiedID(), err)
+		}
+
+		for testResult := range ch {
+			file := testResult.Location.File
+			queryResults, ok := resultsByFile[file]
+			if !ok {
+				queryResults = &result.QueryResults{
+					Source: policyTestSource{name: file},
+				}
+				resultsByFile[file] = queryResults
+				files = append(files, file)
+			}
+			addPolicyTestResult(queryResults, p, testResult)
+		}
+	}
+	sort.Strings(files)
+
+	rv := PolicyTestReport{}
+	for _, file := range files {
+		rv.Results = append(rv.Results, *resultsByFile[file])
+	}
+
+	if coverageTracer != nil {
+		report := coverageTracer.Report()
+		rv.Coverage = &report
+	}
+
+	return rv, nil
+}
+
+func addPolicyTestResult(queryResults *result.QueryResults, policyPackage policy.Package, testResult *tester.Result) {
+	name := testResult.Name
+	for _, prefix := range []string{tester.SkipTestPrefix, tester.TestPrefix} {
+		if strings.HasPrefix(name, prefix) {
+			name = strings.TrimPrefix(name, prefix)
+			break
+		}
+	}
+	rule := policy.Rule{
+		Kind:           policy.QueryKindTest,
+		Name:           name,
+		Namespace:      strings.TrimPrefix(testResult.Package, "data."),
+		SourceLocation: testResult.Location,
+	}
+
+	r := result.Result{
+		Query:   fmt.Sprintf("%s.%s", testResult.Package, testResult.Name),
+		Rule:    rule,
+		Package: policyPackage.QualifiedID(),
+		Location: result.Location{
+			File:   testResult.Location.File,
+			Line:   testResult.Location.Row,
+			Column: testResult.Location.Col,
+		},
+	}
+
+	switch {
+	case testResult.Error != nil:
+		r.Message = testResult.Error.Error()
+		queryResults.Errors = append(queryResults.Errors, r)
+	case testResult.Skip:
+		queryResults.Exceptions = append(queryResults.Exceptions, r)
+	case testResult.Fail:
+		r.Message = "test failed"
+		queryResults.Failures = append(queryResults.Failures, r)
+	default:
+		queryResults.Successes += 1
+		if queryResults.SuccessesByNamespace == nil {
+			queryResults.SuccessesByNamespace = map[string]int{}
+		}
+		queryResults.SuccessesByNamespace[rule.Namespace] += 1
+	}
+}
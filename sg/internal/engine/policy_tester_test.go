@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+)
+
+func Test_RunPolicyTests(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	policyPaths := []string{"./testdata/policy-test/policy"}
+	dataPaths := []policy.DataPath{{Path: "./testdata/policy-test/data"}}
+
+	report, err := RunPolicyTests(ctx, policyPaths, dataPaths, PolicyTestOptions{})
+	assert.NoError(t, err)
+	assert.Nil(t, report.Coverage, "coverage is not enabled")
+	assert.Len(t, report.Results, 1, "results should be grouped by test file")
+
+	results := report.Results[0]
+	assert.Equal(t, "testdata/policy-test/policy/001-no_latest_tag_test.rego", results.Source.Name())
+	assert.Equal(t, 3, results.Successes)
+	assert.Len(t, results.Failures, 1)
+	assert.Equal(t, "data.main.test_deny_unapproved_registry_broken", results.Failures[0].Query)
+	assert.Equal(t, policy.QueryKindTest, results.Failures[0].Rule.Kind)
+	assert.Equal(t, "deny_unapproved_registry_broken", results.Failures[0].Rule.Name)
+	assert.Equal(t, 20, results.Failures[0].Location.Line)
+	assert.Len(t, results.Exceptions, 1, "todo tests should be reported as exceptions")
+	assert.Equal(t, "data.main.todo_test_deny_init_containers", results.Exceptions[0].Query)
+	assert.Empty(t, results.Errors)
+}
+
+func Test_RunPolicyTests_filterAndCoverage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	policyPaths := []string{"./testdata/policy-test/policy"}
+	dataPaths := []policy.DataPath{{Path: "./testdata/policy-test/data"}}
+
+	report, err := RunPolicyTests(ctx, policyPaths, dataPaths, PolicyTestOptions{
+		Filter:   "latest_tag",
+		Coverage: true,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, report.Results, 1)
+	assert.Equal(t, 1, report.Results[0].Successes)
+	assert.Empty(t, report.Results[0].Failures)
+
+	if assert.NotNil(t, report.Coverage) {
+		assert.Len(t, report.Coverage.Packages, 1)
+		files := report.Coverage.Packages[0].Files
+		assert.Len(t, files, 1, "test modules should be excluded")
+		fileCoverage := files[0]
+		assert.Equal(t, "testdata/policy-test/policy/001-no_latest_tag.rego", fileCoverage.File)
+		assert.Greater(t, fileCoverage.Lines.Covered, 0)
+		assert.Greater(t, fileCoverage.Lines.NotCovered, 0, "unapproved registry rule is not tested")
+
+		assert.Len(t, fileCoverage.Rules, 2)
+		assert.Equal(t, "deny_latest_tag", fileCoverage.Rules[0].Rule.Query())
+		assert.True(t, fileCoverage.Rules[0].Fired)
+		assert.Equal(t, "deny_unapproved_registry", fileCoverage.Rules[1].Rule.Query())
+		assert.False(t, fileCoverage.Rules[1].Fired)
+	}
+}
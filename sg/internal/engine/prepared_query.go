@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"context"
+	"sync"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage"
+)
+
+// preparedQuery is a query prepared once and shared by all inputs.
+type preparedQuery struct {
+	once  sync.Once
+	query rego.PreparedEvalQuery
+	err   error
+}
+
+// preparedQueries book-keeps the prepared queries of a compiler.
+// The prepared queries are safe to be evaluated concurrently.
+type preparedQueries struct {
+	compiler *ast.Compiler
+	store    storage.Store
+
+	mu    sync.Mutex
+	items map[string]*preparedQuery
+}
+
+func newPreparedQueries(compiler *ast.Compiler, store storage.Store) *preparedQueries {
+	return &preparedQueries{
+		compiler: compiler,
+		store:    store,
+		items:    map[string]*preparedQuery{},
+	}
+}
+
+// get returns the prepared query. The query is prepared on first use.
+func (pq *preparedQueries) get(ctx context.Context, query string) (rego.PreparedEvalQuery, error) {
+	pq.mu.Lock()
+	item, ok := pq.items[query]
+	if !ok {
+		item = &preparedQuery{}
+		pq.items[query] = item
+	}
+	pq.mu.Unlock()
+
+	item.once.Do(func() {
+		item.query, item.err = rego.New(
+			rego.Query(query),
+			rego.Compiler(pq.compiler),
+			rego.Store(pq.store),
+		).PrepareForEval(ctx)
+	})
+
+	return item.query, item.err
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage/inmem"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+)
+
+func Test_preparedQueries(t *testing.T) {
+	packages, err := policy.LoadPackagesFromPaths([]string{"./testdata/basic/policy"})
+	assert.NoError(t, err)
+	compiler, _, err := policy.NewRegoCompiler(packages)
+	assert.NoError(t, err)
+
+	pq := newPreparedQueries(compiler, inmem.New())
+	ctx := context.Background()
+
+	t.Run("prepare once", func(t *testing.T) {
+		const query = "data.main.deny_foo"
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := pq.get(ctx, query)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, pq.items, 1)
+	})
+
+	t.Run("reuse across inputs", func(t *testing.T) {
+		preparedQuery, err := pq.get(ctx, "input.foo")
+		assert.NoError(t, err)
+
+		for _, v := range []string{"bar", "baz"} {
+			input := ast.NewObject(ast.Item(ast.StringTerm("foo"), ast.StringTerm(v)))
+			rs, err := preparedQuery.Eval(ctx, rego.EvalParsedInput(input))
+			assert.NoError(t, err)
+			assert.Len(t, rs, 1)
+			assert.Equal(t, v, rs[0].Expressions[0].Value)
+		}
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		_, err := pq.get(ctx, "data.main.[")
+		assert.Error(t, err)
+		_, err = pq.get(ctx, "data.main.[")
+		assert.Error(t, err, "error should be kept")
+	})
+}
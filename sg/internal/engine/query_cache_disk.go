@@ -0,0 +1,269 @@
+package engine
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+)
+
+const (
+	// diskQueryCacheVersion is the version of the on-disk cache format.
+	// Bump it when the cache entry format changes to invalidate existing entries.
+	diskQueryCacheVersion = "v1"
+
+	diskQueryCacheEntryExt = ".json"
+
+	// diskQueryCacheTempFilePrefix is the name prefix of the temp files for writing the entries.
+	diskQueryCacheTempFilePrefix = "tmp-"
+	// diskQueryCacheStaleTempFileAge is the age of the temp files to be considered as left by crashed writes.
+	// Younger temp files might be written by other processes, so they are kept.
+	diskQueryCacheStaleTempFileAge = 5 * time.Minute
+
+	// DefaultDiskQueryCacheMaxSize is the default size bound (in bytes) of the disk query cache.
+	DefaultDiskQueryCacheMaxSize int64 = 512 * 1024 * 1024
+)
+
+// diskCacheKey returns the content based key for persisting the query results.
+// Unlike cacheKey, it hashes the full input content so it's safe to be shared across runs.
+func (k queryCacheKey) diskCacheKey() string {
+	h := sha256.New()
+	for _, s := range []string{
+		diskQueryCacheVersion,
+		k.compilerKey,
+		k.parsedInput.String(),
+		k.query,
+	} {
+		fmt.Fprintf(h, "%d:%s;", len(s), s)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// diskQueryCacheResult is the persisted form of a query result.
+// Only fields resolved from the query evaluation are persisted.
+type diskQueryCacheResult struct {
+	Query    string                 `json:"query"`
+	Message  string                 `json:"message,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type diskQueryCacheEntry struct {
+	Results []diskQueryCacheResult `json:"results"`
+}
+
+type diskQueryCacheFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+	// staleTemp tells if the file is a stale temp file, which is removed before any entry on eviction.
+	staleTemp bool
+}
+
+// diskQueryCache is a QueryCache implementation backed by files in a directory.
+// Entries are evicted in least recently used order when the total size exceeds the bound.
+//
+// NOTE: the cache is best-effort, I/O errors are treated as cache misses.
+type diskQueryCache struct {
+	dir     string
+	maxSize int64
+
+	mu   sync.Mutex
+	size int64
+}
+
+// NewDiskQueryCache creates a QueryCache persisting the query results in the given directory.
+// The directory will be created if not exists. maxSize specifies the size bound (in bytes) of the cache,
+// non-positive value means using DefaultDiskQueryCacheMaxSize.
+func NewDiskQueryCache(dir string, maxSize int64) (QueryCache, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("query cache directory is not specified")
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultDiskQueryCacheMaxSize
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create query cache directory: %w", err)
+	}
+
+	rv := &diskQueryCache{
+		dir:     dir,
+		maxSize: maxSize,
+	}
+
+	if _, err := rv.listFiles(); err != nil {
+		return nil, fmt.Errorf("list query cache directory: %w", err)
+	}
+	// NOTE: sync the size, and clean up the stale temp files left by previous runs
+	rv.mu.Lock()
+	rv.evict()
+	rv.mu.Unlock()
+
+	return rv, nil
+}
+
+var _ QueryCache = (*diskQueryCache)(nil)
+
+func (qc *diskQueryCache) entryPath(key queryCacheKey) string {
+	return filepath.Join(qc.dir, key.diskCacheKey()+diskQueryCacheEntryExt)
+}
+
+func (qc *diskQueryCache) get(key queryCacheKey) ([]result.Result, bool) {
+	p := qc.entryPath(key)
+
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, false
+	}
+
+	var entry diskQueryCacheEntry
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	// NOTE: keep numbers as json.Number to match the values returned by rego
+	decoder.UseNumber()
+	if err := decoder.Decode(&entry); err != nil {
+		return nil, false
+	}
+
+	// mark as recently used
+	now := time.Now()
+	_ = os.Chtimes(p, now, now)
+
+	rv := make([]result.Result, 0, len(entry.Results))
+	for _, r := range entry.Results {
+		rv = append(rv, result.Result{
+			Query:    r.Query,
+			Message:  r.Message,
+			Metadata: r.Metadata,
+		})
+	}
+
+	return rv, true
+}
+
+func (qc *diskQueryCache) set(key queryCacheKey, value []result.Result) {
+	entry := diskQueryCacheEntry{
+		Results: make([]diskQueryCacheResult, 0, len(value)),
+	}
+	for _, r := range value {
+		entry.Results = append(entry.Results, diskQueryCacheResult{
+			Query:    r.Query,
+			Message:  r.Message,
+			Metadata: r.Metadata,
+		})
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	p := qc.entryPath(key)
+	var prevSize int64
+	if fi, err := os.Stat(p); err == nil {
+		prevSize = fi.Size()
+	}
+
+	// write to a temp file first so concurrent readers never see partial content
+	tmpFile, err := os.CreateTemp(qc.dir, diskQueryCacheTempFilePrefix+"*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(b); err != nil {
+		tmpFile.Close()
+		return
+	}
+	if err := tmpFile.Close(); err != nil {
+		return
+	}
+	if err := os.Rename(tmpFile.Name(), p); err != nil {
+		return
+	}
+
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+
+	qc.size += int64(len(b)) - prevSize
+	if qc.size > qc.maxSize {
+		qc.evict()
+	}
+}
+
+// evict removes the stale temp files, then the least recently used entries until the cache size is within the bound.
+// It must be called with qc.mu held.
+func (qc *diskQueryCache) evict() {
+	files, err := qc.listFiles()
+	if err != nil {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].staleTemp != files[j].staleTemp {
+			return files[i].staleTemp
+		}
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	// NOTE: resync the size as the directory can be shared with other processes
+	qc.size = 0
+	for _, f := range files {
+		qc.size += f.size
+	}
+
+	for _, f := range files {
+		if qc.size <= qc.maxSize && !f.staleTemp {
+			return
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			continue
+		}
+		qc.size -= f.size
+	}
+}
+
+// listFiles lists the entries and the stale temp files in the cache directory.
+func (qc *diskQueryCache) listFiles() ([]diskQueryCacheFile, error) {
+	entries, err := os.ReadDir(qc.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var rv []diskQueryCacheFile
+	for _, entry := range entries {
+		isEntry := strings.HasSuffix(entry.Name(), diskQueryCacheEntryExt)
+		isTemp := strings.HasPrefix(entry.Name(), diskQueryCacheTempFilePrefix)
+		if entry.IsDir() || (!isEntry && !isTemp) {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// removed by others
+				continue
+			}
+			return nil, err
+		}
+		staleTemp := isTemp && time.Since(fi.ModTime()) > diskQueryCacheStaleTempFileAge
+		if isTemp && !staleTemp {
+			// being written
+			continue
+		}
+		rv = append(rv, diskQueryCacheFile{
+			path:      filepath.Join(qc.dir, entry.Name()),
+			size:      fi.Size(),
+			modTime:   fi.ModTime(),
+			staleTemp: staleTemp,
+		})
+	}
+
+	return rv, nil
+}
@@ -1,8 +1,13 @@
 package engine
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/Azure/ShieldGuard/sg/internal/result"
 	"github.com/open-policy-agent/opa/ast"
@@ -98,3 +103,134 @@ func Test_QueryCache(t *testing.T) {
 		}
 	})
 }
+
+func Test_DiskQueryCache(t *testing.T) {
+	t.Run("basic", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		cacheKey := queryCacheKey{
+			compilerKey: "compilerKey",
+			parsedInput: ast.NewObject(ast.Item(ast.StringTerm("foo"), ast.StringTerm("bar"))),
+			query:       "query",
+		}
+		queryResult := []result.Result{
+			{
+				Query:   "query",
+				Message: "message",
+				Metadata: map[string]interface{}{
+					"path": []interface{}{"spec", json.Number("0")},
+				},
+			},
+			{
+				Query: "query",
+			},
+		}
+
+		qc, err := NewDiskQueryCache(dir, 0)
+		assert.NoError(t, err)
+
+		_, ok := qc.get(cacheKey)
+		assert.False(t, ok)
+
+		qc.set(cacheKey, queryResult)
+		cached, ok := qc.get(cacheKey)
+		assert.True(t, ok)
+		assert.Equal(t, queryResult, cached)
+
+		// shared across instances
+		qc2, err := NewDiskQueryCache(dir, 0)
+		assert.NoError(t, err)
+		cached, ok = qc2.get(cacheKey)
+		assert.True(t, ok)
+		assert.Equal(t, queryResult, cached)
+
+		// different input content
+		otherCacheKey := cacheKey
+		otherCacheKey.parsedInput = ast.NewObject(ast.Item(ast.StringTerm("foo"), ast.StringTerm("baz")))
+		_, ok = qc2.get(otherCacheKey)
+		assert.False(t, ok)
+	})
+
+	t.Run("eviction", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		queryResult := []result.Result{
+			{
+				Query:   "query",
+				Message: strings.Repeat("x", 100),
+			},
+		}
+		cacheKeyOf := func(i int) queryCacheKey {
+			return queryCacheKey{
+				compilerKey: "compilerKey",
+				parsedInput: ast.IntNumberTerm(i).Value,
+				query:       "query",
+			}
+		}
+
+		// each entry is larger than 100 bytes, so at most 2 entries can be kept
+		qc, err := NewDiskQueryCache(dir, 300)
+		assert.NoError(t, err)
+
+		for i := 0; i < 5; i++ {
+			qc.set(cacheKeyOf(i), queryResult)
+			// ensure the modification times are ordered
+			ts := time.Now().Add(time.Duration(i-10) * time.Second)
+			assert.NoError(t, os.Chtimes(qc.(*diskQueryCache).entryPath(cacheKeyOf(i)), ts, ts))
+		}
+
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+
+		for i := 0; i < 3; i++ {
+			_, ok := qc.get(cacheKeyOf(i))
+			assert.False(t, ok, "entry #%d should be evicted", i)
+		}
+		for i := 3; i < 5; i++ {
+			_, ok := qc.get(cacheKeyOf(i))
+			assert.True(t, ok, "entry #%d should be kept", i)
+		}
+	})
+
+	t.Run("stale temp files", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeFile := func(name string, age time.Duration) string {
+			p := filepath.Join(dir, name)
+			assert.NoError(t, os.WriteFile(p, []byte(strings.Repeat("x", 100)), 0644))
+			ts := time.Now().Add(-age)
+			assert.NoError(t, os.Chtimes(p, ts, ts))
+			return p
+		}
+		staleTemp := writeFile("tmp-stale", time.Hour)
+		freshTemp := writeFile("tmp-fresh", time.Second)
+
+		qc, err := NewDiskQueryCache(dir, 1000)
+		assert.NoError(t, err)
+		assert.NoFileExists(t, staleTemp, "stale temp file should be removed")
+		assert.FileExists(t, freshTemp, "temp file being written should be kept")
+
+		// orphaned by a crashed write after the cache is opened
+		staleTemp = writeFile("tmp-stale-2", time.Hour)
+		cacheKey := queryCacheKey{
+			compilerKey: "compilerKey",
+			parsedInput: ast.IntNumberTerm(0).Value,
+			query:       "query",
+		}
+		qc.set(cacheKey, []result.Result{{Query: "query", Message: strings.Repeat("x", 1000)}})
+		assert.NoFileExists(t, staleTemp, "stale temp file should be removed on eviction")
+	})
+
+	t.Run("invalid directory", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewDiskQueryCache("", 0)
+		assert.Error(t, err)
+	})
+}
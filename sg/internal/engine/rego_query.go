@@ -3,6 +3,7 @@ package engine
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/rego"
@@ -17,18 +18,21 @@ import (
 
 type loadedConfiguration struct {
 	Name          string
+	Source        source.Source
+	DocumentIndex int
+	Identity      string
 	Configuration ast.Value
 }
 
-func loadSource(source source.Source, shouldParseArmTemplateDefaults bool) ([]loadedConfiguration, error) {
+func loadSource(src source.Source, shouldParseArmTemplateDefaults bool) ([]loadedConfiguration, error) {
 	var rv []loadedConfiguration
 
-	configurations, err := source.ParsedConfigurations()
+	configurations, err := src.ParsedConfigurations()
 	if err != nil {
 		return nil, err
 	}
 
-	for _, configuration := range configurations {
+	for idx, configuration := range configurations {
 		t := ast.NewTerm(configuration)
 
 		if shouldParseArmTemplateDefaults {
@@ -36,7 +40,10 @@ func loadSource(source source.Source, shouldParseArmTemplateDefaults bool) ([]lo
 		}
 
 		rv = append(rv, loadedConfiguration{
-			Name:          source.Name(),
+			Name:          src.Name(),
+			Source:        src,
+			DocumentIndex: idx,
+			Identity:      source.DocumentIdentity(t.Value),
 			Configuration: t.Value,
 		})
 	}
@@ -45,26 +52,46 @@ func loadSource(source source.Source, shouldParseArmTemplateDefaults bool) ([]lo
 }
 
 // PackageMain is the name of the main package.
-// To ease the usage, we will only use rules from main package.
-const PackageMain = "main"
+// Without specifying the namespaces, we will only use rules from main package.
+const PackageMain = policy.NamespaceMain
+
+// compiledPackage is a policy package compiled in isolation.
+type compiledPackage struct {
+	policyPackage policy.Package
+	// compilerKey identifies the package and the data documents.
+	compilerKey     string
+	preparedQueries *preparedQueries
+}
 
 // RegoEngine is the OPA based query engine implementation.
 type RegoEngine struct {
-	policyPackages           []policy.Package
-	compiler                 *ast.Compiler
+	compiledPackages []compiledPackage
+	// compilerKey identifies all the packages and the data documents.
 	compilerKey              string
 	limiter                  limiter
 	queryCache               QueryCache
+	coverageTracer           *CoverageTracer
 	parseArmTemplateDefaults bool
+	// namespaces overrides the namespaces to query from the policy packages.
+	namespaces []string
+	// ruleSelector selects the rules to query, rules not selected are reported as skipped.
+	ruleSelector policy.RuleSelector
 }
 
 var _ Queryer = (*RegoEngine)(nil)
 
+// CompilerKey returns the key identifying the policy packages and the data documents of the engine.
+func (engine *RegoEngine) CompilerKey() string {
+	return engine.compilerKey
+}
+
 func (engine *RegoEngine) Query(
 	ctx context.Context,
 	source source.Source,
 	opts ...*QueryOptions,
 ) (result.QueryResults, error) {
+	queryOpts := mergeQueryOptions(opts)
+
 	loadedConfigurations, err := loadSource(source, engine.parseArmTemplateDefaults)
 	if err != nil {
 		return result.QueryResults{}, fmt.Errorf("failed to load source: %w", err)
@@ -72,36 +99,64 @@ func (engine *RegoEngine) Query(
 
 	var aggregatedQueryResults result.QueryResults
 	for _, loadedConfiguration := range loadedConfigurations {
-		for _, policyPackage := range engine.policyPackages {
-			queryResult, err := engine.queryPackage(ctx, policyPackage, loadedConfiguration)
+		for _, compiledPackage := range engine.compiledPackages {
+			queryResult, err := engine.queryPackage(ctx, compiledPackage, loadedConfiguration, queryOpts)
 			if err != nil {
 				return result.QueryResults{}, err
 			}
 			aggregatedQueryResults = aggregatedQueryResults.Merge(queryResult)
 		}
+		aggregatedQueryResults.Documents = append(aggregatedQueryResults.Documents, result.Document{
+			Index:    loadedConfiguration.DocumentIndex,
+			Identity: loadedConfiguration.Identity,
+		})
 	}
 
 	aggregatedQueryResults.Source = source
+	aggregatedQueryResults.CompilerKey = engine.compilerKey
 
 	return aggregatedQueryResults, nil
 }
 
 func (engine *RegoEngine) queryPackage(
 	ctx context.Context,
-	policyPackage policy.Package,
+	compiledPackage compiledPackage,
 	loadedConfiguration loadedConfiguration,
+	queryOpts *QueryOptions,
 ) (result.QueryResults, error) {
+	policyPackage := compiledPackage.policyPackage
+
 	// NOTE: because an rego query returns all failures for a given rule,
 	//       even if the rule is repeated with different bodies. Therefore,
 	//       we should only query the distinct rules. At the end, the total success
 	//       rules should be the count of total rules minus the query results plus
 	//       succeeded query results.
 
-	allRules := policyPackage.Rules()
+	queryNamespaces := engine.queryNamespaces(policyPackage)
+	var allRules []policy.Rule
+	var skippedResults []result.Result
+	skippedRulesSet := map[string]struct{}{}
+	for _, rule := range policyPackage.Rules() {
+		if !slices.Contains(queryNamespaces, rule.Namespace) {
+			// we only care about rules in the queried namespaces
+			continue
+		}
+		if engine.ruleSelector.Selects(rule) {
+			allRules = append(allRules, rule)
+			continue
+		}
+
+		skippedRuleKey := rule.Namespace + "." + rule.Query()
+		if _, ok := skippedRulesSet[skippedRuleKey]; ok {
+			continue
+		}
+		skippedRulesSet[skippedRuleKey] = struct{}{}
+		skippedResults = append(skippedResults, queryRuleSkippedResult(policyPackage, rule, loadedConfiguration))
+	}
 	distinctRules := make([]policy.Rule, 0, len(allRules))
 	rulesSet := make(map[string]struct{}, len(allRules))
 	for _, rule := range allRules {
-		primaryRuleKey := rule.Query()
+		primaryRuleKey := rule.Namespace + "." + rule.Query()
 		if _, ok := rulesSet[primaryRuleKey]; ok {
 			// skip duplicate rules
 			continue
@@ -123,11 +178,6 @@ func (engine *RegoEngine) queryPackage(
 
 			rv := result.QueryResults{}
 
-			if rule.Namespace != PackageMain {
-				// we only care about rules in the main package
-				return rv, nil
-			}
-
 			if !rule.IsKind(policy.QueryKindWarn, policy.QueryKindDeny, policy.QueryKindViolation) {
 				// not a query rule
 				return rv, nil
@@ -135,32 +185,163 @@ func (engine *RegoEngine) queryPackage(
 
 			if err := engine.queryRule(
 				ctx,
-				policyPackage, rule,
-				loadedConfiguration, &rv,
+				compiledPackage, rule,
+				loadedConfiguration, queryOpts, &rv,
 			); err != nil {
-				return rv, fmt.Errorf("failed to query rule: %w", err)
+				// NOTE: evaluation errors are reported per rule, so a broken rule
+				//       can neither fail the other rules nor pass silently.
+				return queryRuleErrorResults(policyPackage, rule, loadedConfiguration, err), nil
 			}
+			rv.SuccessesByNamespace = map[string]int{rule.Namespace: rv.Successes}
 
 			return rv, nil
 		},
 	)
 	if err != nil {
-		return result.QueryResults{}, nil
+		return result.QueryResults{}, fmt.Errorf("failed to query package (%s): %w", policyPackage.QualifiedID(), err)
 	}
 
-	queryResult := result.QueryResults{}
+	queryResult := result.QueryResults{
+		Skipped: skippedResults,
+	}
 	for _, qr := range queryResults {
 		queryResult = queryResult.Merge(qr)
 	}
 
-	resultsCount := queryResult.Successes + len(queryResult.Failures) + len(queryResult.Warnings) + len(queryResult.Exceptions)
-	if duplicatedRulesCount := len(allRules) - resultsCount; duplicatedRulesCount > 0 {
-		queryResult.Successes += duplicatedRulesCount
+	for _, namespace := range queryNamespaces {
+		inNamespace := func(r result.Result) bool { return r.Rule.Namespace == namespace }
+		rulesInNamespace := utils.Filter(allRules, func(rule policy.Rule) bool { return rule.Namespace == namespace })
+		resultsCount := queryResult.SuccessesByNamespace[namespace] +
+			len(utils.Filter(queryResult.Failures, inNamespace)) +
+			len(utils.Filter(queryResult.Warnings, inNamespace)) +
+			len(utils.Filter(queryResult.Exceptions, inNamespace)) +
+			len(utils.Filter(queryResult.Errors, inNamespace))
+		if duplicatedRulesCount := len(rulesInNamespace) - resultsCount; duplicatedRulesCount > 0 {
+			queryResult.Successes += duplicatedRulesCount
+			if queryResult.SuccessesByNamespace == nil {
+				queryResult.SuccessesByNamespace = map[string]int{}
+			}
+			queryResult.SuccessesByNamespace[namespace] += duplicatedRulesCount
+			for _, rule := range duplicatedPassedRules(rulesInNamespace, queryResult)[:duplicatedRulesCount] {
+				queryResult.Passes = append(queryResult.Passes, queryRulePassedResult(policyPackage, rule, loadedConfiguration))
+			}
+		}
 	}
 
 	return queryResult, nil
 }
 
+// duplicatedPassedRules returns the rules counted as passed by the duplicated rules adjustment (see queryPackage),
+// which are the distinct rules without any result, then the rest of the rule definitions, in definition order.
+// The count of the returned rules is no less than the adjustment.
+func duplicatedPassedRules(rules []policy.Rule, queryResult result.QueryResults) []policy.Rule {
+	ruleKey := func(rule policy.Rule) string { return rule.Namespace + "." + rule.Query() }
+
+	rulesWithResults := map[string]struct{}{}
+	for _, results := range [][]result.Result{
+		queryResult.Passes, queryResult.Failures, queryResult.Warnings, queryResult.Exceptions, queryResult.Errors,
+	} {
+		for _, r := range results {
+			rulesWithResults[ruleKey(r.Rule)] = struct{}{}
+		}
+	}
+
+	var distinctRules, restRules []policy.Rule
+	seen := map[string]struct{}{}
+	for _, rule := range rules {
+		key := ruleKey(rule)
+		if _, ok := seen[key]; ok {
+			restRules = append(restRules, rule)
+			continue
+		}
+		seen[key] = struct{}{}
+		if _, ok := rulesWithResults[key]; ok {
+			continue
+		}
+		distinctRules = append(distinctRules, rule)
+	}
+
+	return append(distinctRules, restRules...)
+}
+
+// queryNamespaces returns the namespaces to query rules from the policy package.
+func (engine *RegoEngine) queryNamespaces(policyPackage policy.Package) []string {
+	if len(engine.namespaces) > 0 {
+		return engine.namespaces
+	}
+	return policyPackage.Spec().QueryNamespaces()
+}
+
+// queryRuleErrorResults creates the query results for a rule failed to evaluate.
+func queryRuleErrorResults(
+	policyPackage policy.Package,
+	policyRule policy.Rule,
+	loadedConfiguration loadedConfiguration,
+	err error,
+) result.QueryResults {
+	// doc link is optional for error results
+	docLink, _ := resolveRuleDocLinkFn(policyPackage)(policyRule)
+
+	return result.QueryResults{
+		Errors: []result.Result{
+			{
+				Query:       fmt.Sprintf("data.%s.%s", policyRule.Namespace, policyRule.Query()),
+				Rule:        policyRule,
+				RuleDocLink: docLink,
+				Package:     policyPackage.QualifiedID(),
+				Message:     err.Error(),
+				Location: result.Location{
+					File:     loadedConfiguration.Name,
+					Document: loadedConfiguration.DocumentIndex,
+					Identity: loadedConfiguration.Identity,
+				},
+			},
+		},
+	}
+}
+
+// queryRulePassedResult creates the result for a passed rule.
+// NOTE: this is on the hot path (once per passed rule per document), and presenters only use the rule
+// of passed results, so the doc link is not resolved.
+func queryRulePassedResult(
+	policyPackage policy.Package,
+	policyRule policy.Rule,
+	loadedConfiguration loadedConfiguration,
+) result.Result {
+	return result.Result{
+		Query:   fmt.Sprintf("data.%s.%s", policyRule.Namespace, policyRule.Query()),
+		Rule:    policyRule,
+		Package: policyPackage.QualifiedID(),
+		Location: result.Location{
+			File:     loadedConfiguration.Name,
+			Document: loadedConfiguration.DocumentIndex,
+			Identity: loadedConfiguration.Identity,
+		},
+	}
+}
+
+// queryRuleSkippedResult creates the result for a rule skipped by the rule selector.
+func queryRuleSkippedResult(
+	policyPackage policy.Package,
+	policyRule policy.Rule,
+	loadedConfiguration loadedConfiguration,
+) result.Result {
+	// doc link is optional for skipped results
+	docLink, _ := resolveRuleDocLinkFn(policyPackage)(policyRule)
+
+	return result.Result{
+		Query:       fmt.Sprintf("data.%s.%s", policyRule.Namespace, policyRule.Query()),
+		Rule:        policyRule,
+		RuleDocLink: docLink,
+		Package:     policyPackage.QualifiedID(),
+		Location: result.Location{
+			File:     loadedConfiguration.Name,
+			Document: loadedConfiguration.DocumentIndex,
+			Identity: loadedConfiguration.Identity,
+		},
+	}
+}
+
 func resolveRuleDocLinkFn(policyPackage policy.Package) func(policy.Rule) (string, error) {
 	// TODO(hbc): cache resolved doc link by rule
 	return func(rule policy.Rule) (string, error) {
@@ -168,18 +349,63 @@ func resolveRuleDocLinkFn(policyPackage policy.Package) func(policy.Rule) (strin
 	}
 }
 
+// resultMetadataPathField is the result metadata field for reporting the JSON path of the node.
+const resultMetadataPathField = "path"
+
+func resolveResultLocation(loadedConfiguration loadedConfiguration, r result.Result) result.Location {
+	rv := result.Location{
+		File:     loadedConfiguration.Name,
+		Document: loadedConfiguration.DocumentIndex,
+		Identity: loadedConfiguration.Identity,
+	}
+
+	rawPath, ok := r.Metadata[resultMetadataPathField]
+	if !ok {
+		return rv
+	}
+	path, err := source.ParsePath(rawPath)
+	if err != nil {
+		// path is optional, ignore invalid values
+		return rv
+	}
+	positionResolver, ok := loadedConfiguration.Source.(source.PositionResolver)
+	if !ok {
+		return rv
+	}
+	if pos, ok := positionResolver.ResolvePosition(loadedConfiguration.DocumentIndex, path); ok {
+		rv.Line = pos.Line
+		rv.Column = pos.Column
+	}
+
+	return rv
+}
+
+// resolveResultSeverity resolves the severity of the result.
+// The severity reported by the result metadata takes precedence over the rule annotations.
+func resolveResultSeverity(policyRule policy.Rule, r result.Result) policy.Severity {
+	severity, err := policy.SeverityFromMetadata(r.Metadata)
+	if err != nil || severity == policy.SeverityUnspecified {
+		// severity is optional, fallback to the rule severity for invalid values
+		return policyRule.Severity()
+	}
+	return severity
+}
+
 func (engine *RegoEngine) queryRule(
 	ctx context.Context,
-	policyPackage policy.Package,
+	compiledPackage compiledPackage,
 	policyRule policy.Rule,
 	loadedConfiguration loadedConfiguration,
+	queryOpts *QueryOptions,
 	queryResult *result.QueryResults,
 ) error {
+	policyPackage := compiledPackage.policyPackage
 	resolveRuleDocLink := resolveRuleDocLinkFn(policyPackage)
 
 	// execute exception query
-	exceptionQuery := fmt.Sprintf("data.%s.exception[_][_] == %q", PackageMain, policyRule.Name)
-	exceptions, err := engine.executeOneQuery(ctx, loadedConfiguration.Configuration, exceptionQuery)
+	// NOTE: exceptions are resolved in the same namespace of the rule
+	exceptionQuery := fmt.Sprintf("data.%s.exception[_][_] == %q", policyRule.Namespace, policyRule.Name)
+	exceptions, err := engine.executeOneQuery(ctx, compiledPackage, loadedConfiguration.Configuration, exceptionQuery)
 	if err != nil {
 		return fmt.Errorf("failed to execute exception query (%q): %w", exceptionQuery, err)
 	}
@@ -187,8 +413,12 @@ func (engine *RegoEngine) queryRule(
 
 	// execute query
 	// NOTE: even if the exception query returns true, we still execute the query
-	query := fmt.Sprintf("data.%s.%s", PackageMain, policyRule.Query())
-	results, err := engine.executeOneQuery(ctx, loadedConfiguration.Configuration, query)
+	query := fmt.Sprintf("data.%s.%s", policyRule.Namespace, policyRule.Query())
+	executeQuery := engine.executeOneQuery
+	if queryOpts.shouldExplain(policyRule) {
+		executeQuery = engine.executeOneQueryExplain
+	}
+	results, err := executeQuery(ctx, compiledPackage, loadedConfiguration.Configuration, query)
 	if err != nil {
 		return fmt.Errorf("failed to execute query (%q): %w", query, err)
 	}
@@ -202,6 +432,8 @@ func (engine *RegoEngine) queryRule(
 				return fmt.Errorf("resolve rule doc link failed: %w", err)
 			}
 			exceptions[idx].RuleDocLink = docLink
+			exceptions[idx].Package = policyPackage.QualifiedID()
+			exceptions[idx].Location = resolveResultLocation(loadedConfiguration, exceptions[idx])
 		}
 		queryResult.Exceptions = append(queryResult.Exceptions, exceptions...)
 		return nil
@@ -210,6 +442,7 @@ func (engine *RegoEngine) queryRule(
 	for _, result := range results {
 		if result.Passed() {
 			queryResult.Successes += 1
+			queryResult.Passes = append(queryResult.Passes, queryRulePassedResult(policyPackage, policyRule, loadedConfiguration))
 			continue
 		}
 
@@ -219,6 +452,9 @@ func (engine *RegoEngine) queryRule(
 			return fmt.Errorf("resolve rule doc link failed: %w", err)
 		}
 		result.RuleDocLink = ruleDocLink
+		result.Package = policyPackage.QualifiedID()
+		result.Location = resolveResultLocation(loadedConfiguration, result)
+		result.Severity = resolveResultSeverity(policyRule, result)
 
 		switch {
 		case policyRule.IsKind(policy.QueryKindWarn):
@@ -231,28 +467,21 @@ func (engine *RegoEngine) queryRule(
 	return nil
 }
 
-func (engine *RegoEngine) createRegoInstance(
-	parsedInput ast.Value,
-	query string,
-) *rego.Rego {
-	opts := []func(*rego.Rego){
-		rego.ParsedInput(parsedInput),
-		rego.Query(query), // TODO: consider pre-compile query for perf
-		rego.Compiler(engine.compiler),
-	}
-
-	return rego.New(opts...)
-}
-
 func (engine *RegoEngine) executeOneQuery(
 	ctx context.Context,
+	compiledPackage compiledPackage,
 	parsedInput ast.Value,
 	query string,
 ) ([]result.Result, error) {
+	if engine.coverageTracer != nil {
+		// NOTE: cached results are not evaluated, which can't be traced for coverage
+		return engine.executeOneQuerySlow(ctx, compiledPackage, parsedInput, query)
+	}
+
 	// NOTE: we expect the policy implementation is deterministic, which provides
 	// the same results for the same policy rules, input and query.
 	cacheKey := queryCacheKey{
-		compilerKey: engine.compilerKey,
+		compilerKey: compiledPackage.compilerKey,
 		parsedInput: parsedInput,
 		query:       query,
 	}
@@ -260,7 +489,7 @@ func (engine *RegoEngine) executeOneQuery(
 		return cachedResults, nil
 	}
 
-	results, err := engine.executeOneQuerySlow(ctx, parsedInput, query)
+	results, err := engine.executeOneQuerySlow(ctx, compiledPackage, parsedInput, query)
 	if err != nil {
 		return nil, err
 	}
@@ -269,13 +498,41 @@ func (engine *RegoEngine) executeOneQuery(
 	return results, nil
 }
 
+// executeOneQueryExplain executes the query with capturing the explanations of the results.
+// The query cache is bypassed as cached results are not evaluated.
+func (engine *RegoEngine) executeOneQueryExplain(
+	ctx context.Context,
+	compiledPackage compiledPackage,
+	parsedInput ast.Value,
+	query string,
+) ([]result.Result, error) {
+	tracer := newExplainTracer(query)
+	results, err := engine.executeOneQuerySlow(ctx, compiledPackage, parsedInput, query, rego.EvalQueryTracer(tracer))
+	if err != nil {
+		return nil, err
+	}
+
+	attachExplanations(results, tracer.explanations)
+	return results, nil
+}
+
 func (engine *RegoEngine) executeOneQuerySlow(
 	ctx context.Context,
+	compiledPackage compiledPackage,
 	parsedInput ast.Value,
 	query string,
+	extraEvalOpts ...rego.EvalOption,
 ) ([]result.Result, error) {
-	regoInstance := engine.createRegoInstance(parsedInput, query)
-	resultSet, err := regoInstance.Eval(ctx)
+	preparedQuery, err := compiledPackage.preparedQueries.get(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query: %w", err)
+	}
+	evalOpts := []rego.EvalOption{rego.EvalParsedInput(parsedInput)}
+	if engine.coverageTracer != nil {
+		evalOpts = append(evalOpts, rego.EvalQueryTracer(engine.coverageTracer))
+	}
+	evalOpts = append(evalOpts, extraEvalOpts...)
+	resultSet, err := preparedQuery.Eval(ctx, evalOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_resolveResultSeverity(t *testing.T) {
+	rule := policy.Rule{
+		Annotations: &policy.Annotations{
+			Custom: map[string]interface{}{"severity": "high"},
+		},
+	}
+
+	assert.Equal(t, policy.SeverityHigh, resolveResultSeverity(rule, result.Result{}))
+	assert.Equal(
+		t,
+		policy.SeverityLow,
+		resolveResultSeverity(rule, result.Result{Metadata: map[string]interface{}{"severity": "low"}}),
+		"result metadata should take precedence",
+	)
+	assert.Equal(
+		t,
+		policy.SeverityHigh,
+		resolveResultSeverity(rule, result.Result{Metadata: map[string]interface{}{"severity": "urgent"}}),
+		"should fallback to the rule severity for invalid value",
+	)
+	assert.Equal(t, policy.SeverityUnspecified, resolveResultSeverity(policy.Rule{}, result.Result{}))
+}
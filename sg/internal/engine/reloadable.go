@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+	"github.com/Azure/ShieldGuard/sg/internal/source"
+	"github.com/Azure/ShieldGuard/sg/internal/watch"
+)
+
+// ReloadStatus describes the policies served by a ReloadableQueryer.
+type ReloadStatus struct {
+	// CompilerKey identifies the policies (and data) of the active queryer.
+	CompilerKey string
+	// LoadedAt is the time the active queryer was loaded.
+	LoadedAt time.Time
+	// LastReloadError is the error of the last reload. Nil if the last reload succeeded.
+	// When the reload fails, the previous queryer stays active.
+	LastReloadError error
+	// LastReloadAt is the time of the last reload attempt. Zero value means it has not been reloaded.
+	LastReloadAt time.Time
+}
+
+// activeQueryer is the queryer being served by a ReloadableQueryer.
+type activeQueryer struct {
+	queryer     Queryer
+	compilerKey string
+	loadedAt    time.Time
+}
+
+// ReloadableQueryer is a Queryer recompiling the policies when the policy files change.
+// The active queryer is swapped atomically only when the compilation succeeds,
+// so a failed reload keeps serving the previous policies.
+type ReloadableQueryer struct {
+	newBuilder func() *QueryerBuilder
+	watchPaths []string
+	now        func() time.Time
+
+	active atomic.Pointer[activeQueryer]
+
+	// mu serializes the reloads and guards the reload status.
+	mu              sync.Mutex
+	lastReloadError error
+	lastReloadAt    time.Time
+}
+
+var _ Queryer = (*ReloadableQueryer)(nil)
+
+// NewReloadableQueryer creates a ReloadableQueryer. The builder is created by newBuilder for each (re)load,
+// and the watchPaths (e.g. the policy package directories and the data files) are watched by Watch.
+// The initial load must succeed.
+func NewReloadableQueryer(newBuilder func() *QueryerBuilder, watchPaths []string) (*ReloadableQueryer, error) {
+	q := &ReloadableQueryer{
+		newBuilder: newBuilder,
+		watchPaths: watchPaths,
+		now:        time.Now,
+	}
+
+	active, err := q.load()
+	if err != nil {
+		return nil, err
+	}
+	q.active.Store(active)
+
+	return q, nil
+}
+
+func (q *ReloadableQueryer) load() (*activeQueryer, error) {
+	queryer, err := q.newBuilder().Complete()
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &activeQueryer{
+		queryer:  queryer,
+		loadedAt: q.now(),
+	}
+	if k, ok := queryer.(interface{ CompilerKey() string }); ok {
+		rv.compilerKey = k.CompilerKey()
+	}
+	return rv, nil
+}
+
+func (q *ReloadableQueryer) Query(
+	ctx context.Context,
+	source source.Source,
+	opts ...*QueryOptions,
+) (result.QueryResults, error) {
+	// NOTE: in-flight queries keep using the queryer loaded when they started
+	return q.active.Load().queryer.Query(ctx, source, opts...)
+}
+
+// Reload recompiles the policies. The active queryer is replaced only when the compilation succeeds.
+func (q *ReloadableQueryer) Reload() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.lastReloadAt = q.now()
+	active, err := q.load()
+	if err != nil {
+		q.lastReloadError = err
+		return err
+	}
+	q.lastReloadError = nil
+	q.active.Store(active)
+
+	return nil
+}
+
+// Status returns the status of the active policies and the last reload.
+func (q *ReloadableQueryer) Status() ReloadStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	active := q.active.Load()
+	return ReloadStatus{
+		CompilerKey:     active.compilerKey,
+		LoadedAt:        active.loadedAt,
+		LastReloadError: q.lastReloadError,
+		LastReloadAt:    q.lastReloadAt,
+	}
+}
+
+// Watch reloads the policies when the watched paths change, until the context is cancelled.
+// onReload is called with the status after each reload, it can be nil.
+func (q *ReloadableQueryer) Watch(ctx context.Context, onReload func(ReloadStatus)) error {
+	w, err := watch.New(q.watchPaths, watch.DefaultDebounce)
+	if err != nil {
+		return fmt.Errorf("watch policies: %w", err)
+	}
+
+	return w.Run(ctx, func(_ []string) {
+		// NOTE: the error is reported in the status
+		_ = q.Reload()
+		if onReload != nil {
+			onReload(q.Status())
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/ShieldGuard/sg/internal/source"
+)
+
+const (
+	testReloadPolicyFoo = `package main
+
+deny_foo[msg] {
+	input.name == "foo"
+	msg := "name cannot be foo"
+}
+`
+	testReloadPolicyBar = `package main
+
+deny_bar[msg] {
+	input.name == "foo"
+	msg := "name cannot be bar"
+}
+`
+	testReloadPolicyInvalid = `package main
+
+deny_foo[msg] {
+	input.name ==
+}
+`
+)
+
+func testReloadablePolicyDir(t *testing.T) string {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "policy.rego"), []byte(testReloadPolicyFoo), 0o644))
+	return dir
+}
+
+func queryReloadableFailures(t *testing.T, q Queryer) []string {
+	s, err := source.FromConfigurations("test.yaml", map[string]any{"name": "foo"})
+	assert.NoError(t, err)
+
+	queryResults, err := q.Query(context.Background(), s)
+	assert.NoError(t, err)
+
+	var rv []string
+	for _, r := range queryResults.Failures {
+		rv = append(rv, r.Rule.Query())
+	}
+	return rv
+}
+
+func Test_ReloadableQueryer_Reload(t *testing.T) {
+	dir := testReloadablePolicyDir(t)
+	newBuilder := func() *QueryerBuilder {
+		return QueryWithPolicy([]string{dir})
+	}
+
+	q, err := NewReloadableQueryer(newBuilder, []string{dir})
+	assert.NoError(t, err)
+	initialStatus := q.Status()
+	assert.NotEmpty(t, initialStatus.CompilerKey)
+	assert.NoError(t, initialStatus.LastReloadError)
+	assert.True(t, initialStatus.LastReloadAt.IsZero())
+	assert.Equal(t, []string{"deny_foo"}, queryReloadableFailures(t, q))
+
+	t.Run("reload succeeded", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "policy.rego"), []byte(testReloadPolicyBar), 0o644))
+		assert.NoError(t, q.Reload())
+
+		status := q.Status()
+		assert.NotEqual(t, initialStatus.CompilerKey, status.CompilerKey)
+		assert.NoError(t, status.LastReloadError)
+		assert.False(t, status.LastReloadAt.IsZero())
+		assert.Equal(t, []string{"deny_bar"}, queryReloadableFailures(t, q))
+	})
+
+	t.Run("reload failed", func(t *testing.T) {
+		activeCompilerKey := q.Status().CompilerKey
+
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "policy.rego"), []byte(testReloadPolicyInvalid), 0o644))
+		assert.Error(t, q.Reload())
+
+		status := q.Status()
+		assert.Equal(t, activeCompilerKey, status.CompilerKey, "should keep the previous policies")
+		assert.Error(t, status.LastReloadError)
+		assert.Equal(t, []string{"deny_bar"}, queryReloadableFailures(t, q), "should keep serving the previous policies")
+	})
+
+	t.Run("recovered", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "policy.rego"), []byte(testReloadPolicyFoo), 0o644))
+		assert.NoError(t, q.Reload())
+
+		status := q.Status()
+		assert.Equal(t, initialStatus.CompilerKey, status.CompilerKey)
+		assert.NoError(t, status.LastReloadError)
+		assert.Equal(t, []string{"deny_foo"}, queryReloadableFailures(t, q))
+	})
+}
+
+func Test_ReloadableQueryer_initialLoadFailed(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "policy.rego"), []byte(testReloadPolicyInvalid), 0o644))
+
+	_, err := NewReloadableQueryer(func() *QueryerBuilder {
+		return QueryWithPolicy([]string{dir})
+	}, []string{dir})
+	assert.Error(t, err)
+}
+
+func Test_ReloadableQueryer_Watch(t *testing.T) {
+	dir := testReloadablePolicyDir(t)
+	q, err := NewReloadableQueryer(func() *QueryerBuilder {
+		return QueryWithPolicy([]string{dir})
+	}, []string{dir})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan ReloadStatus, 10)
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- q.Watch(ctx, func(status ReloadStatus) { reloaded <- status })
+	}()
+
+	// NOTE: the watcher might not be ready yet, retry writing the file until reloaded
+	deadline := time.After(10 * time.Second)
+	for {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "policy.rego"), []byte(testReloadPolicyBar), 0o644))
+		select {
+		case status := <-reloaded:
+			assert.NoError(t, status.LastReloadError)
+			assert.Equal(t, []string{"deny_bar"}, queryReloadableFailures(t, q))
+			cancel()
+			assert.NoError(t, <-watchErr)
+			return
+		case <-time.After(time.Second):
+		case <-deadline:
+			t.Fatal("timed out waiting for reload")
+		}
+	}
+}
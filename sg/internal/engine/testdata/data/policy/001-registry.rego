@@ -0,0 +1,11 @@
+package main
+
+deny_unapproved_registry[msg] {
+	not approved(input.registry)
+
+	msg = sprintf("registry %s is not approved", [input.registry])
+}
+
+approved(registry) {
+	data.approved_registries[_] == registry
+}
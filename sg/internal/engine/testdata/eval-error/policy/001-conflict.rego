@@ -0,0 +1,17 @@
+package main
+
+owner := input.metadata.labels.owner
+
+owner := "unknown" {
+	input.kind == "Pod"
+}
+
+deny_missing_owner[msg] {
+	owner == "unknown"
+	msg := "owner label is required"
+}
+
+deny_foo[msg] {
+	input.metadata.name == "foo"
+	msg := "name cannot be foo"
+}
@@ -0,0 +1,5 @@
+package main
+
+deny_unapproved_registry_a[msg] {
+	msg := "conflicts with package-a"
+}
@@ -0,0 +1,9 @@
+package main
+
+# NOTE: package-b defines a different value for the same helper rule
+allowed_registry := "registry-a.example.com"
+
+deny_unapproved_registry_a[msg] {
+	not startswith(input.image, allowed_registry)
+	msg := sprintf("image %s is not from %s", [input.image, allowed_registry])
+}
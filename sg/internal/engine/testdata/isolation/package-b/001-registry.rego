@@ -0,0 +1,8 @@
+package main
+
+allowed_registry := "registry-b.example.com"
+
+deny_unapproved_registry_b[msg] {
+	not startswith(input.image, allowed_registry)
+	msg := sprintf("image %s is not from %s", [input.image, allowed_registry])
+}
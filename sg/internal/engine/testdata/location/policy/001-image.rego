@@ -0,0 +1,9 @@
+package main
+
+deny_latest_image[{"msg": msg, "path": path}] {
+	container := input.spec.containers[i]
+	endswith(container.image, ":latest")
+
+	msg := sprintf("container %s uses latest image", [container.name])
+	path := sprintf("spec.containers[%d].image", [i])
+}
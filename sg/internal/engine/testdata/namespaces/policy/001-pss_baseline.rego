@@ -0,0 +1,16 @@
+package kubernetes.pss.baseline
+
+deny_host_network[msg] {
+	input.spec.hostNetwork
+	msg := "host network is not allowed"
+}
+
+deny_privileged[msg] {
+	input.spec.containers[_].securityContext.privileged
+	msg := "privileged container is not allowed"
+}
+
+exception[rules] {
+	input.metadata.labels.privileged == "approved"
+	rules := ["privileged"]
+}
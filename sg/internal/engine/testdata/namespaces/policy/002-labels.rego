@@ -0,0 +1,11 @@
+package kubernetes.labels
+
+warn_missing_owner[msg] {
+	not input.metadata.labels.owner
+	msg := "owner label is recommended"
+}
+
+deny_foo_name[msg] {
+	input.metadata.name == "foo"
+	msg := "name cannot be foo"
+}
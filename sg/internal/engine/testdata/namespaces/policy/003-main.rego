@@ -0,0 +1,11 @@
+package main
+
+# NOTE: main namespace is not declared in the package spec, so these rules should be skipped
+
+deny_always[msg] {
+	msg := "should not be queried"
+}
+
+exception[rules] {
+	rules := ["host_network"]
+}
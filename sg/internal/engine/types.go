@@ -10,6 +10,10 @@ import (
 
 // QueryOptions controls the query behavior.
 type QueryOptions struct {
+	// ExplainRules specifies the rules to capture the evaluation trace for.
+	// A rule can be referenced by the query name (e.g. `deny_foo`) or the rule name (e.g. `foo`).
+	// The explanation is attached to the failure and warning results of the rules.
+	ExplainRules []string
 }
 
 // Queryer performs queries against a target.
@@ -32,7 +36,7 @@ type limiter interface {
 // queryCacheKey is the key for the query cache.
 type queryCacheKey struct {
 	// compilerKey represents the configuration combinations of the compiler.
-	// It should include the policy packages and the compiler options.
+	// It should include the policy packages, the compiler options and the extra data documents.
 	compilerKey string
 	// parsedInput is the parsed input in ast.Value representation.
 	// The hash of the parsed input is used to identify the input.
@@ -0,0 +1,417 @@
+package gitdiff
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ChangedFiles returns the files under the paths changed since the revision in the git repository
+// containing the dir, as absolute paths. The changes include:
+//
+//   - files changed by the commits since the merge base of the revision and HEAD,
+//     which is the same as `git diff <rev>...HEAD`;
+//   - staged, unstaged and untracked (not ignored) files in the worktree.
+//
+// Only the files under the given paths are checked in the index and the worktree, so the rest
+// of the worktree is never scanned. Paths outside the repository are ignored.
+// Both the old and new paths of renamed files are returned. Removed files are returned as well.
+// The repository is read locally, remotes are never fetched.
+func ChangedFiles(dir string, rev string, paths []string) ([]string, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("open git repository from %q: %w", dir, err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("open worktree: %w", err)
+	}
+	root, err := filepath.Abs(worktree.Filesystem.Root())
+	if err != nil {
+		return nil, fmt.Errorf("resolve worktree root: %w", err)
+	}
+
+	filter, err := newPathFilter(root, paths)
+	if err != nil {
+		return nil, err
+	}
+	if len(filter) < 1 {
+		return nil, nil
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("resolve HEAD: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("read commit of HEAD: %w", err)
+	}
+	headTree, err := headCommit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("read tree of HEAD: %w", err)
+	}
+
+	changed := map[string]struct{}{}
+	add := func(name string) {
+		if name == "" || !filter.matches(name) {
+			return
+		}
+		changed[filepath.Join(root, filepath.FromSlash(name))] = struct{}{}
+	}
+
+	committedChanges, err := diffSinceMergeBase(repo, rev, headCommit, headTree)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range committedChanges {
+		add(change.From.Name)
+		add(change.To.Name)
+	}
+
+	indexEntries, err := readIndexEntries(repo, filter)
+	if err != nil {
+		return nil, err
+	}
+	headFiles, err := readTreeFiles(headTree, filter)
+	if err != nil {
+		return nil, err
+	}
+	for name, entry := range indexEntries {
+		if headFile, exists := headFiles[name]; !exists || headFile.Hash != entry.Hash || headFile.Mode != entry.Mode {
+			// staged
+			add(name)
+			continue
+		}
+		modified, err := worktreeFileModified(root, entry)
+		if err != nil {
+			return nil, err
+		}
+		if modified {
+			add(name)
+		}
+	}
+	for name := range headFiles {
+		if _, exists := indexEntries[name]; !exists {
+			// staged removal
+			add(name)
+		}
+	}
+
+	untrackedFiles, err := listUntrackedFiles(worktree.Filesystem, root, filter, indexEntries)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range untrackedFiles {
+		add(name)
+	}
+
+	rv := make([]string, 0, len(changed))
+	for p := range changed {
+		rv = append(rv, p)
+	}
+	sort.Strings(rv)
+	return rv, nil
+}
+
+// pathFilter is the list of slash separated paths relative to the worktree root.
+// An empty path is the worktree root.
+type pathFilter []string
+
+func newPathFilter(root string, paths []string) (pathFilter, error) {
+	var rv pathFilter
+	for _, p := range paths {
+		p, err := filepath.Abs(p)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %q: %w", p, err)
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			// not in the repository
+			continue
+		}
+		if rel == "." {
+			rel = ""
+		}
+		rv = append(rv, filepath.ToSlash(rel))
+	}
+	return rv, nil
+}
+
+// matches tells if the name is the same as or under any path of the filter.
+func (f pathFilter) matches(name string) bool {
+	for _, p := range f {
+		if p == "" || name == p || strings.HasPrefix(name, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// diffSinceMergeBase returns the changes between the merge base of the revision and HEAD, and HEAD.
+func diffSinceMergeBase(
+	repo *git.Repository,
+	rev string,
+	headCommit *object.Commit,
+	headTree *object.Tree,
+) (object.Changes, error) {
+	revHash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("resolve revision %q: %w", rev, err)
+	}
+	revCommit, err := repo.CommitObject(*revHash)
+	if err != nil {
+		return nil, fmt.Errorf("read commit of revision %q: %w", rev, err)
+	}
+
+	mergeBases, err := revCommit.MergeBase(headCommit)
+	if err != nil {
+		return nil, fmt.Errorf("find merge base of %q and HEAD: %w", rev, err)
+	}
+	if len(mergeBases) < 1 {
+		return nil, fmt.Errorf("no merge base of %q and HEAD", rev)
+	}
+
+	baseTree, err := mergeBases[0].Tree()
+	if err != nil {
+		return nil, fmt.Errorf("read tree of merge base: %w", err)
+	}
+
+	// NOTE: unchanged subtrees are skipped by hash, so only the changed parts of the trees are read
+	changes, err := object.DiffTree(baseTree, headTree)
+	if err != nil {
+		return nil, fmt.Errorf("diff %q...HEAD: %w", rev, err)
+	}
+	return changes, nil
+}
+
+// readIndexEntries returns the index entries under the paths of the filter by name.
+func readIndexEntries(repo *git.Repository, filter pathFilter) (map[string]*index.Entry, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+
+	rv := map[string]*index.Entry{}
+	for _, entry := range idx.Entries {
+		if filter.matches(entry.Name) {
+			rv[entry.Name] = entry
+		}
+	}
+	return rv, nil
+}
+
+// readTreeFiles returns the file entries of the tree under the paths of the filter by name.
+// Only the subtrees of the paths are read.
+func readTreeFiles(tree *object.Tree, filter pathFilter) (map[string]object.TreeEntry, error) {
+	rv := map[string]object.TreeEntry{}
+	for _, p := range filter {
+		subtree := tree
+		if p != "" {
+			entry, err := tree.FindEntry(p)
+			if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("read %q from HEAD: %w", p, err)
+			}
+			if entry.Mode != filemode.Dir {
+				rv[p] = *entry
+				continue
+			}
+			if subtree, err = tree.Tree(p); err != nil {
+				return nil, fmt.Errorf("read %q from HEAD: %w", p, err)
+			}
+		}
+
+		walker := object.NewTreeWalker(subtree, true, nil)
+		for {
+			name, entry, err := walker.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				walker.Close()
+				return nil, fmt.Errorf("read %q from HEAD: %w", p, err)
+			}
+			if entry.Mode == filemode.Dir || entry.Mode == filemode.Submodule {
+				continue
+			}
+			if p != "" {
+				name = p + "/" + name
+			}
+			rv[name] = entry
+		}
+		walker.Close()
+	}
+	return rv, nil
+}
+
+// worktreeFileModified tells if the file of the index entry is modified in the worktree.
+func worktreeFileModified(root string, entry *index.Entry) (bool, error) {
+	if entry.Mode == filemode.Submodule {
+		// NOTE: submodules are repositories on their own, which are not checked
+		return false, nil
+	}
+
+	p := filepath.Join(root, filepath.FromSlash(entry.Name))
+	fileInfo, err := os.Lstat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stat %q: %w", p, err)
+	}
+
+	var content []byte
+	switch {
+	case fileInfo.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(p)
+		if err != nil {
+			return false, fmt.Errorf("read link %q: %w", p, err)
+		}
+		content = []byte(filepath.ToSlash(target))
+	case fileInfo.Mode().IsRegular():
+		if uint32(fileInfo.Size()) != entry.Size {
+			return true, nil
+		}
+		if content, err = os.ReadFile(p); err != nil {
+			return false, fmt.Errorf("read %q: %w", p, err)
+		}
+	default:
+		// replaced by a directory or a special file
+		return true, nil
+	}
+
+	return plumbing.ComputeHash(plumbing.BlobObject, content) != entry.Hash, nil
+}
+
+// listUntrackedFiles returns the names of the untracked (not ignored) files under the paths of the filter.
+func listUntrackedFiles(
+	worktreeFS billy.Filesystem,
+	root string,
+	filter pathFilter,
+	indexEntries map[string]*index.Entry,
+) ([]string, error) {
+	var rv []string
+	for _, p := range filter {
+		patterns, err := readIgnorePatterns(worktreeFS, p)
+		if err != nil {
+			return nil, err
+		}
+		matcher := gitignore.NewMatcher(patterns)
+
+		err = filepath.WalkDir(filepath.Join(root, filepath.FromSlash(p)), func(fullPath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+
+			rel, err := filepath.Rel(root, fullPath)
+			if err != nil {
+				return err
+			}
+			if rel == "." {
+				return nil
+			}
+			name := filepath.ToSlash(rel)
+
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if matcher.Match(strings.Split(name, "/"), d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if _, tracked := indexEntries[name]; !tracked {
+				rv = append(rv, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("list untracked files under %q: %w", p, err)
+		}
+	}
+	return rv, nil
+}
+
+// readIgnorePatterns reads the ignore patterns applying to the files under the path, which are
+// from `.git/info/exclude`, the `.gitignore` files of the parent directories of the path,
+// and the `.gitignore` files under the path.
+func readIgnorePatterns(worktreeFS billy.Filesystem, p string) ([]gitignore.Pattern, error) {
+	var pathParts []string
+	if p != "" {
+		pathParts = strings.Split(p, "/")
+	}
+
+	patterns, err := readIgnoreFile(worktreeFS, nil, ".git/info/exclude")
+	if err != nil {
+		return nil, err
+	}
+	for idx := range pathParts {
+		dirPatterns, err := readIgnoreFile(worktreeFS, pathParts[:idx], ".gitignore")
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, dirPatterns...)
+	}
+
+	fileInfo, err := worktreeFS.Lstat(worktreeFS.Join(pathParts...))
+	if err != nil || !fileInfo.IsDir() {
+		return patterns, nil
+	}
+	subPatterns, err := gitignore.ReadPatterns(worktreeFS, pathParts)
+	if err != nil {
+		return nil, fmt.Errorf("read ignore patterns under %q: %w", p, err)
+	}
+	return append(patterns, subPatterns...), nil
+}
+
+// readIgnoreFile reads the ignore patterns from the file in the directory.
+// Missing files have no patterns.
+func readIgnoreFile(worktreeFS billy.Filesystem, dirParts []string, name string) ([]gitignore.Pattern, error) {
+	p := worktreeFS.Join(append(append([]string{}, dirParts...), name)...)
+	f, err := worktreeFS.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %w", p, err)
+	}
+	defer f.Close()
+
+	var rv []gitignore.Pattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		rv = append(rv, gitignore.ParsePattern(line, dirParts))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %q: %w", p, err)
+	}
+	return rv, nil
+}
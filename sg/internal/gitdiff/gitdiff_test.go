@@ -0,0 +1,176 @@
+package gitdiff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRepo struct {
+	t        *testing.T
+	dir      string
+	repo     *git.Repository
+	worktree *git.Worktree
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+
+	return &testRepo{t: t, dir: dir, repo: repo, worktree: worktree}
+}
+
+func (r *testRepo) writeFile(name string, content string) {
+	p := r.path(name)
+	assert.NoError(r.t, os.MkdirAll(filepath.Dir(p), 0755))
+	assert.NoError(r.t, os.WriteFile(p, []byte(content), 0644))
+}
+
+func (r *testRepo) commit(msg string) plumbing.Hash {
+	assert.NoError(r.t, r.worktree.AddWithOptions(&git.AddOptions{All: true}))
+	hash, err := r.worktree.Commit(msg, &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	assert.NoError(r.t, err)
+	return hash
+}
+
+func (r *testRepo) path(name string) string {
+	return filepath.Join(r.dir, filepath.FromSlash(name))
+}
+
+func Test_ChangedFiles(t *testing.T) {
+	r := newTestRepo(t)
+	r.writeFile("manifests/a.yaml", "name: a\n")
+	r.writeFile("manifests/b.yaml", "name: b\n")
+	r.writeFile("manifests/d.yaml", "name: d\n")
+	r.writeFile("manifests/e.yaml", "name: e\n")
+	r.writeFile("policy/main.rego", "package main\n")
+	r.writeFile("other/a.yaml", "name: a\n")
+	r.writeFile(".gitignore", "*.log\n")
+	r.writeFile("manifests/.gitignore", "tmp/\n")
+	base := r.commit("initial")
+
+	r.writeFile("manifests/a.yaml", "name: a2\n")
+	r.commit("update a")
+
+	r.writeFile("manifests/c.yaml", "name: c\n")
+	assert.NoError(t, os.Remove(r.path("manifests/b.yaml")))
+	r.writeFile("manifests/debug.log", "ignored")
+	r.writeFile("manifests/tmp/f.yaml", "name: f\n")
+	r.writeFile("manifests/d.yaml", "name: d2\n")
+	_, err := r.worktree.Add("manifests/d.yaml")
+	assert.NoError(t, err)
+	_, err = r.worktree.Remove("manifests/e.yaml")
+	assert.NoError(t, err)
+	r.writeFile("policy/main.rego", "package main\n\n# changed\n")
+	r.writeFile("other/a.yaml", "name: a2\n")
+	r.writeFile("other/b.yaml", "name: b\n")
+
+	changed, err := ChangedFiles(r.path("manifests"), base.String(), []string{r.path("manifests")})
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]string{
+			r.path("manifests/a.yaml"),
+			r.path("manifests/b.yaml"),
+			r.path("manifests/c.yaml"),
+			r.path("manifests/d.yaml"),
+			r.path("manifests/e.yaml"),
+		},
+		changed,
+		"should include changes under the paths only",
+	)
+
+	changed, err = ChangedFiles(r.dir, "HEAD", []string{r.path("manifests"), r.path("policy/main.rego")})
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]string{
+			r.path("manifests/b.yaml"),
+			r.path("manifests/c.yaml"),
+			r.path("manifests/d.yaml"),
+			r.path("manifests/e.yaml"),
+			r.path("policy/main.rego"),
+		},
+		changed,
+		"should include worktree changes only",
+	)
+
+	changed, err = ChangedFiles(r.dir, "HEAD", []string{r.dir})
+	assert.NoError(t, err)
+	assert.Contains(t, changed, r.path("other/a.yaml"), "should check the whole worktree for the root")
+	assert.Contains(t, changed, r.path("other/b.yaml"), "should check the whole worktree for the root")
+
+	changed, err = ChangedFiles(r.dir, "HEAD", []string{filepath.Join(t.TempDir(), "manifests")})
+	assert.NoError(t, err)
+	assert.Empty(t, changed, "paths outside the repository should be ignored")
+}
+
+func Test_ChangedFiles_renamed(t *testing.T) {
+	r := newTestRepo(t)
+	r.writeFile("manifests/a.yaml", "name: a\n")
+	base := r.commit("initial")
+
+	_, err := r.worktree.Move("manifests/a.yaml", "manifests/b.yaml")
+	assert.NoError(t, err)
+	r.commit("rename")
+
+	changed, err := ChangedFiles(r.dir, base.String(), []string{r.path("manifests")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{r.path("manifests/a.yaml"), r.path("manifests/b.yaml")}, changed)
+}
+
+func Test_ChangedFiles_mergeBase(t *testing.T) {
+	r := newTestRepo(t)
+	r.writeFile("manifests/a.yaml", "name: a\n")
+	base := r.commit("initial")
+
+	// feature branch
+	assert.NoError(t, r.worktree.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName("feature"),
+		Create: true,
+	}))
+	r.writeFile("manifests/feature.yaml", "name: feature\n")
+	r.commit("add feature")
+
+	// main moves on
+	assert.NoError(t, r.worktree.Checkout(&git.CheckoutOptions{Hash: base, Force: true}))
+	r.writeFile("manifests/main.yaml", "name: main\n")
+	mainHash := r.commit("add main")
+	assert.NoError(t, r.repo.Storer.SetReference(plumbing.NewHashReference("refs/heads/main", mainHash)))
+
+	assert.NoError(t, r.worktree.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName("feature"),
+		Force:  true,
+	}))
+
+	changed, err := ChangedFiles(r.dir, "main", []string{r.path("manifests")})
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		[]string{r.path("manifests/feature.yaml")},
+		changed,
+		"should not include changes on the revision since the merge base",
+	)
+}
+
+func Test_ChangedFiles_errors(t *testing.T) {
+	_, err := ChangedFiles(t.TempDir(), "HEAD", nil)
+	assert.Error(t, err, "not a git repository")
+
+	r := newTestRepo(t)
+	r.writeFile("a.yaml", "name: a\n")
+	r.commit("initial")
+	_, err = ChangedFiles(r.dir, "no-such-rev", []string{r.path("a.yaml")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,52 @@
+package policy
+
+import (
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// loadRuleAnnotations resolves the annotations of the rule from the annotation set.
+// It returns nil if the rule is not annotated.
+func loadRuleAnnotations(annotationSet *ast.AnnotationSet, rule *ast.Rule) *Annotations {
+	if annotationSet == nil {
+		return nil
+	}
+
+	// NOTE: the chain is ordered from the most specific scope (rule) to the least specific one (subpackages)
+	chain := annotationSet.Chain(rule)
+
+	var rv *Annotations
+	for _, ref := range chain {
+		a := ref.Annotations
+		if a == nil {
+			continue
+		}
+		if rv == nil {
+			rv = &Annotations{}
+		}
+
+		if rv.Title == "" {
+			rv.Title = a.Title
+		}
+		if rv.Description == "" {
+			rv.Description = a.Description
+		}
+		if len(rv.RelatedResources) == 0 {
+			for _, r := range a.RelatedResources {
+				rv.RelatedResources = append(rv.RelatedResources, RelatedResource{
+					Ref:         r.Ref.String(),
+					Description: r.Description,
+				})
+			}
+		}
+		for k, v := range a.Custom {
+			if rv.Custom == nil {
+				rv.Custom = map[string]interface{}{}
+			}
+			if _, exists := rv.Custom[k]; !exists {
+				rv.Custom[k] = v
+			}
+		}
+	}
+
+	return rv
+}
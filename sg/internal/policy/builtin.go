@@ -0,0 +1,149 @@
+package policy
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/loader"
+)
+
+//go:embed all:builtin
+var builtinFS embed.FS
+
+const (
+	builtinPackageQualifiedIDPrefix = "builtin:"
+
+	// builtinPackagesRoot is the root directory of the built-in packages in builtinFS.
+	builtinPackagesRoot = "builtin"
+
+	// builtinLibDirName is the name of the directory with the modules shared by the built-in packages.
+	// A lib directory is loaded into all built-in packages under its parent directory.
+	// For example: builtin/pss/lib is loaded into both builtin:pss/baseline and builtin:pss/restricted.
+	builtinLibDirName = "lib"
+)
+
+// BuiltinPackage is a policy package embedded in the binary.
+type BuiltinPackage struct {
+	qualifiedID   string
+	packageSpec   PackageSpec
+	rules         []Rule
+	parsedModules map[string]*ast.Module
+}
+
+// IsBuiltinPackageRef checks if the given policy path refers to a built-in package.
+func IsBuiltinPackageRef(path string) bool {
+	return strings.HasPrefix(path, builtinPackageQualifiedIDPrefix)
+}
+
+// BuiltinPackageRefs lists the references of all available built-in packages.
+// For example: "builtin:pss/baseline".
+func BuiltinPackageRefs() []string {
+	var rv []string
+	_ = fs.WalkDir(builtinFS, builtinPackagesRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != PackageSpecFileName {
+			return nil
+		}
+		name := strings.TrimPrefix(path.Dir(p), builtinPackagesRoot+"/")
+		rv = append(rv, builtinPackageQualifiedIDPrefix+name)
+		return nil
+	})
+	sort.Strings(rv)
+
+	return rv
+}
+
+func loadBuiltinPackage(ref string) (Package, error) {
+	name := strings.TrimPrefix(ref, builtinPackageQualifiedIDPrefix)
+	dir := path.Join(builtinPackagesRoot, name)
+	if name == "" || !fs.ValidPath(dir) {
+		return nil, fmt.Errorf("invalid built-in package: %s", ref)
+	}
+
+	rv := &BuiltinPackage{
+		qualifiedID: builtinPackageQualifiedIDPrefix + name,
+	}
+
+	// load package spec
+	// NOTE: unlike FSPackage, the package spec is required to tell a built-in package
+	//       from its sub directories.
+	{
+		b, err := fs.ReadFile(builtinFS, path.Join(dir, PackageSpecFileName))
+		if err != nil {
+			return nil, fmt.Errorf(
+				"unknown built-in package %q, available packages are: %s",
+				ref, strings.Join(BuiltinPackageRefs(), ", "),
+			)
+		}
+		packageSpec, err := parsePackageSpec(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load package spec: %w", err)
+		}
+		rv.packageSpec = packageSpec
+	}
+
+	// load rules
+	{
+		policies, err := loader.NewFileLoader().
+			WithFS(builtinFS).
+			WithProcessAnnotation(true).
+			Filtered(append([]string{dir}, builtinLibDirs(dir)...), func(_ string, info fs.FileInfo, _ int) bool {
+				return !info.IsDir() && !strings.HasSuffix(info.Name(), ".rego")
+			})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load policies: %w", err)
+		}
+		if len(policies.Modules) == 0 {
+			return nil, fmt.Errorf("no policies found from built-in package: %s", ref)
+		}
+
+		rv.parsedModules = policies.ParsedModules()
+		for _, module := range rv.parsedModules {
+			rules, err := loadRulesFromModule(module)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load rules from %s: %w", module.Package.Location.File, err)
+			}
+			rv.rules = append(rv.rules, rules...)
+		}
+	}
+
+	return rv, nil
+}
+
+// builtinLibDirs returns the shared lib directories of the built-in package dir,
+// which are the lib directories in the parent directories of the package.
+func builtinLibDirs(dir string) []string {
+	var rv []string
+	for p := path.Dir(dir); p != builtinPackagesRoot && p != "."; p = path.Dir(p) {
+		libDir := path.Join(p, builtinLibDirName)
+		if info, err := fs.Stat(builtinFS, libDir); err == nil && info.IsDir() {
+			rv = append(rv, libDir)
+		}
+	}
+	return rv
+}
+
+var _ Package = (*BuiltinPackage)(nil)
+
+func (p *BuiltinPackage) QualifiedID() string {
+	return p.qualifiedID
+}
+
+func (p *BuiltinPackage) Spec() PackageSpec {
+	return p.packageSpec
+}
+
+func (p *BuiltinPackage) Rules() []Rule {
+	return p.rules
+}
+
+func (p *BuiltinPackage) ParsedModules() map[string]*ast.Module {
+	return p.parsedModules
+}
@@ -0,0 +1,14 @@
+package main
+
+import data.pss.lib
+
+host_namespace_fields := {"hostNetwork", "hostPID", "hostIPC"}
+
+deny_host_namespaces[result] {
+	lib.pod_specs[[spec_path, spec]]
+	host_namespace_fields[field]
+	spec[field] == true
+
+	msg := sprintf("%s %s must not set %s to true", [input.kind, lib.name, field])
+	result := lib.result(msg, array.concat(spec_path, [field]))
+}
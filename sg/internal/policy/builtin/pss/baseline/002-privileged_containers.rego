@@ -0,0 +1,11 @@
+package main
+
+import data.pss.lib
+
+deny_privileged_containers[result] {
+	lib.containers[[path, container]]
+	container.securityContext.privileged == true
+
+	msg := sprintf("container %s of %s %s must not run in privileged mode", [container.name, input.kind, lib.name])
+	result := lib.result(msg, array.concat(path, ["securityContext", "privileged"]))
+}
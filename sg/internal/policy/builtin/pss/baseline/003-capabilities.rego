@@ -0,0 +1,28 @@
+package main
+
+import data.pss.lib
+
+baseline_allowed_capabilities := {
+	"AUDIT_WRITE",
+	"CHOWN",
+	"DAC_OVERRIDE",
+	"FOWNER",
+	"FSETID",
+	"KILL",
+	"MKNOD",
+	"NET_BIND_SERVICE",
+	"SETFCAP",
+	"SETGID",
+	"SETPCAP",
+	"SETUID",
+	"SYS_CHROOT",
+}
+
+deny_capabilities[result] {
+	lib.containers[[path, container]]
+	capability := container.securityContext.capabilities.add[i]
+	not baseline_allowed_capabilities[capability]
+
+	msg := sprintf("container %s of %s %s must not add capability %s", [container.name, input.kind, lib.name, capability])
+	result := lib.result(msg, array.concat(path, ["securityContext", "capabilities", "add", i]))
+}
@@ -0,0 +1,12 @@
+package main
+
+import data.pss.lib
+
+deny_host_path_volumes[result] {
+	lib.pod_specs[[spec_path, spec]]
+	volume := spec.volumes[i]
+	volume.hostPath
+
+	msg := sprintf("volume %s of %s %s must not use hostPath", [volume.name, input.kind, lib.name])
+	result := lib.result(msg, array.concat(spec_path, ["volumes", i, "hostPath"]))
+}
@@ -0,0 +1,12 @@
+package main
+
+import data.pss.lib
+
+deny_host_ports[result] {
+	lib.containers[[path, container]]
+	port := container.ports[i]
+	port.hostPort != 0
+
+	msg := sprintf("container %s of %s %s must not use host port %v", [container.name, input.kind, lib.name, port.hostPort])
+	result := lib.result(msg, array.concat(path, ["ports", i, "hostPort"]))
+}
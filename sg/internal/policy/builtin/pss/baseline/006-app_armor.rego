@@ -0,0 +1,34 @@
+package main
+
+import data.pss.lib
+
+app_armor_annotation_prefix := "container.apparmor.security.beta.kubernetes.io/"
+
+app_armor_allowed_profile_types := {"RuntimeDefault", "Localhost"}
+
+allowed_app_armor_annotation(value) {
+	value == "runtime/default"
+}
+
+allowed_app_armor_annotation(value) {
+	startswith(value, "localhost/")
+}
+
+deny_app_armor[result] {
+	lib.pod_metadatas[[metadata_path, metadata]]
+	value := metadata.annotations[key]
+	startswith(key, app_armor_annotation_prefix)
+	not allowed_app_armor_annotation(value)
+
+	msg := sprintf("%s %s must not override the default AppArmor profile with %s", [input.kind, lib.name, value])
+	result := lib.result(msg, array.concat(metadata_path, ["annotations", key]))
+}
+
+deny_app_armor[result] {
+	lib.security_contexts[[path, security_context]]
+	profile_type := security_context.appArmorProfile.type
+	not app_armor_allowed_profile_types[profile_type]
+
+	msg := sprintf("%s %s must not set AppArmor profile type to %s", [input.kind, lib.name, profile_type])
+	result := lib.result(msg, array.concat(path, ["appArmorProfile", "type"]))
+}
@@ -0,0 +1,32 @@
+package main
+
+import data.pss.lib
+
+selinux_allowed_types := {
+	"",
+	"container_t",
+	"container_init_t",
+	"container_kvm_t",
+	"container_engine_t",
+}
+
+deny_selinux[result] {
+	lib.security_contexts[[path, security_context]]
+	selinux_type := security_context.seLinuxOptions.type
+	not selinux_allowed_types[selinux_type]
+
+	msg := sprintf("%s %s must not set SELinux type to %s", [input.kind, lib.name, selinux_type])
+	result := lib.result(msg, array.concat(path, ["seLinuxOptions", "type"]))
+}
+
+selinux_forbidden_fields := {"user", "role"}
+
+deny_selinux[result] {
+	lib.security_contexts[[path, security_context]]
+	selinux_forbidden_fields[field]
+	value := security_context.seLinuxOptions[field]
+	value != ""
+
+	msg := sprintf("%s %s must not set custom SELinux %s", [input.kind, lib.name, field])
+	result := lib.result(msg, array.concat(path, ["seLinuxOptions", field]))
+}
@@ -0,0 +1,12 @@
+package main
+
+import data.pss.lib
+
+deny_proc_mount_type[result] {
+	lib.containers[[path, container]]
+	proc_mount := container.securityContext.procMount
+	proc_mount != "Default"
+
+	msg := sprintf("container %s of %s %s must not set procMount to %s", [container.name, input.kind, lib.name, proc_mount])
+	result := lib.result(msg, array.concat(path, ["securityContext", "procMount"]))
+}
@@ -0,0 +1,25 @@
+package main
+
+import data.pss.lib
+
+safe_sysctls := {
+	"kernel.shm_rmid_forced",
+	"net.ipv4.ip_local_port_range",
+	"net.ipv4.ip_unprivileged_port_start",
+	"net.ipv4.tcp_syncookies",
+	"net.ipv4.ping_group_range",
+	"net.ipv4.ip_local_reserved_ports",
+	"net.ipv4.tcp_keepalive_time",
+	"net.ipv4.tcp_fin_timeout",
+	"net.ipv4.tcp_keepalive_intvl",
+	"net.ipv4.tcp_keepalive_probes",
+}
+
+deny_sysctls[result] {
+	lib.pod_specs[[spec_path, spec]]
+	sysctl := spec.securityContext.sysctls[i]
+	not safe_sysctls[sysctl.name]
+
+	msg := sprintf("%s %s must not set unsafe sysctl %s", [input.kind, lib.name, sysctl.name])
+	result := lib.result(msg, array.concat(spec_path, ["securityContext", "sysctls", i, "name"]))
+}
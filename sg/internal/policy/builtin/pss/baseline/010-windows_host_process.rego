@@ -0,0 +1,11 @@
+package main
+
+import data.pss.lib
+
+deny_windows_host_process[result] {
+	lib.security_contexts[[path, security_context]]
+	security_context.windowsOptions.hostProcess == true
+
+	msg := sprintf("%s %s must not run Windows HostProcess containers", [input.kind, lib.name])
+	result := lib.result(msg, array.concat(path, ["windowsOptions", "hostProcess"]))
+}
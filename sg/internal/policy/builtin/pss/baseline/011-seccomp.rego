@@ -0,0 +1,11 @@
+package main
+
+import data.pss.lib
+
+deny_seccomp[result] {
+	lib.security_contexts[[path, security_context]]
+	security_context.seccompProfile.type == "Unconfined"
+
+	msg := sprintf("%s %s must not set seccomp profile to Unconfined", [input.kind, lib.name])
+	result := lib.result(msg, array.concat(path, ["seccompProfile", "type"]))
+}
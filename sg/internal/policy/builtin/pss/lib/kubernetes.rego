@@ -0,0 +1,74 @@
+package pss.lib
+
+# workload kinds with pod template under .spec.template
+pod_template_kinds := {
+	"DaemonSet",
+	"Deployment",
+	"Job",
+	"ReplicaSet",
+	"ReplicationController",
+	"StatefulSet",
+}
+
+# pod_specs contains the [path, pod spec] tuples of the input.
+pod_specs[[["spec"], spec]] {
+	input.kind == "Pod"
+	spec := input.spec
+}
+
+pod_specs[[["spec", "template", "spec"], spec]] {
+	pod_template_kinds[input.kind]
+	spec := input.spec.template.spec
+}
+
+pod_specs[[["spec", "jobTemplate", "spec", "template", "spec"], spec]] {
+	input.kind == "CronJob"
+	spec := input.spec.jobTemplate.spec.template.spec
+}
+
+# pod_metadatas contains the [path, pod metadata] tuples of the input.
+pod_metadatas[[["metadata"], metadata]] {
+	input.kind == "Pod"
+	metadata := input.metadata
+}
+
+pod_metadatas[[["spec", "template", "metadata"], metadata]] {
+	pod_template_kinds[input.kind]
+	metadata := input.spec.template.metadata
+}
+
+pod_metadatas[[["spec", "jobTemplate", "spec", "template", "metadata"], metadata]] {
+	input.kind == "CronJob"
+	metadata := input.spec.jobTemplate.spec.template.metadata
+}
+
+container_fields := {"containers", "initContainers", "ephemeralContainers"}
+
+# containers contains the [path, container] tuples of the input.
+containers[[path, container]] {
+	pod_specs[[spec_path, spec]]
+	container_fields[field]
+	container := spec[field][i]
+	path := array.concat(spec_path, [field, i])
+}
+
+# security_contexts contains the [path, security context] tuples of the pod and containers.
+security_contexts[[path, security_context]] {
+	pod_specs[[spec_path, spec]]
+	security_context := spec.securityContext
+	path := array.concat(spec_path, ["securityContext"])
+}
+
+security_contexts[[path, security_context]] {
+	containers[[container_path, container]]
+	security_context := container.securityContext
+	path := array.concat(container_path, ["securityContext"])
+}
+
+name := input.metadata.name
+
+name := "<unknown>" {
+	not input.metadata.name
+}
+
+result(msg, path) := {"msg": msg, "path": path}
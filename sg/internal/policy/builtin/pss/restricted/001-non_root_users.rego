@@ -0,0 +1,35 @@
+package main
+
+import data.pss.lib
+
+# container level settings take precedence over the pod level settings
+run_as_non_root(spec, container) {
+	container.securityContext.runAsNonRoot == true
+}
+
+run_as_non_root(spec, container) {
+	not has_container_run_as_non_root(container)
+	spec.securityContext.runAsNonRoot == true
+}
+
+has_container_run_as_non_root(container) {
+	_ = container.securityContext.runAsNonRoot
+}
+
+deny_non_root_users[result] {
+	lib.pod_specs[[spec_path, spec]]
+	lib.container_fields[field]
+	container := spec[field][i]
+	not run_as_non_root(spec, container)
+
+	msg := sprintf("container %s of %s %s must set runAsNonRoot to true", [container.name, input.kind, lib.name])
+	result := lib.result(msg, array.concat(spec_path, [field, i]))
+}
+
+deny_non_root_users[result] {
+	lib.security_contexts[[path, security_context]]
+	security_context.runAsUser == 0
+
+	msg := sprintf("%s %s must not set runAsUser to 0", [input.kind, lib.name])
+	result := lib.result(msg, array.concat(path, ["runAsUser"]))
+}
@@ -0,0 +1,26 @@
+package main
+
+import data.pss.lib
+
+restricted_allowed_capabilities := {"NET_BIND_SERVICE"}
+
+drops_all_capabilities(container) {
+	container.securityContext.capabilities.drop[_] == "ALL"
+}
+
+deny_min_capabilities[result] {
+	lib.containers[[path, container]]
+	not drops_all_capabilities(container)
+
+	msg := sprintf("container %s of %s %s must drop ALL capabilities", [container.name, input.kind, lib.name])
+	result := lib.result(msg, path)
+}
+
+deny_min_capabilities[result] {
+	lib.containers[[path, container]]
+	capability := container.securityContext.capabilities.add[i]
+	not restricted_allowed_capabilities[capability]
+
+	msg := sprintf("container %s of %s %s must not add capability %s", [container.name, input.kind, lib.name, capability])
+	result := lib.result(msg, array.concat(path, ["securityContext", "capabilities", "add", i]))
+}
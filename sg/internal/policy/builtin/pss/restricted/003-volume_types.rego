@@ -0,0 +1,25 @@
+package main
+
+import data.pss.lib
+
+restricted_allowed_volume_types := {
+	"configMap",
+	"csi",
+	"downwardAPI",
+	"emptyDir",
+	"ephemeral",
+	"persistentVolumeClaim",
+	"projected",
+	"secret",
+}
+
+deny_volume_types[result] {
+	lib.pod_specs[[spec_path, spec]]
+	volume := spec.volumes[i]
+	volume[volume_type]
+	volume_type != "name"
+	not restricted_allowed_volume_types[volume_type]
+
+	msg := sprintf("volume %s of %s %s must not use volume type %s", [volume.name, input.kind, lib.name, volume_type])
+	result := lib.result(msg, array.concat(spec_path, ["volumes", i, volume_type]))
+}
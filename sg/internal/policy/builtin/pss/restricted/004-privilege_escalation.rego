@@ -0,0 +1,11 @@
+package main
+
+import data.pss.lib
+
+deny_privilege_escalation[result] {
+	lib.containers[[path, container]]
+	not container.securityContext.allowPrivilegeEscalation == false
+
+	msg := sprintf("container %s of %s %s must set allowPrivilegeEscalation to false", [container.name, input.kind, lib.name])
+	result := lib.result(msg, path)
+}
@@ -0,0 +1,26 @@
+package main
+
+import data.pss.lib
+
+restricted_allowed_seccomp_profile_types := {"RuntimeDefault", "Localhost"}
+
+# container level settings take precedence over the pod level settings
+seccomp_profile_type(spec, container) := container.securityContext.seccompProfile.type
+
+seccomp_profile_type(spec, container) := spec.securityContext.seccompProfile.type {
+	not container.securityContext.seccompProfile.type
+}
+
+allowed_seccomp_profile(spec, container) {
+	restricted_allowed_seccomp_profile_types[seccomp_profile_type(spec, container)]
+}
+
+deny_seccomp_profile[result] {
+	lib.pod_specs[[spec_path, spec]]
+	lib.container_fields[field]
+	container := spec[field][i]
+	not allowed_seccomp_profile(spec, container)
+
+	msg := sprintf("container %s of %s %s must set seccomp profile to RuntimeDefault or Localhost", [container.name, input.kind, lib.name])
+	result := lib.result(msg, array.concat(spec_path, [field, i]))
+}
@@ -0,0 +1,46 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BuiltinPackageRefs(t *testing.T) {
+	assert.Equal(t, []string{"builtin:pss/baseline", "builtin:pss/restricted"}, BuiltinPackageRefs())
+}
+
+func Test_LoadPackagesFromPaths_builtin(t *testing.T) {
+	pkgs, err := LoadPackagesFromPaths(BuiltinPackageRefs())
+	assert.NoError(t, err)
+	assert.Len(t, pkgs, 2)
+
+	for _, pkg := range pkgs {
+		assert.NotEmpty(t, pkg.Rules())
+		assert.NotEmpty(t, pkg.ParsedModules())
+		assert.Contains(t, pkg.ParsedModules(), "builtin/pss/lib/kubernetes.rego", "shared lib should be loaded")
+
+		for _, rule := range pkg.Rules() {
+			assert.Equal(t, "main", rule.Namespace)
+			assert.Equal(t, QueryKindDeny, rule.Kind)
+
+			docLink, err := ResolveRuleDocLink(pkg.Spec(), rule)
+			assert.NoError(t, err)
+			assert.Regexp(
+				t,
+				`^https://github\.com/Azure/ShieldGuard/blob/main/policies/pod-security-standard/(baseline|restricted)/docs/\d{3}-`+rule.Name+`\.md$`,
+				docLink,
+			)
+		}
+	}
+
+	_, _, err = NewRegoCompiler(pkgs)
+	assert.NoError(t, err, "built-in packages should be compiled together")
+}
+
+func Test_LoadPackagesFromPaths_builtin_unknown(t *testing.T) {
+	for _, ref := range []string{"builtin:", "builtin:pss", "builtin:pss/unknown", "builtin:../pss/baseline"} {
+		_, err := LoadPackagesFromPaths([]string{ref})
+		assert.Error(t, err, ref)
+	}
+}
@@ -0,0 +1,88 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// CheckPackageConflicts checks the conflicts between the policy packages.
+// Packages are compiled in isolation and each result reports the package defining the rule,
+// so rules with the same name can be defined in different packages. Loading the same package
+// more than once is reported as a conflict.
+func CheckPackageConflicts(packages []Package) error {
+	packageIDs := make(map[string]struct{}, len(packages))
+	for _, p := range packages {
+		if _, exists := packageIDs[p.QualifiedID()]; exists {
+			return fmt.Errorf("policy package %s is loaded more than once", p.QualifiedID())
+		}
+		packageIDs[p.QualifiedID()] = struct{}{}
+	}
+
+	return nil
+}
+
+// CheckDataConflicts checks the conflicts between the data documents and the namespaces of the policy packages.
+// A data document mounted to (or above) a rego package path (e.g. `main` or `kubernetes` for
+// `package kubernetes.pss.baseline`) would shadow the rules, or be shadowed by the rules.
+func CheckDataConflicts(packages []Package, docs []DataDocument) error {
+	var conflicts []string
+	for _, doc := range docs {
+		for _, p := range packages {
+			for _, namespace := range packageNamespaces(p) {
+				if !isDataKeyPrefix(doc.Key, namespace) && !isDataKeyPrefix(namespace, doc.Key) {
+					continue
+				}
+				conflicts = append(conflicts, fmt.Sprintf(
+					"data document %q at key %q conflicts with namespace %q of package %s",
+					doc.Source, strings.Join(doc.Key, dataKeySeparator),
+					strings.Join(namespace, dataKeySeparator), p.QualifiedID(),
+				))
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return fmt.Errorf("conflicting data documents:\n%s", strings.Join(conflicts, "\n"))
+	}
+
+	return nil
+}
+
+// packageNamespaces returns the distinct rego package paths (without the `data.` prefix) of the package modules.
+func packageNamespaces(p Package) [][]string {
+	var rv [][]string
+	seen := map[string]struct{}{}
+	for _, module := range p.ParsedModules() {
+		var namespace []string
+		for _, term := range module.Package.Path[1:] {
+			s, ok := term.Value.(ast.String)
+			if !ok {
+				break
+			}
+			namespace = append(namespace, string(s))
+		}
+		key := strings.Join(namespace, dataKeySeparator)
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		rv = append(rv, namespace)
+	}
+	return rv
+}
+
+// isDataKeyPrefix tells if the prefix is the same as or a prefix of the key, by segments.
+func isDataKeyPrefix(prefix []string, key []string) bool {
+	if len(prefix) > len(key) {
+		return false
+	}
+	for idx := range prefix {
+		if prefix[idx] != key[idx] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/stretchr/testify/assert"
+)
+
+func testFSPackage(t *testing.T, qualifiedID string, modules map[string]string) *FSPackage {
+	t.Helper()
+
+	rv := &FSPackage{
+		qualifiedID:   qualifiedID,
+		packageSpec:   defaultPackageSpec(),
+		parsedModules: map[string]*ast.Module{},
+	}
+	for name, content := range modules {
+		module, err := ast.ParseModule(name, content)
+		assert.NoError(t, err)
+		rules, err := loadRulesFromModule(module)
+		assert.NoError(t, err)
+		rv.parsedModules[name] = module
+		rv.rules = append(rv.rules, rules...)
+	}
+	return rv
+}
+
+func Test_CheckPackageConflicts(t *testing.T) {
+	packageA := testFSPackage(t, "fs:a", map[string]string{
+		"a/001-foo.rego": "package main\n\ndeny_foo[msg] { msg := \"foo\" }\n",
+		"a/002-foo.rego": "package main\n\ndeny_foo[msg] { msg := \"another foo\" }\n",
+	})
+	packageB := testFSPackage(t, "fs:b", map[string]string{
+		"b/001-foo.rego": "package main\n\ndeny_foo[msg] { msg := \"foo\" }\n",
+	})
+	packageC := testFSPackage(t, "fs:c", map[string]string{
+		"c/001-foo.rego": "package kubernetes.c\n\ndeny_foo[msg] { msg := \"foo\" }\n",
+	})
+
+	assert.NoError(t, CheckPackageConflicts([]Package{packageA}), "rule defined more than once in a package")
+	assert.NoError(t, CheckPackageConflicts([]Package{packageA, packageC}), "same rule name in different namespaces")
+	assert.NoError(t, CheckPackageConflicts([]Package{packageA, packageB, packageC}), "same rule in different packages")
+
+	err := CheckPackageConflicts([]Package{packageA, packageC, packageA})
+	assert.ErrorContains(t, err, "policy package fs:a is loaded more than once")
+}
+
+func Test_NewRegoCompiler_moduleConflict(t *testing.T) {
+	packageA := testFSPackage(t, "fs:a", map[string]string{
+		"001-foo.rego": "package main\n\ndeny_foo[msg] { msg := \"foo\" }\n",
+	})
+	packageB := testFSPackage(t, "fs:b", map[string]string{
+		"001-foo.rego": "package main\n\ndeny_bar[msg] { msg := \"bar\" }\n",
+	})
+
+	_, _, err := NewRegoCompiler([]Package{packageA, packageB})
+	assert.ErrorContains(t, err, "module 001-foo.rego is defined in both packages fs:a and fs:b")
+
+	_, err = RegoCompilerKey([]Package{packageA, packageB})
+	assert.Error(t, err)
+}
+
+func Test_CheckDataConflicts(t *testing.T) {
+	packageMain := testFSPackage(t, "fs:a", map[string]string{
+		"a/001-foo.rego": "package main\n\ndeny_foo[msg] { msg := \"foo\" }\n",
+	})
+	packagePSS := testFSPackage(t, "fs:b", map[string]string{
+		"b/001-foo.rego": "package kubernetes.pss.baseline\n\ndeny_foo[msg] { msg := \"foo\" }\n",
+	})
+	packages := []Package{packageMain, packagePSS}
+
+	assert.NoError(t, CheckDataConflicts(packages, []DataDocument{
+		{Key: []string{"registries"}, Source: "registries.yaml"},
+		{Key: []string{"mainx"}, Source: "mainx.yaml"},
+		{Key: []string{"kubernetes", "exceptions"}, Source: "exceptions.yaml"},
+	}))
+
+	conflictCases := []struct {
+		doc      DataDocument
+		expected string
+	}{
+		{
+			doc:      DataDocument{Key: []string{"main"}, Source: "main.yaml"},
+			expected: `data document "main.yaml" at key "main" conflicts with namespace "main" of package fs:a`,
+		},
+		{
+			doc:      DataDocument{Key: []string{"main", "foo"}, Source: "foo.yaml"},
+			expected: `data document "foo.yaml" at key "main.foo" conflicts with namespace "main" of package fs:a`,
+		},
+		{
+			doc:      DataDocument{Key: []string{"kubernetes"}, Source: "kubernetes.yaml"},
+			expected: `data document "kubernetes.yaml" at key "kubernetes" conflicts with namespace "kubernetes.pss.baseline" of package fs:b`,
+		},
+	}
+	for _, c := range conflictCases {
+		err := CheckDataConflicts(packages, []DataDocument{c.doc})
+		assert.ErrorContains(t, err, c.expected)
+	}
+}
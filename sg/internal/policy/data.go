@@ -0,0 +1,244 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/OneOfOne/xxhash"
+	"github.com/open-policy-agent/opa/util"
+)
+
+// dataPathKeySeparator separates the explicit key and the path in a data path.
+const dataPathKeySeparator = "="
+
+// dataKeySeparator separates the segments of a (nested) data key.
+const dataKeySeparator = "."
+
+var dataFileExtensions = map[string]struct{}{
+	".json": {},
+	".yaml": {},
+	".yml":  {},
+}
+
+// DataPath specifies a data file or directory to load.
+type DataPath struct {
+	// Key is the optional explicit key to mount the data under.
+	// Dotted key (e.g. "registries.approved") mounts the data as nested document.
+	// When empty, each file is mounted by its file name (without extension).
+	Key string
+	// Path is the path to the data file or directory.
+	Path string
+}
+
+// ParseDataPath parses a data path in the form of `[<key>=]<path>`.
+// For example:
+//
+//   - "data/approved_registries.yaml" => mounts as `data.approved_registries`
+//   - "registries=data/approved.yaml" => mounts as `data.registries`
+//   - "teams.exceptions=data/exceptions" => mounts each file as `data.teams.exceptions.<file name>`
+//
+// The prefix is parsed as key only if it's a valid (dotted) rego reference, so paths containing
+// `=` (e.g. "conf/a=b.json") are parsed as path.
+func ParseDataPath(s string) DataPath {
+	key, path, found := strings.Cut(s, dataPathKeySeparator)
+	// NOTE: data keys share the same form as the namespaces
+	if !found || !namespaceRegex.MatchString(key) {
+		return DataPath{Path: s}
+	}
+	return DataPath{Key: key, Path: path}
+}
+
+// DataDocument is an extra (non-policy) document loaded into the rego data document.
+type DataDocument struct {
+	// Key is the path of the document under the data root.
+	// For example, ["registries", "approved"] mounts the document as `data.registries.approved`.
+	Key []string
+	// Source is the file path of the document.
+	Source string
+	// Value is the parsed document content.
+	Value interface{}
+}
+
+func splitDataKey(key string) ([]string, error) {
+	segments := strings.Split(key, dataKeySeparator)
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("invalid data key %q", key)
+		}
+	}
+	return segments, nil
+}
+
+func fileNameWithoutExt(p string) string {
+	f := filepath.Base(p)
+	return strings.TrimSuffix(f, filepath.Ext(f))
+}
+
+func loadDataDocumentFromFile(key []string, path string) (DataDocument, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return DataDocument{}, fmt.Errorf("read data file %q: %w", path, err)
+	}
+
+	var value interface{}
+	if err := util.Unmarshal(b, &value); err != nil {
+		return DataDocument{}, fmt.Errorf("parse data file %q: %w", path, err)
+	}
+
+	return DataDocument{
+		Key:    key,
+		Source: path,
+		Value:  value,
+	}, nil
+}
+
+func loadDataFromPath(dataPath DataPath) ([]DataDocument, error) {
+	var baseKey []string
+	if dataPath.Key != "" {
+		k, err := splitDataKey(dataPath.Key)
+		if err != nil {
+			return nil, err
+		}
+		baseKey = k
+	}
+
+	info, err := os.Stat(dataPath.Path)
+	if err != nil {
+		return nil, fmt.Errorf("stat data path %q: %w", dataPath.Path, err)
+	}
+
+	if !info.IsDir() {
+		key := baseKey
+		if len(key) == 0 {
+			key = []string{fileNameWithoutExt(dataPath.Path)}
+		}
+		doc, err := loadDataDocumentFromFile(key, dataPath.Path)
+		if err != nil {
+			return nil, err
+		}
+		return []DataDocument{doc}, nil
+	}
+
+	var files []string
+	walk := func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if _, ok := dataFileExtensions[strings.ToLower(filepath.Ext(path))]; ok {
+			files = append(files, path)
+		}
+		return nil
+	}
+	if err := filepath.WalkDir(dataPath.Path, walk); err != nil {
+		return nil, fmt.Errorf("walk data path %q: %w", dataPath.Path, err)
+	}
+	sort.Strings(files)
+
+	var rv []DataDocument
+	for _, file := range files {
+		key := append(append([]string{}, baseKey...), fileNameWithoutExt(file))
+		doc, err := loadDataDocumentFromFile(key, file)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, doc)
+	}
+
+	return rv, nil
+}
+
+// LoadDataFromPaths loads data documents from the given paths.
+func LoadDataFromPaths(dataPaths []DataPath) ([]DataDocument, error) {
+	var rv []DataDocument
+
+	for _, dataPath := range dataPaths {
+		docs, err := loadDataFromPath(dataPath)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, docs...)
+	}
+
+	return rv, nil
+}
+
+func conflictDataSource(sources map[string]string, key string) string {
+	if source, ok := sources[key]; ok {
+		return source
+	}
+	// the key is occupied by nested documents
+	keys := make([]string, 0, len(sources))
+	for k := range sources {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.HasPrefix(k, key+dataKeySeparator) {
+			return sources[k]
+		}
+	}
+	return ""
+}
+
+// MergeDataDocuments merges data documents into a single object rooted at `data`.
+// Documents mounted to the same key are considered as conflict.
+func MergeDataDocuments(docs []DataDocument) (map[string]interface{}, error) {
+	rv := map[string]interface{}{}
+	sources := map[string]string{}
+
+	for _, doc := range docs {
+		if len(doc.Key) == 0 {
+			return nil, fmt.Errorf("data document %q has empty key", doc.Source)
+		}
+
+		parent := rv
+		for idx, segment := range doc.Key[:len(doc.Key)-1] {
+			prefix := strings.Join(doc.Key[:idx+1], dataKeySeparator)
+			if source, ok := sources[prefix]; ok {
+				return nil, fmt.Errorf(
+					"data document %q conflicts with %q at key %q",
+					doc.Source, source, prefix,
+				)
+			}
+			child, ok := parent[segment].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				parent[segment] = child
+			}
+			parent = child
+		}
+
+		key := strings.Join(doc.Key, dataKeySeparator)
+		leaf := doc.Key[len(doc.Key)-1]
+		if _, exists := parent[leaf]; exists {
+			return nil, fmt.Errorf(
+				"data document %q conflicts with %q at key %q",
+				doc.Source, conflictDataSource(sources, key), key,
+			)
+		}
+		parent[leaf] = doc.Value
+		sources[key] = doc.Source
+	}
+
+	return rv, nil
+}
+
+// DataKey returns the key of the merged data documents.
+// The key changes when the content of the data changes.
+func DataKey(data map[string]interface{}) (string, error) {
+	// NOTE: json.Marshal sorts the map keys, so the output is stable.
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("marshal data: %w", err)
+	}
+
+	return fmt.Sprint(xxhash.Checksum64(b)), nil
+}
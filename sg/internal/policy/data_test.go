@@ -0,0 +1,144 @@
+package policy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseDataPath(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected DataPath
+	}{
+		{
+			input:    "data/foo.yaml",
+			expected: DataPath{Path: "data/foo.yaml"},
+		},
+		{
+			input:    "foo=data/foo.yaml",
+			expected: DataPath{Key: "foo", Path: "data/foo.yaml"},
+		},
+		{
+			input:    "foo.bar=data",
+			expected: DataPath{Key: "foo.bar", Path: "data"},
+		},
+		{
+			input:    "conf/a=b.json",
+			expected: DataPath{Path: "conf/a=b.json"},
+		},
+		{
+			input:    "foo=conf/a=b.json",
+			expected: DataPath{Key: "foo", Path: "conf/a=b.json"},
+		},
+		{
+			input:    "a-b=data/foo.yaml",
+			expected: DataPath{Path: "a-b=data/foo.yaml"},
+		},
+	}
+
+	for idx := range cases {
+		c := cases[idx]
+		t.Run(fmt.Sprintf("case #%d", idx), func(t *testing.T) {
+			assert.Equal(t, c.expected, ParseDataPath(c.input))
+		})
+	}
+}
+
+func Test_LoadDataFromPaths(t *testing.T) {
+	t.Run("file", func(t *testing.T) {
+		docs, err := LoadDataFromPaths([]DataPath{
+			{Path: "./testdata/data/approved_registries.yaml"},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, docs, 1)
+		assert.Equal(t, []string{"approved_registries"}, docs[0].Key)
+		assert.Equal(t, []interface{}{"mcr.microsoft.com", "ghcr.io"}, docs[0].Value)
+	})
+
+	t.Run("file with explicit key", func(t *testing.T) {
+		docs, err := LoadDataFromPaths([]DataPath{
+			{Key: "registries.approved", Path: "./testdata/data/approved_registries.yaml"},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, docs, 1)
+		assert.Equal(t, []string{"registries", "approved"}, docs[0].Key)
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		docs, err := LoadDataFromPaths([]DataPath{
+			{Key: "teams", Path: "./testdata/data/nested"},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, docs, 1, "non data files should be ignored")
+		assert.Equal(t, []string{"teams", "team-a"}, docs[0].Key)
+		assert.Equal(t, map[string]interface{}{"owner": "team-a"}, docs[0].Value)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := LoadDataFromPaths([]DataPath{
+			{Key: "teams..a", Path: "./testdata/data/nested"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("not exists", func(t *testing.T) {
+		_, err := LoadDataFromPaths([]DataPath{
+			{Path: "./testdata/data/not-exists.yaml"},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func Test_MergeDataDocuments(t *testing.T) {
+	t.Run("merge", func(t *testing.T) {
+		data, err := MergeDataDocuments([]DataDocument{
+			{Key: []string{"foo"}, Source: "foo.yaml", Value: "foo"},
+			{Key: []string{"bar", "baz"}, Source: "baz.yaml", Value: "baz"},
+			{Key: []string{"bar", "qux"}, Source: "qux.yaml", Value: "qux"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"foo": "foo",
+			"bar": map[string]interface{}{
+				"baz": "baz",
+				"qux": "qux",
+			},
+		}, data)
+	})
+
+	conflictCases := [][]DataDocument{
+		{
+			{Key: []string{"foo"}, Source: "foo.yaml", Value: "foo"},
+			{Key: []string{"foo"}, Source: "foo.json", Value: "foo"},
+		},
+		{
+			{Key: []string{"foo"}, Source: "foo.yaml", Value: "foo"},
+			{Key: []string{"foo", "bar"}, Source: "bar.yaml", Value: "bar"},
+		},
+		{
+			{Key: []string{"foo", "bar"}, Source: "bar.yaml", Value: "bar"},
+			{Key: []string{"foo"}, Source: "foo.yaml", Value: "foo"},
+		},
+	}
+	for idx := range conflictCases {
+		docs := conflictCases[idx]
+		t.Run(fmt.Sprintf("conflict #%d", idx), func(t *testing.T) {
+			_, err := MergeDataDocuments(docs)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_DataKey(t *testing.T) {
+	key1, err := DataKey(map[string]interface{}{"foo": "bar", "baz": []interface{}{1}})
+	assert.NoError(t, err)
+	key2, err := DataKey(map[string]interface{}{"baz": []interface{}{1}, "foo": "bar"})
+	assert.NoError(t, err)
+	assert.Equal(t, key1, key2, "key should be stable")
+
+	key3, err := DataKey(map[string]interface{}{"foo": "bar"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, key1, key3, "key should change with data content")
+}
@@ -2,6 +2,8 @@ package policy
 
 import (
 	"fmt"
+	"io/fs"
+	"strings"
 
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/loader"
@@ -24,7 +26,12 @@ func loadPackageFromPath(path string) (Package, error) {
 
 	// load rules
 	{
-		policies, err := loader.AllRegos([]string{path})
+		// NOTE: annotations are processed for loading the rule metadata
+		policies, err := loader.NewFileLoader().
+			WithProcessAnnotation(true).
+			Filtered([]string{path}, func(_ string, info fs.FileInfo, _ int) bool {
+				return !info.IsDir() && !strings.HasSuffix(info.Name(), ".rego")
+			})
 		if err != nil {
 			return nil, fmt.Errorf("failed to load policies: %w", err)
 		}
@@ -34,7 +41,11 @@ func loadPackageFromPath(path string) (Package, error) {
 
 		rv.parsedModules = policies.ParsedModules()
 		for _, module := range rv.parsedModules {
-			rv.rules = append(rv.rules, loadRulesFromModule(module)...)
+			rules, err := loadRulesFromModule(module)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load rules from %s: %w", module.Package.Location.File, err)
+			}
+			rv.rules = append(rv.rules, rules...)
 		}
 	}
 
@@ -69,11 +80,17 @@ func (p *FSPackage) ParsedModules() map[string]*ast.Module {
 }
 
 // LoadPackagesFromPaths loads policy packages from the given paths.
+// Paths prefixed with "builtin:" refer to the built-in packages. See BuiltinPackageRefs for available values.
 func LoadPackagesFromPaths(paths []string) ([]Package, error) {
 	var rv []Package
 
 	for _, path := range paths {
-		p, err := loadPackageFromPath(path)
+		loadPackage := loadPackageFromPath
+		if IsBuiltinPackageRef(path) {
+			loadPackage = loadBuiltinPackage
+		}
+
+		p, err := loadPackage(path)
 		if err != nil {
 			return nil, err
 		}
@@ -118,3 +118,58 @@ func Test_LoadPackages_multiple(t *testing.T) {
 	assert.NotEqual(t, defaultPackageSpec(), pkgs[0].Spec())
 	assert.Equal(t, defaultPackageSpec(), pkgs[1].Spec())
 }
+
+func Test_LoadPackagesFromPaths_annotations(t *testing.T) {
+	pkgs, err := LoadPackagesFromPaths([]string{"./testdata/annotations"})
+	assert.NoError(t, err)
+	assert.Len(t, pkgs, 1)
+
+	rules := map[string]Rule{}
+	for _, r := range pkgs[0].Rules() {
+		rules[r.Query()] = r
+	}
+	assert.Len(t, rules, 2)
+
+	t.Run("rule annotations", func(t *testing.T) {
+		r := rules["deny_latest_tag"]
+		if !assert.NotNil(t, r.Annotations) {
+			return
+		}
+		assert.Equal(t, "No latest tag", r.Annotations.Title)
+		assert.Equal(t, "Container images must be pinned to a version instead of the latest tag.", r.Annotations.Description)
+		assert.Equal(t, []RelatedResource{
+			{
+				Ref:         "https://kubernetes.io/docs/concepts/containers/images/#image-names",
+				Description: "Image names",
+			},
+		}, r.Annotations.RelatedResources)
+		assert.Equal(t, map[string]interface{}{
+			"severity": "high",
+			"category": "supply-chain",
+		}, r.Annotations.Custom, "rule annotations should take precedence over package annotations")
+
+		docLink, err := ResolveRuleDocLink(pkgs[0].Spec(), r)
+		assert.NoError(t, err)
+		assert.Equal(t, "https://kubernetes.io/docs/concepts/containers/images/#image-names", docLink)
+	})
+
+	t.Run("package annotations", func(t *testing.T) {
+		r := rules["warn_missing_tag"]
+		if !assert.NotNil(t, r.Annotations) {
+			return
+		}
+		assert.Equal(t, "Container images", r.Annotations.Title)
+		assert.Equal(t, "Rules for the container images.", r.Annotations.Description)
+		assert.Empty(t, r.Annotations.RelatedResources)
+		assert.Equal(t, map[string]interface{}{
+			"severity": "medium",
+			"category": "supply-chain",
+		}, r.Annotations.Custom)
+	})
+
+	basicPkgs, err := LoadPackagesFromPaths([]string{"./testdata/basic"})
+	assert.NoError(t, err)
+	for _, r := range basicPkgs[0].Rules() {
+		assert.Nil(t, r.Annotations, "rule %s is not annotated", r.Query())
+	}
+}
@@ -0,0 +1,29 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// NamespaceMain is the default namespace to query rules from.
+const NamespaceMain = "main"
+
+var namespaceRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)*$`)
+
+// ValidateNamespace validates the rego namespace (the package path without the `data.` prefix).
+// For example: `main`, `kubernetes.pss.baseline`.
+func ValidateNamespace(namespace string) error {
+	if !namespaceRegex.MatchString(namespace) {
+		return fmt.Errorf("invalid namespace %q", namespace)
+	}
+	return nil
+}
+
+// QueryNamespaces returns the namespaces to query rules from.
+// It returns the main namespace if the package spec doesn't specify any.
+func (spec PackageSpec) QueryNamespaces() []string {
+	if len(spec.Namespaces) < 1 {
+		return []string{NamespaceMain}
+	}
+	return spec.Namespaces
+}
@@ -28,10 +28,15 @@ type RuleSpec struct {
 type PackageSpec struct {
 	// Rule specifies the policy rule settings.
 	Rule *RuleSpec `json:"rule,omitempty" yaml:"rule,omitempty"`
+	// Namespaces specifies the rego namespaces (e.g. `kubernetes.pss.baseline`) to query rules from.
+	// Defaults to the `main` namespace.
+	Namespaces []string `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
 }
 
 // rule:
 //   doc_link: https://example.com/docs/{{.Kind}}/{{.SourceFileName}}.md
+// namespaces:
+//   - kubernetes.pss.baseline
 
 func defaultPackageSpec() PackageSpec {
 	return PackageSpec{
@@ -50,17 +55,31 @@ func loadPackageSpecFromDir(dir string) (PackageSpec, error) {
 		}
 		return PackageSpec{}, fmt.Errorf("failed to read package spec file: %w", err)
 	} else {
-		var spec PackageSpec
-		if err := yaml.Unmarshal(b, &spec); err != nil {
-			return PackageSpec{}, fmt.Errorf("failed to unmarshal package spec: %w", err)
+		return parsePackageSpec(b)
+	}
+}
+
+func parsePackageSpec(b []byte) (PackageSpec, error) {
+	var spec PackageSpec
+	if err := yaml.Unmarshal(b, &spec); err != nil {
+		return PackageSpec{}, fmt.Errorf("failed to unmarshal package spec: %w", err)
+	}
+	for _, namespace := range spec.Namespaces {
+		if err := ValidateNamespace(namespace); err != nil {
+			return PackageSpec{}, fmt.Errorf("invalid package spec: %w", err)
 		}
-		return spec, nil
 	}
+	return spec, nil
 }
 
 // ResolveRuleDocLink resolves the rule document link.
+// When the package spec doesn't set the doc link, the first related resource
+// in the rule annotations is used.
 func ResolveRuleDocLink(spec PackageSpec, rule Rule) (string, error) {
 	if spec.Rule == nil || spec.Rule.DocLink == "" {
+		if rule.Annotations != nil && len(rule.Annotations.RelatedResources) > 0 {
+			return rule.Annotations.RelatedResources[0].Ref, nil
+		}
 		// not set
 		return "", nil
 	}
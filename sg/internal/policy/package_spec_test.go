@@ -45,6 +45,19 @@ func Test_ResolveRuleDocLink(t *testing.T) {
 			rule:     makeRule(),
 			expected: "",
 		},
+		// no rule doc link, fallback to related resource
+		{
+			spec: makePackageSpec(),
+			rule: makeRule(func(r *Rule) {
+				r.Annotations = &Annotations{
+					RelatedResources: []RelatedResource{
+						{Ref: "https://example.com/foo"},
+						{Ref: "https://example.com/bar"},
+					},
+				}
+			}),
+			expected: "https://example.com/foo",
+		},
 		// invalid rule doc link format
 		{
 			spec: makePackageSpec(func(ps *PackageSpec) {
@@ -94,3 +107,19 @@ func Test_ResolveRuleDocLink(t *testing.T) {
 		})
 	}
 }
+
+func Test_parsePackageSpec_namespaces(t *testing.T) {
+	spec, err := parsePackageSpec([]byte(`namespaces: [kubernetes.pss.baseline, main]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kubernetes.pss.baseline", "main"}, spec.Namespaces)
+	assert.Equal(t, []string{"kubernetes.pss.baseline", "main"}, spec.QueryNamespaces())
+
+	spec, err = parsePackageSpec([]byte(`rule: {}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{NamespaceMain}, spec.QueryNamespaces())
+
+	_, err = parsePackageSpec([]byte(`namespaces: ["kubernetes..pss"]`))
+	assert.Error(t, err)
+	_, err = parsePackageSpec([]byte(`namespaces: ["kubernetes/pss"]`))
+	assert.Error(t, err)
+}
@@ -1,40 +1,103 @@
 package policy
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
-	"strings"
 
 	"github.com/OneOfOne/xxhash"
 	"github.com/open-policy-agent/opa/ast"
 )
 
-func regoCompilerKey(packages []Package, _ []RegoCompilerOptions) string {
-	packageIDs := make([]string, 0, len(packages))
+// regoCompilerKey resolves the key of the compiler from the content of the modules,
+// the package specs and the compiler options.
+// Module file paths are excluded, so the same policies checked out to different locations share the same key.
+func regoCompilerKey(
+	packages []Package,
+	modules map[string]*ast.Module,
+	opts []RegoCompilerOptions,
+) (string, error) {
+	moduleContents := make([]string, 0, len(modules))
+	for _, m := range modules {
+		moduleContents = append(moduleContents, m.String())
+	}
+	sort.Strings(moduleContents)
+
+	packageSpecs := make([]string, 0, len(packages))
 	for _, p := range packages {
-		packageIDs = append(packageIDs, p.QualifiedID())
+		b, err := json.Marshal(p.Spec())
+		if err != nil {
+			return "", fmt.Errorf("marshal package spec (%s): %w", p.QualifiedID(), err)
+		}
+		packageSpecs = append(packageSpecs, string(b))
 	}
-	sort.Strings(packageIDs)
+	sort.Strings(packageSpecs)
 
-	k := xxhash.Checksum64([]byte(strings.Join(packageIDs, ",")))
+	compilerOptions, err := json.Marshal(opts)
+	if err != nil {
+		return "", fmt.Errorf("marshal compiler options: %w", err)
+	}
 
-	return fmt.Sprint(k)
+	h := xxhash.New64()
+	for _, section := range [][]string{
+		moduleContents,
+		packageSpecs,
+		{string(compilerOptions)},
+	} {
+		fmt.Fprintf(h, "%d;", len(section))
+		for _, c := range section {
+			fmt.Fprintf(h, "%d:%s;", len(c), c)
+		}
+	}
+
+	return fmt.Sprint(h.Sum64()), nil
 }
 
 // RegoCompilerOptions configs the RegoCompiler.
 type RegoCompilerOptions struct{}
 
-// NewRegoCompiler creates a compiler from policy packages.
-func NewRegoCompiler(
-	packages []Package,
-	opts ...RegoCompilerOptions,
-) (*ast.Compiler, string, error) {
+// mergeModules merges the parsed modules of the packages.
+// Modules with the same name from different packages are reported as conflicts,
+// instead of overwriting each other silently. Identical modules are merged once,
+// as the shared lib modules of the built-in packages (see builtinLibDirs).
+func mergeModules(packages []Package) (map[string]*ast.Module, error) {
 	modules := map[string]*ast.Module{}
+	modulePackages := map[string]string{}
 	for _, p := range packages {
 		for name, m := range p.ParsedModules() {
+			if other, exists := modulePackages[name]; exists {
+				if modules[name].Equal(m) {
+					continue
+				}
+				return nil, fmt.Errorf("module %s is defined in both packages %s and %s", name, other, p.QualifiedID())
+			}
 			modules[name] = m
+			modulePackages[name] = p.QualifiedID()
 		}
 	}
+	return modules, nil
+}
+
+// RegoCompilerKey resolves the compiler key of the policy packages without compiling them.
+// It's the same key returned by NewRegoCompiler with the same packages.
+func RegoCompilerKey(packages []Package, opts ...RegoCompilerOptions) (string, error) {
+	modules, err := mergeModules(packages)
+	if err != nil {
+		return "", err
+	}
+	return regoCompilerKey(packages, modules, opts)
+}
+
+// NewRegoCompiler creates a compiler from policy packages.
+// Modules of all packages are compiled together. To isolate the packages, create one compiler per package.
+func NewRegoCompiler(
+	packages []Package,
+	opts ...RegoCompilerOptions,
+) (*ast.Compiler, string, error) {
+	modules, err := mergeModules(packages)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create compiler: %w", err)
+	}
 
 	compiler := ast.NewCompiler()
 	compiler.Compile(modules)
@@ -42,7 +105,10 @@ func NewRegoCompiler(
 		return nil, "", fmt.Errorf("failed to create compiler: %w", compiler.Errors)
 	}
 
-	compilerKey := regoCompilerKey(packages, opts)
+	compilerKey, err := regoCompilerKey(packages, modules, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve compiler key: %w", err)
+	}
 
 	return compiler, compilerKey, nil
 }
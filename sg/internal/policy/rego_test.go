@@ -2,6 +2,8 @@ package policy
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,7 +16,7 @@ func Test_NewRegoCompiler_Integration(t *testing.T) {
 	}{
 		{
 			path:                "./testdata/basic",
-			expectedCompilerKey: "16834659949892238337",
+			expectedCompilerKey: "2180293901412091829",
 		},
 	}
 
@@ -32,3 +34,58 @@ func Test_NewRegoCompiler_Integration(t *testing.T) {
 		})
 	}
 }
+
+func Test_NewRegoCompiler_compilerKey(t *testing.T) {
+	compilerKeyOf := func(t *testing.T, path string) string {
+		packages, err := LoadPackagesFromPaths([]string{path})
+		assert.NoError(t, err)
+		_, compilerKey, err := NewRegoCompiler(packages)
+		assert.NoError(t, err)
+		return compilerKey
+	}
+
+	writePolicy := func(t *testing.T, dir string, content string) {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "001-policy.rego"), []byte(content), 0644))
+	}
+
+	const policy = `package main
+
+deny_foo[msg] {
+	input.foo
+	msg := "foo"
+}
+`
+
+	dir1 := t.TempDir()
+	writePolicy(t, dir1, policy)
+	dir2 := t.TempDir()
+	writePolicy(t, dir2, policy)
+
+	assert.Equal(
+		t, compilerKeyOf(t, dir1), compilerKeyOf(t, dir2),
+		"same policies from different paths should have the same key",
+	)
+
+	keyBeforeChange := compilerKeyOf(t, dir1)
+	writePolicy(t, dir1, policy+`
+deny_bar[msg] {
+	input.bar
+	msg := "bar"
+}
+`)
+	assert.NotEqual(
+		t, keyBeforeChange, compilerKeyOf(t, dir1),
+		"editing policies should change the key",
+	)
+
+	keyBeforeChange = compilerKeyOf(t, dir1)
+	assert.NoError(t, os.WriteFile(
+		filepath.Join(dir1, PackageSpecFileName),
+		[]byte("rule:\n  doc_link: https://example.com/{{.Name}}.md\n"),
+		0644,
+	))
+	assert.NotEqual(
+		t, keyBeforeChange, compilerKeyOf(t, dir1),
+		"editing package spec should change the key",
+	)
+}
@@ -35,11 +35,16 @@ func (r Rule) IsKind(kind QueryKind, others ...QueryKind) bool {
 	return false
 }
 
-func loadRulesFromModule(module *ast.Module) []Rule {
+func loadRulesFromModule(module *ast.Module) ([]Rule, error) {
 	var rv []Rule
 
 	moduleNamespace := strings.Replace(module.Package.Path.String(), "data.", "", 1)
 
+	annotationSet, errs := ast.BuildAnnotationSet([]*ast.Module{module})
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to load annotations: %w", errs)
+	}
+
 	for _, regoRule := range module.Rules {
 		ruleString := regoRule.Head.Name.String()
 		ps := queryRegex.FindAllStringSubmatch(ruleString, -1)
@@ -53,10 +58,16 @@ func loadRulesFromModule(module *ast.Module) []Rule {
 			Name:           strings.TrimPrefix(ruleString, parsed[1]+"_"),
 			Namespace:      moduleNamespace,
 			SourceLocation: regoRule.Location,
+			Annotations:    loadRuleAnnotations(annotationSet, regoRule),
+		}
+		if rule.Annotations != nil {
+			if _, err := SeverityFromMetadata(rule.Annotations.Custom); err != nil {
+				return nil, fmt.Errorf("invalid annotations of rule %s: %w", ruleString, err)
+			}
 		}
 
 		rv = append(rv, rule)
 	}
 
-	return rv
+	return rv, nil
 }
@@ -3,6 +3,8 @@ package policy
 import (
 	"testing"
 
+	"github.com/open-policy-agent/opa/ast"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,3 +18,22 @@ func Test_Rule_IsKind(t *testing.T) {
 	assert.True(t, r.IsKind(QueryKindWarn, QueryKindViolation, QueryKindException, QueryKindDeny))
 	assert.False(t, r.IsKind(QueryKindWarn, QueryKindViolation))
 }
+
+func Test_loadRulesFromModule_invalidSeverity(t *testing.T) {
+	module, err := ast.ParseModuleWithOpts(
+		"test.rego",
+		`package main
+
+# METADATA
+# custom:
+#   severity: urgent
+deny_foo[msg] {
+	msg := "foo"
+}`,
+		ast.ParserOptions{ProcessAnnotation: true},
+	)
+	assert.NoError(t, err)
+
+	_, err = loadRulesFromModule(module)
+	assert.ErrorContains(t, err, "invalid annotations of rule")
+}
@@ -0,0 +1,44 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+)
+
+// RuleSelector selects the rules to query by matching the rule query (e.g. `deny_foo`, see Rule.Query).
+// Patterns are either exact rule queries or globs (e.g. `warn_*`).
+type RuleSelector struct {
+	// Include specifies the rules to query. Empty value means all rules.
+	Include []string
+	// Exclude specifies the rules to skip. It takes precedence over Include.
+	Exclude []string
+}
+
+// Validate validates the patterns of the selector.
+func (s RuleSelector) Validate() error {
+	for _, pattern := range append(append([]string{}, s.Include...), s.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid rule pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Selects tells if the rule is selected.
+func (s RuleSelector) Selects(rule Rule) bool {
+	query := rule.Query()
+	if len(s.Include) > 0 && !matchRulePatterns(s.Include, query) {
+		return false
+	}
+	return !matchRulePatterns(s.Exclude, query)
+}
+
+func matchRulePatterns(patterns []string, query string) bool {
+	for _, pattern := range patterns {
+		// NOTE: patterns are validated in advance
+		if matched, _ := path.Match(pattern, query); matched {
+			return true
+		}
+	}
+	return false
+}
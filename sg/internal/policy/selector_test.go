@@ -0,0 +1,30 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RuleSelector(t *testing.T) {
+	denyFoo := Rule{Kind: QueryKindDeny, Name: "foo"}
+	denyBar := Rule{Kind: QueryKindDeny, Name: "bar"}
+	warnFoo := Rule{Kind: QueryKindWarn, Name: "foo"}
+
+	all := RuleSelector{}
+	assert.True(t, all.Selects(denyFoo))
+	assert.True(t, all.Selects(warnFoo))
+
+	includeDeny := RuleSelector{Include: []string{"deny_*"}}
+	assert.True(t, includeDeny.Selects(denyFoo))
+	assert.True(t, includeDeny.Selects(denyBar))
+	assert.False(t, includeDeny.Selects(warnFoo))
+
+	excludeFoo := RuleSelector{Include: []string{"deny_*"}, Exclude: []string{"deny_foo"}}
+	assert.False(t, excludeFoo.Selects(denyFoo), "exclude takes precedence")
+	assert.True(t, excludeFoo.Selects(denyBar))
+	assert.False(t, excludeFoo.Selects(warnFoo))
+
+	assert.NoError(t, excludeFoo.Validate())
+	assert.Error(t, RuleSelector{Exclude: []string{"deny_["}}.Validate())
+}
@@ -0,0 +1,97 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity specifies the severity level of a rule or result.
+type Severity string
+
+const (
+	// SeverityUnspecified means the severity is not specified.
+	SeverityUnspecified Severity = ""
+	SeverityCritical    Severity = "critical"
+	SeverityHigh        Severity = "high"
+	SeverityMedium      Severity = "medium"
+	SeverityLow         Severity = "low"
+	SeverityInfo        Severity = "info"
+)
+
+// Severities lists the severity levels, from the highest to the lowest.
+var Severities = []Severity{
+	SeverityCritical,
+	SeverityHigh,
+	SeverityMedium,
+	SeverityLow,
+	SeverityInfo,
+}
+
+// severityMetadataField is the field name for specifying the severity
+// in the rule annotations (custom fields) or the result metadata.
+const severityMetadataField = "severity"
+
+// ParseSeverity parses the severity level (case insensitive).
+func ParseSeverity(s string) (Severity, error) {
+	severity := Severity(strings.ToLower(strings.TrimSpace(s)))
+	for _, v := range Severities {
+		if v == severity {
+			return v, nil
+		}
+	}
+	return SeverityUnspecified, fmt.Errorf("unknown severity %q, supported values: %s", s, SeveritiesHelp())
+}
+
+// SeveritiesHelp returns help message for the severity levels.
+func SeveritiesHelp() string {
+	rv := make([]string, 0, len(Severities))
+	for _, s := range Severities {
+		rv = append(rv, string(s))
+	}
+	return strings.Join(rv, ", ")
+}
+
+// rank returns the rank of the severity. Higher rank means more severe.
+// Unspecified severity has the lowest rank.
+func (s Severity) rank() int {
+	for idx, v := range Severities {
+		if v == s {
+			return len(Severities) - idx
+		}
+	}
+	return 0
+}
+
+// AtLeast tells if the severity is at or above the other severity.
+// Unspecified severity is never at or above any level.
+func (s Severity) AtLeast(other Severity) bool {
+	if s == SeverityUnspecified {
+		return false
+	}
+	return s.rank() >= other.rank()
+}
+
+// SeverityFromMetadata resolves the severity from the `severity` field of the metadata.
+// It returns SeverityUnspecified if the field is not set.
+func SeverityFromMetadata(metadata map[string]interface{}) (Severity, error) {
+	v, ok := metadata[severityMetadataField]
+	if !ok {
+		return SeverityUnspecified, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return SeverityUnspecified, fmt.Errorf("%s field must be string: %v", severityMetadataField, v)
+	}
+	return ParseSeverity(s)
+}
+
+// Severity returns the severity of the rule, which is specified by the `severity` custom field
+// in the rule annotations. It returns SeverityUnspecified if the rule doesn't specify one.
+func (r Rule) Severity() Severity {
+	if r.Annotations == nil {
+		return SeverityUnspecified
+	}
+	// NOTE: the value is validated when loading the rule
+	severity, _ := SeverityFromMetadata(r.Annotations.Custom)
+	return severity
+}
@@ -0,0 +1,57 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseSeverity(t *testing.T) {
+	for _, s := range Severities {
+		parsed, err := ParseSeverity(string(s))
+		assert.NoError(t, err)
+		assert.Equal(t, s, parsed)
+	}
+
+	parsed, err := ParseSeverity(" HIGH ")
+	assert.NoError(t, err)
+	assert.Equal(t, SeverityHigh, parsed)
+
+	_, err = ParseSeverity("")
+	assert.Error(t, err)
+	_, err = ParseSeverity("foobar")
+	assert.Error(t, err)
+}
+
+func Test_Severity_AtLeast(t *testing.T) {
+	assert.True(t, SeverityCritical.AtLeast(SeverityHigh))
+	assert.True(t, SeverityHigh.AtLeast(SeverityHigh))
+	assert.False(t, SeverityMedium.AtLeast(SeverityHigh))
+	assert.True(t, SeverityInfo.AtLeast(SeverityInfo))
+	assert.False(t, SeverityUnspecified.AtLeast(SeverityInfo))
+}
+
+func Test_SeverityFromMetadata(t *testing.T) {
+	severity, err := SeverityFromMetadata(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, SeverityUnspecified, severity)
+
+	severity, err = SeverityFromMetadata(map[string]interface{}{"severity": "Medium"})
+	assert.NoError(t, err)
+	assert.Equal(t, SeverityMedium, severity)
+
+	_, err = SeverityFromMetadata(map[string]interface{}{"severity": 1})
+	assert.Error(t, err)
+	_, err = SeverityFromMetadata(map[string]interface{}{"severity": "urgent"})
+	assert.Error(t, err)
+}
+
+func Test_Rule_Severity(t *testing.T) {
+	assert.Equal(t, SeverityUnspecified, Rule{}.Severity())
+	assert.Equal(t, SeverityUnspecified, Rule{Annotations: &Annotations{Title: "foo"}}.Severity())
+	assert.Equal(
+		t,
+		SeverityHigh,
+		Rule{Annotations: &Annotations{Custom: map[string]interface{}{"severity": "high"}}}.Severity(),
+	)
+}
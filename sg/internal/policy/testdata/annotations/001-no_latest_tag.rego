@@ -0,0 +1,25 @@
+# METADATA
+# title: Container images
+# description: Rules for the container images.
+# custom:
+#   severity: medium
+#   category: supply-chain
+package main
+
+# METADATA
+# title: No latest tag
+# description: Container images must be pinned to a version instead of the latest tag.
+# related_resources:
+#   - ref: https://kubernetes.io/docs/concepts/containers/images/#image-names
+#     description: Image names
+# custom:
+#   severity: high
+deny_latest_tag[msg] {
+	endswith(input.image, ":latest")
+	msg := sprintf("image %s uses latest tag", [input.image])
+}
+
+warn_missing_tag[msg] {
+	not contains(input.image, ":")
+	msg := sprintf("image %s has no tag", [input.image])
+}
@@ -13,6 +13,7 @@ const (
 	QueryKindDeny      QueryKind = "deny"
 	QueryKindViolation QueryKind = "violation"
 	QueryKindException QueryKind = "exception"
+	QueryKindTest      QueryKind = "test"
 )
 
 // Rule specifies a policy rule.
@@ -31,6 +32,32 @@ type Rule struct {
 	Namespace string
 	// SourceLocation is the source definition of the rule.
 	SourceLocation *ast.Location
+	// Annotations is the metadata annotations of the rule.
+	// Nil if the rule is not annotated.
+	Annotations *Annotations
+}
+
+// Annotations specifies the OPA metadata annotations (`# METADATA`) of a rule.
+// Annotations in rule, document and package scopes are merged, where the more specific scope takes precedence.
+//
+// See: https://www.openpolicyagent.org/docs/latest/policy-language/#metadata
+type Annotations struct {
+	// Title is the title of the rule.
+	Title string
+	// Description is the description of the rule.
+	Description string
+	// RelatedResources is the list of resources related to the rule.
+	RelatedResources []RelatedResource
+	// Custom is the custom fields (e.g. severity) of the rule.
+	Custom map[string]interface{}
+}
+
+// RelatedResource specifies a resource related to a rule.
+type RelatedResource struct {
+	// Ref is the URL of the resource.
+	Ref string
+	// Description is the optional description of the resource.
+	Description string
 }
 
 // Package defines the access methods to a policy package.
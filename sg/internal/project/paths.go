@@ -0,0 +1,85 @@
+package project
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/utils"
+)
+
+// ResolveToContextRootFn returns the function to resolve the paths in the project spec to the context root.
+func ResolveToContextRootFn(contextRoot string) func(string) string {
+	return func(path string) string {
+		// FIXME(hbc): handle absolute paths input
+		//             We should limit the input to be relative to the context root.
+
+		fullPath := filepath.Join(contextRoot, path)
+		fullPath = filepath.Clean(fullPath)
+		return fullPath
+	}
+}
+
+// RelativeToContextRootFn returns the function to convert the absolute paths to be relative to the context root.
+// Relative paths are returned as is.
+func RelativeToContextRootFn(contextRoot string) func(string) string {
+	return func(path string) string {
+		if !filepath.IsAbs(path) {
+			return path
+		}
+		rel, err := filepath.Rel(contextRoot, path)
+		if err != nil {
+			return path
+		}
+		return rel
+	}
+}
+
+// RelativePackageID updates the path in the package qualified id (e.g. `fs:<path>`) to be relative to the context root.
+func RelativePackageID(contextRoot string, packageID string) string {
+	kind, path, found := strings.Cut(packageID, ":")
+	if !found {
+		return packageID
+	}
+	return kind + ":" + RelativeToContextRootFn(contextRoot)(path)
+}
+
+// ResolvePolicyPaths resolves the policy paths of the target to the context root.
+// Built-in package references are returned as is.
+func ResolvePolicyPaths(contextRoot string, target FileTargetSpec) []string {
+	resolveToContextRoot := ResolveToContextRootFn(contextRoot)
+	return utils.Map(target.Policies, func(s string) string {
+		if policy.IsBuiltinPackageRef(s) {
+			// built-in packages are not loaded from the file system
+			return s
+		}
+		return resolveToContextRoot(s)
+	})
+}
+
+// ResolveDataPaths resolves the data paths of the target to the context root.
+func ResolveDataPaths(contextRoot string, target FileTargetSpec) []policy.DataPath {
+	resolveToContextRoot := ResolveToContextRootFn(contextRoot)
+	return utils.Map(target.Data, func(s string) policy.DataPath {
+		dataPath := policy.ParseDataPath(s)
+		dataPath.Path = resolveToContextRoot(dataPath.Path)
+		return dataPath
+	})
+}
+
+// PolicyFilePaths returns the policy paths and data paths of the target on the file system,
+// resolved to the context root. Changes to these paths require recompiling the policies of the target.
+func PolicyFilePaths(contextRoot string, target FileTargetSpec) []string {
+	var rv []string
+	for _, p := range ResolvePolicyPaths(contextRoot, target) {
+		if policy.IsBuiltinPackageRef(p) {
+			// built-in packages are embedded in the binary
+			continue
+		}
+		rv = append(rv, p)
+	}
+	for _, dataPath := range ResolveDataPaths(contextRoot, target) {
+		rv = append(rv, dataPath.Path)
+	}
+	return rv
+}
@@ -0,0 +1,69 @@
+package project
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PolicyFilePaths(t *testing.T) {
+	contextRoot := filepath.Join("testdata", "project")
+	target := FileTargetSpec{
+		Name:     "test",
+		Policies: []string{"policy", "builtin:pss/baseline"},
+		Data:     []string{"foo=data/foo.json"},
+	}
+
+	assert.Equal(
+		t,
+		[]string{filepath.Join(contextRoot, "policy"), "builtin:pss/baseline"},
+		ResolvePolicyPaths(contextRoot, target),
+	)
+	assert.Equal(
+		t,
+		[]policy.DataPath{{Key: "foo", Path: filepath.Join(contextRoot, "data", "foo.json")}},
+		ResolveDataPaths(contextRoot, target),
+	)
+	assert.Equal(
+		t,
+		[]string{
+			filepath.Join(contextRoot, "policy"),
+			filepath.Join(contextRoot, "data", "foo.json"),
+		},
+		PolicyFilePaths(contextRoot, target),
+		"should skip built-in packages and resolve paths to the context root",
+	)
+}
+
+func Test_RelativePackageID(t *testing.T) {
+	contextRoot, err := filepath.Abs("testdata")
+	assert.NoError(t, err)
+
+	cases := []struct {
+		packageID string
+		expected  string
+	}{
+		{
+			packageID: "fs:" + filepath.Join(contextRoot, "policy"),
+			expected:  "fs:policy",
+		},
+		{
+			packageID: "fs:policy",
+			expected:  "fs:policy",
+		},
+		{
+			packageID: "builtin:pss/baseline",
+			expected:  "builtin:pss/baseline",
+		},
+		{
+			packageID: "",
+			expected:  "",
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, RelativePackageID(contextRoot, c.packageID), c.packageID)
+	}
+}
@@ -41,6 +41,47 @@ files:
 				)
 			},
 		},
+		// namespaces
+		{
+			content: `
+files:
+  - name: foo
+    paths:
+      - ./foo
+    policies:
+      - ./foo1
+    namespaces:
+      - kubernetes.pss.baseline
+      - main
+`,
+			validateSpec: func(t *testing.T, spec Spec) {
+				assert.Len(t, spec.Files, 1)
+				fileTarget := spec.Files[0]
+				assert.Equal(t, []string{"kubernetes.pss.baseline", "main"}, fileTarget.Namespaces)
+			},
+		},
+		// rules selection
+		{
+			content: `
+files:
+  - name: foo
+    paths:
+      - ./foo
+    policies:
+      - ./foo1
+    rules:
+      include:
+        - deny_*
+      exclude:
+        - deny_foo
+`,
+			validateSpec: func(t *testing.T, spec Spec) {
+				assert.Len(t, spec.Files, 1)
+				fileTarget := spec.Files[0]
+				assert.Equal(t, []string{"deny_*"}, fileTarget.Rules.Include)
+				assert.Equal(t, []string{"deny_foo"}, fileTarget.Rules.Exclude)
+			},
+		},
 		// policies using string map
 		{
 			content: `
@@ -10,6 +10,8 @@ import (
 // Spec defines the project specification.
 type Spec struct {
 	Files []FileTargetSpec `json:"files"`
+	// Waivers - path to the waivers file, which waives the results of the targets.
+	Waivers string `json:"waivers"`
 }
 
 // FileTargetSpec defines the specification of a file target.
@@ -24,7 +26,25 @@ type FileTargetSpec struct {
 	// Policies - paths to the policy to load.
 	Policies strListOrMap `json:"policies"`
 	// Data - paths to the (extra) data to load.
+	// Each entry is in the form of `[<key>=]<path>`. Without an explicit key, each file is mounted
+	// under `data.<file name>`. With an explicit key, a file is mounted under `data.<key>`,
+	// while files in a directory are mounted under `data.<key>.<file name>`.
 	Data []string `json:"data"`
+	// Namespaces - rego namespaces (e.g. `kubernetes.pss.baseline`) to query rules from.
+	// When specified, it overrides the namespaces declared by the policy packages.
+	Namespaces []string `json:"namespaces"`
+	// Rules - rules to include / exclude from the policies.
+	Rules RuleSelectorSpec `json:"rules"`
+}
+
+// RuleSelectorSpec defines the rules to select from the policies of a target.
+// Each pattern is matched against the rule query (`<kind>_<name>`, e.g. `deny_foo`), either exactly or by glob (e.g. `warn_*`).
+// Rules not selected are reported as skipped.
+type RuleSelectorSpec struct {
+	// Include - rules to query. Defaults to all rules.
+	Include []string `json:"include"`
+	// Exclude - rules to skip. It takes precedence over Include.
+	Exclude []string `json:"exclude"`
 }
 
 // strListOrMap is a helper type to support specifying string value using list or map (keys).
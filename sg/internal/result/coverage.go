@@ -0,0 +1,80 @@
+package result
+
+import (
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+)
+
+// LineCoverage specifies the count of covered and not covered policy lines.
+type LineCoverage struct {
+	// Covered is the number of lines evaluated by at least one query.
+	Covered int
+	// NotCovered is the number of lines never evaluated.
+	NotCovered int
+}
+
+// Add returns the sum of the line coverages.
+func (c LineCoverage) Add(other LineCoverage) LineCoverage {
+	return LineCoverage{
+		Covered:    c.Covered + other.Covered,
+		NotCovered: c.NotCovered + other.NotCovered,
+	}
+}
+
+// Total returns the total number of lines.
+func (c LineCoverage) Total() int {
+	return c.Covered + c.NotCovered
+}
+
+// Percentage returns the coverage in percentage. It returns 0 if there are no lines.
+func (c LineCoverage) Percentage() float64 {
+	if c.Total() == 0 {
+		return 0
+	}
+	return 100.0 * float64(c.Covered) / float64(c.Total())
+}
+
+// LineRange specifies a range of lines (1-based, inclusive).
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// RuleCoverage specifies the coverage of a policy rule.
+type RuleCoverage struct {
+	// Rule is the covered rule.
+	Rule policy.Rule
+	// Fired tells if the rule has produced results for at least one input.
+	Fired bool
+	// Lines is the line coverage of the rule definitions in the file.
+	Lines LineCoverage
+}
+
+// FileCoverage specifies the coverage of a policy file.
+type FileCoverage struct {
+	// File is the path of the policy file.
+	File string
+	// Lines is the line coverage of the file.
+	Lines LineCoverage
+	// NotCovered is the list of line ranges never evaluated.
+	NotCovered []LineRange
+	// Rules is the list of rules defined in the file, ordered by the definition.
+	Rules []RuleCoverage
+}
+
+// PackageCoverage specifies the coverage of a policy package.
+type PackageCoverage struct {
+	// Package is the qualified id of the policy package.
+	Package string
+	// Lines is the line coverage of the package.
+	Lines LineCoverage
+	// Files is the list of policy files in the package, ordered by the file path.
+	Files []FileCoverage
+}
+
+// CoverageReport specifies the coverage of the policy packages.
+type CoverageReport struct {
+	// Lines is the line coverage of all packages.
+	Lines LineCoverage
+	// Packages is the list of policy packages.
+	Packages []PackageCoverage
+}
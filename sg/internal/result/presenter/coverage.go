@@ -0,0 +1,173 @@
+package presenter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+)
+
+type lineRangeObj struct {
+	Start int `json:"start" yaml:"start"`
+	End   int `json:"end" yaml:"end"`
+}
+
+type ruleCoverageObj struct {
+	Name            string  `json:"name" yaml:"name"`
+	Kind            string  `json:"kind" yaml:"kind"`
+	Line            int     `json:"line,omitempty" yaml:"line,omitempty"`
+	Fired           bool    `json:"fired" yaml:"fired"`
+	CoveredLines    int     `json:"covered_lines" yaml:"covered_lines"`
+	NotCoveredLines int     `json:"not_covered_lines" yaml:"not_covered_lines"`
+	Coverage        float64 `json:"coverage" yaml:"coverage"`
+}
+
+type fileCoverageObj struct {
+	File            string            `json:"file" yaml:"file"`
+	CoveredLines    int               `json:"covered_lines" yaml:"covered_lines"`
+	NotCoveredLines int               `json:"not_covered_lines" yaml:"not_covered_lines"`
+	Coverage        float64           `json:"coverage" yaml:"coverage"`
+	NotCovered      []lineRangeObj    `json:"not_covered" yaml:"not_covered"`
+	Rules           []ruleCoverageObj `json:"rules" yaml:"rules"`
+}
+
+type packageCoverageObj struct {
+	Package         string            `json:"package" yaml:"package"`
+	CoveredLines    int               `json:"covered_lines" yaml:"covered_lines"`
+	NotCoveredLines int               `json:"not_covered_lines" yaml:"not_covered_lines"`
+	Coverage        float64           `json:"coverage" yaml:"coverage"`
+	Files           []fileCoverageObj `json:"files" yaml:"files"`
+}
+
+type coverageReportObj struct {
+	CoveredLines    int                  `json:"covered_lines" yaml:"covered_lines"`
+	NotCoveredLines int                  `json:"not_covered_lines" yaml:"not_covered_lines"`
+	Coverage        float64              `json:"coverage" yaml:"coverage"`
+	Packages        []packageCoverageObj `json:"packages" yaml:"packages"`
+}
+
+func asCoverageReportObj(report result.CoverageReport) coverageReportObj {
+	rv := coverageReportObj{
+		CoveredLines:    report.Lines.Covered,
+		NotCoveredLines: report.Lines.NotCovered,
+		Coverage:        report.Lines.Percentage(),
+		Packages:        make([]packageCoverageObj, 0, len(report.Packages)),
+	}
+
+	for _, p := range report.Packages {
+		packageObj := packageCoverageObj{
+			Package:         p.Package,
+			CoveredLines:    p.Lines.Covered,
+			NotCoveredLines: p.Lines.NotCovered,
+			Coverage:        p.Lines.Percentage(),
+			Files:           make([]fileCoverageObj, 0, len(p.Files)),
+		}
+		for _, f := range p.Files {
+			fileObj := fileCoverageObj{
+				File:            f.File,
+				CoveredLines:    f.Lines.Covered,
+				NotCoveredLines: f.Lines.NotCovered,
+				Coverage:        f.Lines.Percentage(),
+				NotCovered:      make([]lineRangeObj, 0, len(f.NotCovered)),
+				Rules:           make([]ruleCoverageObj, 0, len(f.Rules)),
+			}
+			for _, r := range f.NotCovered {
+				fileObj.NotCovered = append(fileObj.NotCovered, lineRangeObj{Start: r.Start, End: r.End})
+			}
+			for _, r := range f.Rules {
+				ruleObj := ruleCoverageObj{
+					Name:            r.Rule.Name,
+					Kind:            string(r.Rule.Kind),
+					Fired:           r.Fired,
+					CoveredLines:    r.Lines.Covered,
+					NotCoveredLines: r.Lines.NotCovered,
+					Coverage:        r.Lines.Percentage(),
+				}
+				if r.Rule.SourceLocation != nil {
+					ruleObj.Line = r.Rule.SourceLocation.Row
+				}
+				fileObj.Rules = append(fileObj.Rules, ruleObj)
+			}
+			packageObj.Files = append(packageObj.Files, fileObj)
+		}
+		rv.Packages = append(rv.Packages, packageObj)
+	}
+
+	return rv
+}
+
+// CoverageReport presents a policy coverage report.
+// Only JSON and text formats are supported, other formats default to JSON.
+func CoverageReport(format string, report result.CoverageReport) WriteQueryResultTo {
+	switch strings.ToLower(format) {
+	case FormatText:
+		return coverageReportText(report)
+	default:
+		return coverageReportJSON(report)
+	}
+}
+
+func coverageReportJSON(report result.CoverageReport) WriteQueryResultTo {
+	return writeQueryResultToFunc(func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(asCoverageReportObj(report))
+	})
+}
+
+func coverageReportText(report result.CoverageReport) WriteQueryResultTo {
+	return writeQueryResultToFunc(func(w io.Writer) error {
+		var lines []string
+
+		var totalRules, neverFiredRules int
+		for _, p := range report.Packages {
+			lines = append(lines, fmt.Sprintf("PACKAGE %s: %s", p.Package, lineCoverageString(p.Lines)))
+			for _, f := range p.Files {
+				line := fmt.Sprintf("  %s: %s", f.File, lineCoverageString(f.Lines))
+				if len(f.NotCovered) > 0 {
+					line += fmt.Sprintf(" (not covered: %s)", lineRangesString(f.NotCovered))
+				}
+				lines = append(lines, line)
+
+				for _, r := range f.Rules {
+					totalRules += 1
+					status := "fired"
+					if !r.Fired {
+						neverFiredRules += 1
+						status = "never fired"
+					}
+					lines = append(lines, fmt.Sprintf(
+						"    %s: %s, %s",
+						r.Rule.Query(), lineCoverageString(r.Lines), status,
+					))
+				}
+			}
+		}
+
+		lines = append(lines, fmt.Sprintf(
+			"coverage: %s, %d rule(s), %d never fired",
+			lineCoverageString(report.Lines), totalRules, neverFiredRules,
+		))
+
+		_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+		return err
+	})
+}
+
+func lineCoverageString(c result.LineCoverage) string {
+	return fmt.Sprintf("%.2f%% (%d/%d line(s))", c.Percentage(), c.Covered, c.Total())
+}
+
+func lineRangesString(ranges []result.LineRange) string {
+	rv := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		if r.Start == r.End {
+			rv = append(rv, fmt.Sprintf("%d", r.Start))
+		} else {
+			rv = append(rv, fmt.Sprintf("%d-%d", r.Start, r.End))
+		}
+	}
+	return strings.Join(rv, ", ")
+}
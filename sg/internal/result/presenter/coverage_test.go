@@ -0,0 +1,95 @@
+package presenter
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+)
+
+func testCoverageReport() result.CoverageReport {
+	return result.CoverageReport{
+		Lines: result.LineCoverage{Covered: 3, NotCovered: 3},
+		Packages: []result.PackageCoverage{
+			{
+				Package: "fs:policy",
+				Lines:   result.LineCoverage{Covered: 3, NotCovered: 3},
+				Files: []result.FileCoverage{
+					{
+						File:  "policy/001-foo.rego",
+						Lines: result.LineCoverage{Covered: 1, NotCovered: 3},
+						NotCovered: []result.LineRange{
+							{Start: 5, End: 5},
+							{Start: 8, End: 9},
+						},
+						Rules: []result.RuleCoverage{
+							{
+								Rule: policy.Rule{
+									Kind:           policy.QueryKindDeny,
+									Name:           "foo",
+									SourceLocation: &ast.Location{Row: 3},
+								},
+								Fired: true,
+								Lines: result.LineCoverage{Covered: 1, NotCovered: 1},
+							},
+							{
+								Rule: policy.Rule{
+									Kind:           policy.QueryKindWarn,
+									Name:           "bar",
+									SourceLocation: &ast.Location{Row: 8},
+								},
+								Lines: result.LineCoverage{NotCovered: 2},
+							},
+						},
+					},
+					{
+						File:  "policy/002-baz.rego",
+						Lines: result.LineCoverage{Covered: 2},
+					},
+				},
+			},
+		},
+	}
+}
+
+func Test_CoverageReport_Text(t *testing.T) {
+	output := new(bytes.Buffer)
+	assert.NoError(t, CoverageReport(FormatText, testCoverageReport()).WriteQueryResultTo(output))
+	t.Log("\n" + output.String())
+
+	assert.Equal(
+		t,
+		`PACKAGE fs:policy: 50.00% (3/6 line(s))
+  policy/001-foo.rego: 25.00% (1/4 line(s)) (not covered: 5, 8-9)
+    deny_foo: 50.00% (1/2 line(s)), fired
+    warn_bar: 0.00% (0/2 line(s)), never fired
+  policy/002-baz.rego: 100.00% (2/2 line(s))
+coverage: 50.00% (3/6 line(s)), 2 rule(s), 1 never fired
+`,
+		output.String(),
+	)
+}
+
+func Test_CoverageReport_JSON(t *testing.T) {
+	output := new(bytes.Buffer)
+	assert.NoError(t, CoverageReport(FormatJSON, testCoverageReport()).WriteQueryResultTo(output))
+
+	var parsed coverageReportObj
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	assert.Equal(t, 50.0, parsed.Coverage)
+	assert.Len(t, parsed.Packages, 1)
+	assert.Len(t, parsed.Packages[0].Files, 2)
+
+	fileObj := parsed.Packages[0].Files[0]
+	assert.Equal(t, "policy/001-foo.rego", fileObj.File)
+	assert.Equal(t, []lineRangeObj{{Start: 5, End: 5}, {Start: 8, End: 9}}, fileObj.NotCovered)
+	assert.Equal(t, []ruleCoverageObj{
+		{Name: "foo", Kind: "deny", Line: 3, Fired: true, CoveredLines: 1, NotCoveredLines: 1, Coverage: 50},
+		{Name: "bar", Kind: "warn", Line: 8, Fired: false, NotCoveredLines: 2, Coverage: 0},
+	}, fileObj.Rules)
+}
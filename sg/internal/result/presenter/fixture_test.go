@@ -13,6 +13,10 @@ func testQueryResults() []result.QueryResults {
 				return "file name"
 			}},
 			Successes: 2,
+			Passes: []result.Result{
+				{Rule: policy.Rule{Kind: policy.QueryKindDeny, Name: "004-rule"}},
+				{Rule: policy.Rule{Kind: policy.QueryKindWarn, Name: "004-rule"}},
+			},
 			Failures: []result.Result{
 				{
 					Message:     "fail message1",
@@ -71,3 +75,130 @@ func testQueryResults() []result.QueryResults {
 		},
 	}
 }
+
+func testQueryResultsWithErrors() []result.QueryResults {
+	return []result.QueryResults{
+		{
+			Source: &testsource.TestSource{NameFunc: func() string {
+				return "file name"
+			}},
+			Successes: 1,
+			Passes: []result.Result{
+				{Rule: policy.Rule{Kind: policy.QueryKindDeny, Name: "005-rule"}},
+			},
+			Errors: []result.Result{
+				{
+					Message:     "eval_conflict_error: complete rules must not produce multiple outputs",
+					RuleDocLink: "https://github.com/Azure/ShieldGuard/docs/004-rego.md",
+					Rule: policy.Rule{
+						Kind: policy.QueryKindDeny,
+						Name: "004-rule",
+					},
+					Location: result.Location{
+						File:     "file name",
+						Document: 1,
+					},
+				},
+			},
+		},
+	}
+}
+
+func testQueryResultsWithExplanation() []result.QueryResults {
+	return []result.QueryResults{
+		{
+			Source: &testsource.TestSource{NameFunc: func() string {
+				return "file name"
+			}},
+			Failures: []result.Result{
+				{
+					Message:     "name cannot be foo",
+					RuleDocLink: "https://github.com/Azure/ShieldGuard/docs/001-rego.md",
+					Rule: policy.Rule{
+						Kind: policy.QueryKindDeny,
+						Name: "001-rule",
+					},
+					Explanation: &result.Explanation{
+						Steps: []result.ExplanationStep{
+							{
+								File:       "policy/001-rule.rego",
+								Line:       4,
+								Expression: `input.metadata.name == "foo"`,
+								Input: map[string]interface{}{
+									"input.metadata.name": "foo",
+								},
+							},
+							{
+								File:       "policy/001-rule.rego",
+								Line:       6,
+								Expression: `msg := "name cannot be foo"`,
+								Bindings: map[string]interface{}{
+									"msg": "name cannot be foo",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func testQueryResultsWithAnnotations() []result.QueryResults {
+	return []result.QueryResults{
+		{
+			Source: &testsource.TestSource{NameFunc: func() string {
+				return "file name"
+			}},
+			Failures: []result.Result{
+				{
+					Message:     "image app:latest uses latest tag",
+					RuleDocLink: "https://github.com/Azure/ShieldGuard/docs/001-rego.md",
+					Rule: policy.Rule{
+						Kind: policy.QueryKindDeny,
+						Name: "latest_tag",
+						Annotations: &policy.Annotations{
+							Title:       "No latest tag",
+							Description: "Container images must be pinned to a version.",
+							RelatedResources: []policy.RelatedResource{
+								{Ref: "https://github.com/Azure/ShieldGuard/docs/001-rego.md"},
+								{Ref: "https://kubernetes.io/docs/concepts/containers/images/", Description: "Images"},
+							},
+							Custom: map[string]interface{}{
+								"severity": "high",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func testQueryResultsWithSeverity() []result.QueryResults {
+	return []result.QueryResults{
+		{
+			Source: &testsource.TestSource{NameFunc: func() string {
+				return "file name"
+			}},
+			Failures: []result.Result{
+				{
+					Message:  "fail message1",
+					Rule:     policy.Rule{Kind: policy.QueryKindDeny, Name: "001-rule"},
+					Severity: policy.SeverityHigh,
+				},
+				{
+					Message: "fail message2",
+					Rule:    policy.Rule{Kind: policy.QueryKindDeny, Name: "002-rule"},
+				},
+			},
+			Warnings: []result.Result{
+				{
+					Message:  "warn message1",
+					Rule:     policy.Rule{Kind: policy.QueryKindWarn, Name: "003-rule"},
+					Severity: policy.SeverityLow,
+				},
+			},
+		},
+	}
+}
@@ -7,14 +7,18 @@ import (
 )
 
 const (
-	FormatJSON = "json"
-	FormatText = "text"
+	FormatJSON  = "json"
+	FormatText  = "text"
+	FormatSARIF = "sarif"
+	FormatJUnit = "junit"
 )
 
 // AvailableFormats book-keeps the available formats.
 var AvailableFormats = map[string]struct{}{
-	FormatJSON: {},
-	FormatText: {},
+	FormatJSON:  {},
+	FormatText:  {},
+	FormatSARIF: {},
+	FormatJUnit: {},
 }
 
 // AvailableFormatsHelp returns help message for available formats.
@@ -36,6 +40,10 @@ func QueryResultsList(
 		return JSON(queryResultsList)
 	case FormatText:
 		return Text(queryResultsList)
+	case FormatSARIF:
+		return SARIF(queryResultsList)
+	case FormatJUnit:
+		return JUnit(queryResultsList)
 	default:
 		// defaults to JSON
 		return JSON(queryResultsList)
@@ -2,8 +2,11 @@ package presenter
 
 import (
 	"bytes"
+	"encoding/json"
 	"testing"
 
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -65,7 +68,8 @@ func Test_JSON(t *testing.T) {
 				  },
 				  "message": ""
 				}
-			  ]
+			  ],
+			  "errors": []
 			},
 			{
 			  "filename": "",
@@ -73,9 +77,197 @@ func Test_JSON(t *testing.T) {
 			  "success": 0,
 			  "failures": [],
 			  "warnings": [],
-			  "exceptions": []
+			  "exceptions": [],
+			  "errors": []
 			}
 		  ]`,
 		output.String(),
 	)
 }
+
+func Test_JSON_compilerKey(t *testing.T) {
+	queryResultsList := testQueryResults()
+	queryResultsList[0].CompilerKey = "compiler-key"
+
+	presenter := JSON(queryResultsList)
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	assert.Equal(t, "compiler-key", parsed[0]["compiler_key"])
+	assert.NotContains(t, parsed[1], "compiler_key", "should omit empty compiler key")
+}
+
+func Test_JSON_errors(t *testing.T) {
+	presenter := JSON(testQueryResultsWithErrors())
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed []queryResultsObj
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	assert.Len(t, parsed, 1)
+	assert.Len(t, parsed[0].Errors, 1)
+	assert.Equal(t, "004-rule", parsed[0].Errors[0].Rule.Name)
+	assert.Equal(
+		t,
+		"eval_conflict_error: complete rules must not produce multiple outputs",
+		parsed[0].Errors[0].Message,
+	)
+}
+
+func Test_JSON_explanation(t *testing.T) {
+	presenter := JSON(testQueryResultsWithExplanation())
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed []queryResultsObj
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	assert.Len(t, parsed, 1)
+	assert.Len(t, parsed[0].Failures, 1)
+
+	explanation := parsed[0].Failures[0].Explanation
+	if assert.NotNil(t, explanation) {
+		assert.Len(t, explanation.Steps, 2)
+		assert.Equal(t, "policy/001-rule.rego", explanation.Steps[0].File)
+		assert.Equal(t, 4, explanation.Steps[0].Line)
+		assert.Equal(t, map[string]interface{}{"input.metadata.name": "foo"}, explanation.Steps[0].Input)
+		assert.Equal(t, map[string]interface{}{"msg": "name cannot be foo"}, explanation.Steps[1].Bindings)
+	}
+
+	output.Reset()
+	assert.NoError(t, JSON(testQueryResults()).WriteQueryResultTo(output))
+	assert.NotContains(t, output.String(), "explanation", "should omit explanation when not explained")
+}
+
+func Test_JSON_annotations(t *testing.T) {
+	presenter := JSON(testQueryResultsWithAnnotations())
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed []queryResultsObj
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	assert.Len(t, parsed, 1)
+	assert.Len(t, parsed[0].Failures, 1)
+	assert.Equal(
+		t,
+		policyRuleObj{
+			Name:        "latest_tag",
+			DocLink:     "https://github.com/Azure/ShieldGuard/docs/001-rego.md",
+			Title:       "No latest tag",
+			Description: "Container images must be pinned to a version.",
+			RelatedResources: []relatedResourceObj{
+				{Ref: "https://github.com/Azure/ShieldGuard/docs/001-rego.md"},
+				{Ref: "https://kubernetes.io/docs/concepts/containers/images/", Description: "Images"},
+			},
+			Custom: map[string]interface{}{
+				"severity": "high",
+			},
+		},
+		parsed[0].Failures[0].Rule,
+	)
+}
+
+func Test_JSON_severity(t *testing.T) {
+	presenter := JSON(testQueryResultsWithSeverity())
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed []queryResultsObj
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	assert.Len(t, parsed, 1)
+	assert.Len(t, parsed[0].Failures, 2)
+	assert.Equal(t, "high", parsed[0].Failures[0].Severity)
+	assert.Empty(t, parsed[0].Failures[1].Severity)
+	assert.Len(t, parsed[0].Warnings, 1)
+	assert.Equal(t, "low", parsed[0].Warnings[0].Severity)
+
+	output.Reset()
+	assert.NoError(t, JSON(testQueryResults()).WriteQueryResultTo(output))
+	assert.NotContains(t, output.String(), "severity", "should omit severity when not specified")
+}
+
+func Test_JSON_namespaces(t *testing.T) {
+	queryResultsList := testQueryResults()
+	for _, results := range [][]result.Result{
+		queryResultsList[0].Failures, queryResultsList[0].Warnings, queryResultsList[0].Exceptions,
+	} {
+		for idx := range results {
+			results[idx].Rule.Namespace = "main"
+		}
+	}
+	queryResultsList[0].Failures[1].Rule.Namespace = "kubernetes.pss.baseline"
+	queryResultsList[0].SuccessesByNamespace = map[string]int{"kubernetes.pss.baseline": 2}
+	for idx := range queryResultsList[0].Passes {
+		queryResultsList[0].Passes[idx].Rule.Namespace = "kubernetes.pss.baseline"
+	}
+
+	presenter := JSON(queryResultsList)
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed []queryResultsObj
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	assert.Len(t, parsed, 3, "should report results per namespace")
+
+	assert.Equal(t, "file name", parsed[0].Filename)
+	assert.Equal(t, "kubernetes.pss.baseline", parsed[0].Namespace)
+	assert.Equal(t, 2, parsed[0].Success)
+	assert.Len(t, parsed[0].Failures, 1)
+	assert.Equal(t, "002-rule", parsed[0].Failures[0].Rule.Name)
+
+	assert.Equal(t, "file name", parsed[1].Filename)
+	assert.Equal(t, "main", parsed[1].Namespace)
+	assert.Equal(t, 0, parsed[1].Success)
+	assert.Len(t, parsed[1].Failures, 1)
+	assert.Len(t, parsed[1].Warnings, 2)
+	assert.Len(t, parsed[1].Exceptions, 1)
+
+	assert.Equal(t, "main", parsed[2].Namespace)
+}
+
+func Test_JSON_package(t *testing.T) {
+	queryResultsList := testQueryResults()
+	queryResultsList[0].Failures[0].Package = "builtin:pss/baseline"
+
+	presenter := JSON(queryResultsList)
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed []queryResultsObj
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	assert.Equal(t, "builtin:pss/baseline", parsed[0].Failures[0].Package)
+	assert.Empty(t, parsed[0].Failures[1].Package)
+}
+
+func Test_JSON_skipped(t *testing.T) {
+	queryResultsList := testQueryResults()
+	queryResultsList[0].Skipped = []result.Result{
+		{Query: "data.main.warn_005-rule", Rule: policy.Rule{Kind: policy.QueryKindWarn, Name: "005-rule"}},
+	}
+
+	presenter := JSON(queryResultsList)
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	assert.Len(t, parsed[0]["skipped"], 1)
+	assert.NotContains(t, parsed[1], "skipped", "should omit empty skipped")
+}
+
+func Test_JSON_baselined(t *testing.T) {
+	queryResultsList := testQueryResults()
+	queryResultsList[0].Baselined = []result.Result{
+		{Query: "data.main.deny_005-rule", Rule: policy.Rule{Kind: policy.QueryKindDeny, Name: "005-rule"}},
+	}
+
+	presenter := JSON(queryResultsList)
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	assert.Len(t, parsed[0]["baselined"], 1)
+	assert.NotContains(t, parsed[1], "baselined", "should omit empty baselined")
+}
@@ -0,0 +1,200 @@
+package presenter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+)
+
+// JUnit XML schema: https://github.com/testmoapp/junitxml
+const (
+	junitTestSuitesName = "ShieldGuard"
+
+	// junitErrorTypeEvaluation is the error type for rules failed to evaluate.
+	junitErrorTypeEvaluation = "evaluation"
+
+	// junitPropertyCompilerKey is the test suite property for recording the compiler key which produced the results.
+	junitPropertyCompilerKey = "compiler_key"
+	// junitPropertySkippedRule is the test suite property for recording the rule skipped by rule selection.
+	// Skipped rules are not evaluated, so they are not counted as tests, which is consistent with the text output.
+	junitPropertySkippedRule = "skipped_rule"
+)
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",chardata"`
+}
+
+type junitError struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitError   `xml:"error,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	TestCases  []junitTestCase  `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+func junitResultDetails(r result.Result) string {
+	lines := []string{r.Message}
+	if r.RuleDocLink != "" {
+		lines = append(lines, fmt.Sprintf("Document: %s", r.RuleDocLink))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// junitClassName returns the class name of the test case of the result, which is the rego namespace of the rule.
+// It falls back to the file name for results without namespace.
+func junitClassName(filename string, r result.Result) string {
+	if r.Rule.Namespace != "" {
+		return r.Rule.Namespace
+	}
+	return filename
+}
+
+func asJUnitTestSuite(queryResults result.QueryResults) junitTestSuite {
+	filename := queryResults.Source.Name()
+
+	rv := junitTestSuite{
+		Name:     filename,
+		Failures: len(queryResults.Failures),
+		Skipped:  len(queryResults.Exceptions) + len(queryResults.Baselined),
+		Errors:   len(queryResults.Errors),
+	}
+	var properties []junitProperty
+	if queryResults.CompilerKey != "" {
+		properties = append(properties, junitProperty{Name: junitPropertyCompilerKey, Value: queryResults.CompilerKey})
+	}
+	for _, r := range queryResults.Skipped {
+		properties = append(properties, junitProperty{Name: junitPropertySkippedRule, Value: r.Query})
+	}
+	if len(properties) > 0 {
+		rv.Properties = &junitProperties{Properties: properties}
+	}
+
+	for _, r := range queryResults.Failures {
+		rv.TestCases = append(rv.TestCases, junitTestCase{
+			Name:      r.Rule.Query(),
+			ClassName: junitClassName(filename, r),
+			Failure: &junitFailure{
+				Message:  r.Message,
+				Type:     string(r.Rule.Kind),
+				Contents: junitResultDetails(r),
+			},
+		})
+	}
+	for _, r := range queryResults.Errors {
+		rv.TestCases = append(rv.TestCases, junitTestCase{
+			Name:      r.Rule.Query(),
+			ClassName: junitClassName(filename, r),
+			Error: &junitError{
+				Message:  r.Message,
+				Type:     junitErrorTypeEvaluation,
+				Contents: junitResultDetails(r),
+			},
+		})
+	}
+	for _, r := range queryResults.Warnings {
+		rv.TestCases = append(rv.TestCases, junitTestCase{
+			Name:      r.Rule.Query(),
+			ClassName: junitClassName(filename, r),
+			SystemOut: junitResultDetails(r),
+		})
+	}
+	for _, r := range queryResults.Exceptions {
+		rv.TestCases = append(rv.TestCases, junitTestCase{
+			Name:      r.Rule.Query(),
+			ClassName: junitClassName(filename, r),
+			Skipped:   &junitSkipped{Message: "excepted by policy exception"},
+		})
+	}
+	for _, r := range queryResults.Baselined {
+		rv.TestCases = append(rv.TestCases, junitTestCase{
+			Name:      r.Rule.Query(),
+			ClassName: junitClassName(filename, r),
+			Skipped:   &junitSkipped{Message: "recorded in baseline"},
+		})
+	}
+	// NOTE: the engine reports one passed result per success (including the duplicated rules),
+	//       so the totals are consistent with other presenters.
+	for _, r := range queryResults.Passes {
+		rv.TestCases = append(rv.TestCases, junitTestCase{
+			Name:      r.Rule.Query(),
+			ClassName: junitClassName(filename, r),
+		})
+	}
+
+	rv.Tests = len(rv.TestCases)
+
+	return rv
+}
+
+// JUnit creates a new JUnit XML presenter.
+func JUnit(queryResultsList []result.QueryResults) WriteQueryResultTo {
+	testSuites := junitTestSuites{
+		Name: junitTestSuitesName,
+	}
+	for _, queryResults := range queryResultsList {
+		testSuite := asJUnitTestSuite(queryResults)
+		testSuites.Tests += testSuite.Tests
+		testSuites.Failures += testSuite.Failures
+		testSuites.Skipped += testSuite.Skipped
+		testSuites.Errors += testSuite.Errors
+		testSuites.TestSuites = append(testSuites.TestSuites, testSuite)
+	}
+
+	return writeQueryResultToFunc(func(w io.Writer) error {
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		encoder := xml.NewEncoder(w)
+		encoder.Indent("", "  ")
+		if err := encoder.Encode(testSuites); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	})
+}
@@ -0,0 +1,205 @@
+package presenter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_JUnit(t *testing.T) {
+	presenter := JUnit(testQueryResults())
+	output := new(bytes.Buffer)
+	err := presenter.WriteQueryResultTo(output)
+	assert.NoError(t, err)
+	t.Log("\n" + output.String())
+	assert.Equal(
+		t,
+		`<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="ShieldGuard" tests="7" failures="2" errors="0" skipped="1">
+  <testsuite name="file name" tests="7" failures="2" errors="0" skipped="1">
+    <testcase name="deny_001-rule" classname="file name">
+      <failure message="fail message1" type="deny">fail message1&#xA;Document: https://github.com/Azure/ShieldGuard/docs/001-rego.md</failure>
+    </testcase>
+    <testcase name="deny_002-rule" classname="file name">
+      <failure message="fail message2" type="deny">fail message2&#xA;Document: https://github.com/Azure/ShieldGuard/docs/002-rego.md</failure>
+    </testcase>
+    <testcase name="warn_001-rule" classname="file name">
+      <system-out>warn message1&#xA;Document: https://github.com/Azure/ShieldGuard/docs/001-rego.md</system-out>
+    </testcase>
+    <testcase name="warn_002-rule" classname="file name">
+      <system-out>warn message2&#xA;Document: https://github.com/Azure/ShieldGuard/docs/002-rego.md</system-out>
+    </testcase>
+    <testcase name="exception_003-rule" classname="file name">
+      <skipped message="excepted by policy exception"></skipped>
+    </testcase>
+    <testcase name="deny_004-rule" classname="file name"></testcase>
+    <testcase name="warn_004-rule" classname="file name"></testcase>
+  </testsuite>
+  <testsuite name="" tests="0" failures="0" errors="0" skipped="0"></testsuite>
+</testsuites>
+`,
+		output.String(),
+	)
+
+	t.Run("valid xml", func(t *testing.T) {
+		var parsed junitTestSuites
+		assert.NoError(t, xml.Unmarshal(output.Bytes(), &parsed))
+		// should match the text presenter summary: 7 test(s), 2 passed, 2 failure(s) 2 warning(s), 1 exception(s)
+		assert.Equal(t, 7, parsed.Tests)
+		assert.Equal(t, 2, parsed.Failures)
+		assert.Equal(t, 1, parsed.Skipped)
+	})
+}
+
+func Test_JUnit_compilerKey(t *testing.T) {
+	queryResultsList := testQueryResults()
+	queryResultsList[0].CompilerKey = "compiler-key"
+
+	presenter := JUnit(queryResultsList)
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed junitTestSuites
+	assert.NoError(t, xml.Unmarshal(output.Bytes(), &parsed))
+	assert.Equal(
+		t,
+		&junitProperties{
+			Properties: []junitProperty{{Name: "compiler_key", Value: "compiler-key"}},
+		},
+		parsed.TestSuites[0].Properties,
+	)
+	assert.Nil(t, parsed.TestSuites[1].Properties, "should omit empty compiler key")
+}
+
+func Test_JUnit_errors(t *testing.T) {
+	presenter := JUnit(testQueryResultsWithErrors())
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed junitTestSuites
+	assert.NoError(t, xml.Unmarshal(output.Bytes(), &parsed))
+	assert.Equal(t, 1, parsed.Errors)
+	assert.Equal(t, 2, parsed.Tests)
+	assert.Equal(t, 1, parsed.TestSuites[0].Errors)
+
+	testCase := parsed.TestSuites[0].TestCases[0]
+	assert.Equal(t, "deny_004-rule", testCase.Name)
+	if assert.NotNil(t, testCase.Error) {
+		assert.Equal(t, "evaluation", testCase.Error.Type)
+		assert.Equal(
+			t,
+			"eval_conflict_error: complete rules must not produce multiple outputs",
+			testCase.Error.Message,
+		)
+	}
+}
+
+func Test_JUnit_skipped(t *testing.T) {
+	queryResultsList := testQueryResults()
+	queryResultsList[0].Skipped = []result.Result{
+		{Query: "data.main.warn_005-rule", Rule: policy.Rule{Kind: policy.QueryKindWarn, Name: "005-rule"}},
+	}
+
+	presenter := JUnit(queryResultsList)
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed junitTestSuites
+	assert.NoError(t, xml.Unmarshal(output.Bytes(), &parsed))
+	assert.Equal(t, 1, parsed.Skipped, "skipped rules should not be counted as skipped tests")
+	assert.Equal(t, 1, parsed.TestSuites[0].Skipped)
+	assert.Equal(t, 7, parsed.TestSuites[0].Tests, "skipped rules should not be counted as tests")
+
+	for _, testCase := range parsed.TestSuites[0].TestCases {
+		assert.NotEqual(t, "warn_005-rule", testCase.Name)
+	}
+	if assert.NotNil(t, parsed.TestSuites[0].Properties) {
+		assert.Equal(
+			t,
+			[]junitProperty{{Name: "skipped_rule", Value: "data.main.warn_005-rule"}},
+			parsed.TestSuites[0].Properties.Properties,
+		)
+	}
+}
+
+func Test_JUnit_skipped_textTotals(t *testing.T) {
+	t.Setenv("CI_NAME", "CUSTOM")
+
+	queryResultsList := testQueryResults()
+	queryResultsList[0].Skipped = []result.Result{
+		{Query: "data.main.deny_005-rule", Rule: policy.Rule{Kind: policy.QueryKindDeny, Name: "005-rule"}},
+		{Query: "data.main.warn_005-rule", Rule: policy.Rule{Kind: policy.QueryKindWarn, Name: "005-rule"}},
+	}
+
+	junitOutput := new(bytes.Buffer)
+	assert.NoError(t, JUnit(queryResultsList).WriteQueryResultTo(junitOutput))
+	var parsed junitTestSuites
+	assert.NoError(t, xml.Unmarshal(junitOutput.Bytes(), &parsed))
+
+	textOutput := new(bytes.Buffer)
+	assert.NoError(t, Text(queryResultsList).WriteQueryResultTo(textOutput))
+	matches := regexp.MustCompile(`(?m)^(\d+) test\(s\)`).FindStringSubmatch(textOutput.String())
+	if assert.Len(t, matches, 2, "text output should contain the summary") {
+		assert.Equal(t, matches[1], strconv.Itoa(parsed.Tests), "JUnit tests should match the text summary")
+	}
+}
+
+func Test_JUnit_baselined(t *testing.T) {
+	queryResultsList := testQueryResults()
+	queryResultsList[0].Baselined = []result.Result{
+		{Rule: policy.Rule{Kind: policy.QueryKindDeny, Name: "005-rule"}},
+	}
+
+	presenter := JUnit(queryResultsList)
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed junitTestSuites
+	assert.NoError(t, xml.Unmarshal(output.Bytes(), &parsed))
+	assert.Equal(t, 2, parsed.Skipped, "exceptions and baselined results")
+
+	var baselined []junitTestCase
+	for _, testCase := range parsed.TestSuites[0].TestCases {
+		if testCase.Name == "deny_005-rule" {
+			baselined = append(baselined, testCase)
+		}
+	}
+	if assert.Len(t, baselined, 1) && assert.NotNil(t, baselined[0].Skipped) {
+		assert.Equal(t, "recorded in baseline", baselined[0].Skipped.Message)
+	}
+}
+
+func Test_JUnit_passes(t *testing.T) {
+	queryResultsList := []result.QueryResults{
+		{
+			Source:    testQueryResults()[0].Source,
+			Successes: 2,
+			Passes: []result.Result{
+				{Rule: policy.Rule{Kind: policy.QueryKindDeny, Name: "privileged", Namespace: "kubernetes.pss.baseline"}},
+				{Rule: policy.Rule{Kind: policy.QueryKindWarn, Name: "latest_tag", Namespace: "main"}},
+			},
+		},
+	}
+
+	presenter := JUnit(queryResultsList)
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed junitTestSuites
+	assert.NoError(t, xml.Unmarshal(output.Bytes(), &parsed))
+	assert.Equal(t, 2, parsed.Tests)
+	assert.Equal(
+		t,
+		[]junitTestCase{
+			{Name: "deny_privileged", ClassName: "kubernetes.pss.baseline"},
+			{Name: "warn_latest_tag", ClassName: "main"},
+		},
+		parsed.TestSuites[0].TestCases,
+	)
+}
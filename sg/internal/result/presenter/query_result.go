@@ -1,60 +1,237 @@
 package presenter
 
 import (
-	"github.com/Azure/ShieldGuard/sg/internal/engine"
+	"fmt"
+
 	"github.com/Azure/ShieldGuard/sg/internal/policy"
 	"github.com/Azure/ShieldGuard/sg/internal/result"
 	"github.com/Azure/ShieldGuard/sg/internal/utils"
 )
 
+type relatedResourceObj struct {
+	Ref         string `json:"ref" yaml:"ref"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
 type policyRuleObj struct {
-	Name    string `json:"name" yaml:"name"`
-	DocLink string `json:"doc_link,omitempty" yaml:"doc_link,omitempty"`
+	Name             string                 `json:"name" yaml:"name"`
+	DocLink          string                 `json:"doc_link,omitempty" yaml:"doc_link,omitempty"`
+	Title            string                 `json:"title,omitempty" yaml:"title,omitempty"`
+	Description      string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	RelatedResources []relatedResourceObj   `json:"related_resources,omitempty" yaml:"related_resources,omitempty"`
+	Custom           map[string]interface{} `json:"custom,omitempty" yaml:"custom,omitempty"`
 }
 
 func asPolicyRuleObj(rule policy.Rule, docLink string) policyRuleObj {
-	return policyRuleObj{
+	rv := policyRuleObj{
 		Name:    rule.Name,
 		DocLink: docLink,
 	}
+
+	if annotations := rule.Annotations; annotations != nil {
+		rv.Title = annotations.Title
+		rv.Description = annotations.Description
+		rv.RelatedResources = utils.Map(annotations.RelatedResources, func(r policy.RelatedResource) relatedResourceObj {
+			return relatedResourceObj{
+				Ref:         r.Ref,
+				Description: r.Description,
+			}
+		})
+		rv.Custom = annotations.Custom
+	}
+
+	return rv
+}
+
+type locationObj struct {
+	File     string `json:"file" yaml:"file"`
+	Document int    `json:"document" yaml:"document"`
+	Line     int    `json:"line,omitempty" yaml:"line,omitempty"`
+	Column   int    `json:"column,omitempty" yaml:"column,omitempty"`
+	Identity string `json:"identity,omitempty" yaml:"identity,omitempty"`
+}
+
+func asLocationObj(location result.Location) *locationObj {
+	if location.File == "" {
+		// not set
+		return nil
+	}
+
+	return &locationObj{
+		File:     location.File,
+		Document: location.Document,
+		Line:     location.Line,
+		Column:   location.Column,
+		Identity: location.Identity,
+	}
+}
+
+// String returns the location in `file[:line:col] [#<document> <identity>]` form.
+// The document part is omitted for the first document without identity.
+func (o *locationObj) String() string {
+	rv := result.Location{
+		File:     o.File,
+		Document: o.Document,
+		Line:     o.Line,
+		Column:   o.Column,
+	}.String()
+
+	switch {
+	case o.Identity != "":
+		rv += fmt.Sprintf(" [#%d %s]", o.Document, o.Identity)
+	case o.Document > 0:
+		rv += fmt.Sprintf(" [#%d]", o.Document)
+	}
+
+	return rv
+}
+
+type explanationStepObj struct {
+	File       string                 `json:"file" yaml:"file"`
+	Line       int                    `json:"line" yaml:"line"`
+	Expression string                 `json:"expression" yaml:"expression"`
+	Bindings   map[string]interface{} `json:"bindings,omitempty" yaml:"bindings,omitempty"`
+	Input      map[string]interface{} `json:"input,omitempty" yaml:"input,omitempty"`
+}
+
+type waiverObj struct {
+	Owner   string `json:"owner" yaml:"owner"`
+	Reason  string `json:"reason" yaml:"reason"`
+	Expires string `json:"expires" yaml:"expires"`
+	Expired bool   `json:"expired,omitempty" yaml:"expired,omitempty"`
+}
+
+func asWaiverObj(waiver *result.Waiver) *waiverObj {
+	if waiver == nil {
+		// not waived
+		return nil
+	}
+
+	return &waiverObj{
+		Owner:   waiver.Owner,
+		Reason:  waiver.Reason,
+		Expires: waiver.Expires,
+		Expired: waiver.Expired,
+	}
+}
+
+type explanationObj struct {
+	Steps []explanationStepObj `json:"steps" yaml:"steps"`
+}
+
+func asExplanationObj(explanation *result.Explanation) *explanationObj {
+	if explanation == nil {
+		// not explained
+		return nil
+	}
+
+	return &explanationObj{
+		Steps: utils.Map(explanation.Steps, func(step result.ExplanationStep) explanationStepObj {
+			return explanationStepObj{
+				File:       step.File,
+				Line:       step.Line,
+				Expression: step.Expression,
+				Bindings:   step.Bindings,
+				Input:      step.Input,
+			}
+		}),
+	}
 }
 
 type resultObj struct {
-	Query    string                 `json:"query" yaml:"query"`
-	Rule     policyRuleObj          `json:"rule" yaml:"rule"`
-	Message  string                 `json:"message" yaml:"message"`
-	Metadata map[string]interface{} `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Query       string                 `json:"query" yaml:"query"`
+	Rule        policyRuleObj          `json:"rule" yaml:"rule"`
+	Message     string                 `json:"message" yaml:"message"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Location    *locationObj           `json:"location,omitempty" yaml:"location,omitempty"`
+	Package     string                 `json:"package,omitempty" yaml:"package,omitempty"`
+	Severity    string                 `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Waiver      *waiverObj             `json:"waiver,omitempty" yaml:"waiver,omitempty"`
+	Explanation *explanationObj        `json:"explanation,omitempty" yaml:"explanation,omitempty"`
 }
 
 func asResultObj(result result.Result) resultObj {
 	return resultObj{
-		Query:    result.Query,
-		Rule:     asPolicyRuleObj(result.Rule, result.RuleDocLink),
-		Message:  result.Message,
-		Metadata: result.Metadata,
+		Query:       result.Query,
+		Rule:        asPolicyRuleObj(result.Rule, result.RuleDocLink),
+		Message:     result.Message,
+		Metadata:    result.Metadata,
+		Location:    asLocationObj(result.Location),
+		Package:     result.Package,
+		Severity:    string(result.Severity),
+		Waiver:      asWaiverObj(result.Waiver),
+		Explanation: asExplanationObj(result.Explanation),
+	}
+}
+
+// locationString returns the location of the result, or the fallback filename if not set.
+func (o resultObj) locationString(filename string) string {
+	if o.Location == nil {
+		return filename
+	}
+	return o.Location.String()
+}
+
+type documentObj struct {
+	Index    int    `json:"index" yaml:"index"`
+	Identity string `json:"identity,omitempty" yaml:"identity,omitempty"`
+}
+
+func asDocumentObj(document result.Document) documentObj {
+	return documentObj{
+		Index:    document.Index,
+		Identity: document.Identity,
 	}
 }
 
 type queryResultsObj struct {
-	Filename   string      `json:"filename" yaml:"filename"`
-	Namespace  string      `json:"namespace" yaml:"namespace"`
-	Success    int         `json:"success" yaml:"success"`
-	Failures   []resultObj `json:"failures" yaml:"failures"`
-	Warnings   []resultObj `json:"warnings" yaml:"warnings"`
-	Exceptions []resultObj `json:"exceptions" yaml:"exceptions"`
+	Filename    string        `json:"filename" yaml:"filename"`
+	Namespace   string        `json:"namespace" yaml:"namespace"`
+	CompilerKey string        `json:"compiler_key,omitempty" yaml:"compiler_key,omitempty"`
+	Documents   []documentObj `json:"documents,omitempty" yaml:"documents,omitempty"`
+	Success     int           `json:"success" yaml:"success"`
+	Failures    []resultObj   `json:"failures" yaml:"failures"`
+	Warnings    []resultObj   `json:"warnings" yaml:"warnings"`
+	Exceptions  []resultObj   `json:"exceptions" yaml:"exceptions"`
+	Skipped     []resultObj   `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+	Baselined   []resultObj   `json:"baselined,omitempty" yaml:"baselined,omitempty"`
+	Errors      []resultObj   `json:"errors" yaml:"errors"`
 }
 
 func asQueryResultsObj(queryResult result.QueryResults) queryResultsObj {
+	namespace := queryResult.Namespace
+	if namespace == "" {
+		namespace = policy.NamespaceMain
+	}
+
 	return queryResultsObj{
-		Filename:   queryResult.Source.Name(),
-		Namespace:  engine.PackageMain,
-		Success:    queryResult.Successes,
-		Failures:   utils.Map(queryResult.Failures, asResultObj),
-		Warnings:   utils.Map(queryResult.Warnings, asResultObj),
-		Exceptions: utils.Map(queryResult.Exceptions, asResultObj),
+		Filename:    queryResult.Source.Name(),
+		Namespace:   namespace,
+		CompilerKey: queryResult.CompilerKey,
+		Documents:   utils.Map(queryResult.Documents, asDocumentObj),
+		Success:     queryResult.Successes,
+		Failures:    utils.Map(queryResult.Failures, asResultObj),
+		Warnings:    utils.Map(queryResult.Warnings, asResultObj),
+		Exceptions:  utils.Map(queryResult.Exceptions, asResultObj),
+		Skipped:     asResultObjList(queryResult.Skipped),
+		Baselined:   asResultObjList(queryResult.Baselined),
+		Errors:      utils.Map(queryResult.Errors, asResultObj),
+	}
+}
+
+// asResultObjList converts the results, returns nil for empty results.
+func asResultObjList(results []result.Result) []resultObj {
+	if len(results) < 1 {
+		return nil
 	}
+	return utils.Map(results, asResultObj)
 }
 
 func asQueryResultsObjList(queryResultsList []result.QueryResults) []queryResultsObj {
-	return utils.Map(queryResultsList, asQueryResultsObj)
+	rv := make([]queryResultsObj, 0, len(queryResultsList))
+	for _, queryResults := range queryResultsList {
+		// NOTE: results are reported per rego namespace
+		rv = append(rv, utils.Map(queryResults.ByNamespace(), asQueryResultsObj)...)
+	}
+	return rv
 }
@@ -0,0 +1,373 @@
+package presenter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+)
+
+// SARIF 2.1.0 specification: https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	sarifToolName           = "ShieldGuard"
+	sarifToolInformationURI = "https://github.com/Azure/ShieldGuard"
+
+	sarifLevelError   = "error"
+	sarifLevelWarning = "warning"
+	sarifLevelNote    = "note"
+
+	sarifSuppressionKindExternal = "external"
+
+	sarifBaselineStateUnchanged = "unchanged"
+
+	sarifLogicalLocationKindResource = "resource"
+
+	// sarifPropertyCompilerKeys is the run property for recording the compiler keys which produced the results.
+	sarifPropertyCompilerKeys = "compilerKeys"
+	// sarifPropertyPackage is the result and rule property for recording the policy package of the rule.
+	sarifPropertyPackage = "package"
+)
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID        string                 `json:"ruleId"`
+	RuleIndex     int                    `json:"ruleIndex"`
+	Level         string                 `json:"level"`
+	Message       sarifMessage           `json:"message"`
+	Locations     []sarifLocation        `json:"locations,omitempty"`
+	Suppressions  []sarifSuppression     `json:"suppressions,omitempty"`
+	BaselineState string                 `json:"baselineState,omitempty"`
+	Properties    map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifReportingDescriptorReference struct {
+	ID    string `json:"id"`
+	Index int    `json:"index"`
+}
+
+type sarifNotification struct {
+	Level          string                             `json:"level"`
+	Message        sarifMessage                       `json:"message"`
+	Locations      []sarifLocation                    `json:"locations,omitempty"`
+	AssociatedRule *sarifReportingDescriptorReference `json:"associatedRule,omitempty"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	Name             string        `json:"name"`
+	ShortDescription sarifMessage  `json:"shortDescription"`
+	FullDescription  *sarifMessage `json:"fullDescription,omitempty"`
+	HelpURI          string        `json:"helpUri,omitempty"`
+
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool              `json:"tool"`
+	Invocations []sarifInvocation      `json:"invocations,omitempty"`
+	Results     []sarifResult          `json:"results"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+func sarifLevel(rule policy.Rule) string {
+	switch {
+	case rule.IsKind(policy.QueryKindDeny, policy.QueryKindViolation):
+		return sarifLevelError
+	case rule.IsKind(policy.QueryKindWarn):
+		return sarifLevelWarning
+	default:
+		return sarifLevelNote
+	}
+}
+
+func sarifLocations(filename string, location result.Location) []sarifLocation {
+	if location.File != "" {
+		filename = location.File
+	}
+	if filename == "" {
+		return nil
+	}
+
+	physicalLocation := sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{
+			URI: filepath.ToSlash(filename),
+		},
+	}
+	if location.Line > 0 {
+		physicalLocation.Region = &sarifRegion{
+			StartLine:   location.Line,
+			StartColumn: location.Column,
+		}
+	}
+
+	rv := sarifLocation{PhysicalLocation: physicalLocation}
+	if location.Identity != "" {
+		rv.LogicalLocations = []sarifLogicalLocation{
+			{
+				FullyQualifiedName: location.Identity,
+				Kind:               sarifLogicalLocationKindResource,
+			},
+		}
+	}
+
+	return []sarifLocation{rv}
+}
+
+// sarifRuleKey identifies a rule in the run.
+type sarifRuleKey struct {
+	ruleID      string
+	packageName string
+}
+
+// sarifRunBuilder book-keeps the rules referenced by the results.
+type sarifRunBuilder struct {
+	rules        []sarifRule
+	ruleIndices  map[sarifRuleKey]int
+	results      []sarifResult
+	compilerKeys []string
+	// notifications records the rule evaluation errors.
+	notifications []sarifNotification
+}
+
+func (b *sarifRunBuilder) addCompilerKey(compilerKey string) {
+	if compilerKey == "" || slices.Contains(b.compilerKeys, compilerKey) {
+		return
+	}
+	b.compilerKeys = append(b.compilerKeys, compilerKey)
+}
+
+// sarifRuleID resolves the id of the rule, which is the full query of the rule.
+// For example: data.main.deny_privileged_containers.
+//
+// NOTE: the package is not part of the id, so moving or renaming a policy package keeps the ids stable.
+// Rules with the same id from different packages are reported as different rules in the run,
+// which can be told apart by the package property.
+func sarifRuleID(r result.Result) string {
+	namespace := r.Rule.Namespace
+	if namespace == "" {
+		namespace = policy.NamespaceMain
+	}
+	return fmt.Sprintf("data.%s.%s", namespace, r.Rule.Query())
+}
+
+func (b *sarifRunBuilder) ruleIndex(r result.Result) (string, int) {
+	ruleID := sarifRuleID(r)
+	key := sarifRuleKey{ruleID: ruleID, packageName: r.Package}
+	if idx, ok := b.ruleIndices[key]; ok {
+		return ruleID, idx
+	}
+
+	rule := sarifRule{
+		ID:               ruleID,
+		Name:             r.Rule.Name,
+		ShortDescription: sarifMessage{Text: r.Rule.Name},
+		HelpURI:          r.RuleDocLink,
+	}
+	if r.Package != "" {
+		rule.Properties = map[string]interface{}{sarifPropertyPackage: r.Package}
+	}
+	if annotations := r.Rule.Annotations; annotations != nil {
+		if annotations.Title != "" {
+			rule.ShortDescription = sarifMessage{Text: annotations.Title}
+		}
+		if annotations.Description != "" {
+			rule.FullDescription = &sarifMessage{Text: annotations.Description}
+		}
+	}
+
+	idx := len(b.rules)
+	b.rules = append(b.rules, rule)
+	b.ruleIndices[key] = idx
+	return ruleID, idx
+}
+
+func (b *sarifRunBuilder) addResult(filename string, r result.Result, suppressed bool) {
+	ruleID, ruleIndex := b.ruleIndex(r)
+
+	message := r.Message
+	if message == "" {
+		// SARIF requires a non-empty message
+		message = r.Rule.Name
+	}
+
+	properties := r.Metadata
+	if r.Package != "" {
+		// NOTE: copy to avoid modifying the result metadata
+		properties = make(map[string]interface{}, len(r.Metadata)+1)
+		for k, v := range r.Metadata {
+			properties[k] = v
+		}
+		properties[sarifPropertyPackage] = r.Package
+	}
+
+	o := sarifResult{
+		RuleID:     ruleID,
+		RuleIndex:  ruleIndex,
+		Level:      sarifLevel(r.Rule),
+		Message:    sarifMessage{Text: message},
+		Locations:  sarifLocations(filename, r.Location),
+		Properties: properties,
+	}
+	if suppressed {
+		suppression := sarifSuppression{Kind: sarifSuppressionKindExternal}
+		if r.Waiver != nil {
+			suppression.Justification = r.Waiver.Reason
+		}
+		o.Suppressions = []sarifSuppression{suppression}
+	}
+
+	b.results = append(b.results, o)
+}
+
+func (b *sarifRunBuilder) addError(filename string, r result.Result) {
+	ruleID, ruleIndex := b.ruleIndex(r)
+
+	b.notifications = append(b.notifications, sarifNotification{
+		Level:     sarifLevelError,
+		Message:   sarifMessage{Text: r.Message},
+		Locations: sarifLocations(filename, r.Location),
+		AssociatedRule: &sarifReportingDescriptorReference{
+			ID:    ruleID,
+			Index: ruleIndex,
+		},
+	})
+}
+
+func (b *sarifRunBuilder) complete() sarifRun {
+	rules := b.rules
+	if rules == nil {
+		rules = []sarifRule{}
+	}
+	results := b.results
+	if results == nil {
+		results = []sarifResult{}
+	}
+
+	rv := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           sarifToolName,
+				InformationURI: sarifToolInformationURI,
+				Rules:          rules,
+			},
+		},
+		Results: results,
+	}
+	if len(b.notifications) > 0 {
+		// NOTE: rule evaluation errors are not results, we report them as tool execution notifications
+		rv.Invocations = []sarifInvocation{
+			{
+				ExecutionSuccessful:        false,
+				ToolExecutionNotifications: b.notifications,
+			},
+		}
+	}
+	if len(b.compilerKeys) > 0 {
+		rv.Properties = map[string]interface{}{
+			sarifPropertyCompilerKeys: b.compilerKeys,
+		}
+	}
+
+	return rv
+}
+
+// SARIF creates a new SARIF presenter.
+func SARIF(queryResultsList []result.QueryResults) WriteQueryResultTo {
+	b := &sarifRunBuilder{
+		ruleIndices: map[sarifRuleKey]int{},
+	}
+	for _, queryResults := range queryResultsList {
+		filename := queryResults.Source.Name()
+		b.addCompilerKey(queryResults.CompilerKey)
+
+		for _, r := range queryResults.Failures {
+			b.addResult(filename, r, false)
+		}
+		for _, r := range queryResults.Warnings {
+			b.addResult(filename, r, false)
+		}
+		for _, r := range queryResults.Exceptions {
+			b.addResult(filename, r, true)
+		}
+		for _, r := range queryResults.Baselined {
+			b.addResult(filename, r, false)
+			// NOTE: baselined results are existing results, consumers can filter them by the baseline state
+			b.results[len(b.results)-1].BaselineState = sarifBaselineStateUnchanged
+		}
+		for _, r := range queryResults.Errors {
+			b.addError(filename, r)
+		}
+	}
+
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{b.complete()},
+	}
+
+	return writeQueryResultToFunc(func(w io.Writer) error {
+		marshaler := json.NewEncoder(w)
+		marshaler.SetIndent("", "  ")
+		return marshaler.Encode(log)
+	})
+}
@@ -0,0 +1,314 @@
+package presenter
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SARIF(t *testing.T) {
+	presenter := SARIF(testQueryResults())
+	output := new(bytes.Buffer)
+	err := presenter.WriteQueryResultTo(output)
+	assert.NoError(t, err)
+	t.Log("\n" + output.String())
+	assert.JSONEq(
+		t,
+		`{
+			"version": "2.1.0",
+			"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+			"runs": [
+			  {
+				"tool": {
+				  "driver": {
+					"name": "ShieldGuard",
+					"informationUri": "https://github.com/Azure/ShieldGuard",
+					"rules": [
+					  {
+						"id": "data.main.deny_001-rule",
+						"name": "001-rule",
+						"shortDescription": {"text": "001-rule"},
+						"helpUri": "https://github.com/Azure/ShieldGuard/docs/001-rego.md"
+					  },
+					  {
+						"id": "data.main.deny_002-rule",
+						"name": "002-rule",
+						"shortDescription": {"text": "002-rule"},
+						"helpUri": "https://github.com/Azure/ShieldGuard/docs/002-rego.md"
+					  },
+					  {
+						"id": "data.main.warn_001-rule",
+						"name": "001-rule",
+						"shortDescription": {"text": "001-rule"},
+						"helpUri": "https://github.com/Azure/ShieldGuard/docs/001-rego.md"
+					  },
+					  {
+						"id": "data.main.warn_002-rule",
+						"name": "002-rule",
+						"shortDescription": {"text": "002-rule"},
+						"helpUri": "https://github.com/Azure/ShieldGuard/docs/002-rego.md"
+					  },
+					  {
+						"id": "data.main.exception_003-rule",
+						"name": "003-rule",
+						"shortDescription": {"text": "003-rule"},
+						"helpUri": "https://github.com/Azure/ShieldGuard/docs/003-rego.md"
+					  }
+					]
+				  }
+				},
+				"results": [
+				  {
+					"ruleId": "data.main.deny_001-rule",
+					"ruleIndex": 0,
+					"level": "error",
+					"message": {"text": "fail message1"},
+					"locations": [{"physicalLocation": {"artifactLocation": {"uri": "file name"}}}]
+				  },
+				  {
+					"ruleId": "data.main.deny_002-rule",
+					"ruleIndex": 1,
+					"level": "error",
+					"message": {"text": "fail message2"},
+					"locations": [{"physicalLocation": {"artifactLocation": {"uri": "file name"}}}]
+				  },
+				  {
+					"ruleId": "data.main.warn_001-rule",
+					"ruleIndex": 2,
+					"level": "warning",
+					"message": {"text": "warn message1"},
+					"locations": [{"physicalLocation": {"artifactLocation": {"uri": "file name"}}}]
+				  },
+				  {
+					"ruleId": "data.main.warn_002-rule",
+					"ruleIndex": 3,
+					"level": "warning",
+					"message": {"text": "warn message2"},
+					"locations": [{"physicalLocation": {"artifactLocation": {"uri": "file name"}}}]
+				  },
+				  {
+					"ruleId": "data.main.exception_003-rule",
+					"ruleIndex": 4,
+					"level": "note",
+					"message": {"text": "003-rule"},
+					"locations": [{"physicalLocation": {"artifactLocation": {"uri": "file name"}}}],
+					"suppressions": [{"kind": "external"}]
+				  }
+				]
+			  }
+			]
+		  }`,
+		output.String(),
+	)
+}
+
+func Test_SARIF_empty(t *testing.T) {
+	presenter := SARIF(nil)
+	output := new(bytes.Buffer)
+	err := presenter.WriteQueryResultTo(output)
+	assert.NoError(t, err)
+	assert.JSONEq(
+		t,
+		`{
+			"version": "2.1.0",
+			"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+			"runs": [
+			  {
+				"tool": {
+				  "driver": {
+					"name": "ShieldGuard",
+					"informationUri": "https://github.com/Azure/ShieldGuard",
+					"rules": []
+				  }
+				},
+				"results": []
+			  }
+			]
+		  }`,
+		output.String(),
+	)
+}
+
+func Test_SARIF_compilerKey(t *testing.T) {
+	queryResultsList := append(testQueryResults(), testQueryResults()...)
+	queryResultsList[0].CompilerKey = "compiler-key-1"
+	queryResultsList[1].CompilerKey = "compiler-key-2"
+	queryResultsList[2].CompilerKey = "compiler-key-1"
+
+	presenter := SARIF(queryResultsList)
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed sarifLog
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"compilerKeys": []interface{}{"compiler-key-1", "compiler-key-2"},
+		},
+		parsed.Runs[0].Properties,
+		"should record distinct compiler keys",
+	)
+}
+
+func Test_SARIF_errors(t *testing.T) {
+	presenter := SARIF(testQueryResultsWithErrors())
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed sarifLog
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	run := parsed.Runs[0]
+	assert.Empty(t, run.Results, "errors should not be reported as results")
+	assert.Len(t, run.Tool.Driver.Rules, 1)
+	if assert.Len(t, run.Invocations, 1) {
+		invocation := run.Invocations[0]
+		assert.False(t, invocation.ExecutionSuccessful)
+		assert.Equal(
+			t,
+			[]sarifNotification{
+				{
+					Level:   "error",
+					Message: sarifMessage{Text: "eval_conflict_error: complete rules must not produce multiple outputs"},
+					Locations: []sarifLocation{
+						{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: "file name"}}},
+					},
+					AssociatedRule: &sarifReportingDescriptorReference{ID: "data.main.deny_004-rule", Index: 0},
+				},
+			},
+			invocation.ToolExecutionNotifications,
+		)
+	}
+}
+
+func Test_SARIF_annotations(t *testing.T) {
+	presenter := SARIF(testQueryResultsWithAnnotations())
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed sarifLog
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	rules := parsed.Runs[0].Tool.Driver.Rules
+	assert.Len(t, rules, 1)
+	assert.Equal(t, "No latest tag", rules[0].ShortDescription.Text)
+	if assert.NotNil(t, rules[0].FullDescription) {
+		assert.Equal(t, "Container images must be pinned to a version.", rules[0].FullDescription.Text)
+	}
+}
+
+func Test_SARIF_package(t *testing.T) {
+	queryResultsList := testQueryResults()
+	queryResultsList[0].Failures[0].Package = "builtin:pss/baseline"
+	queryResultsList[0].Failures[0].Metadata = map[string]interface{}{"foo": "bar"}
+
+	presenter := SARIF(queryResultsList)
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed sarifLog
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	results := parsed.Runs[0].Results
+	assert.Equal(
+		t,
+		map[string]interface{}{"foo": "bar", "package": "builtin:pss/baseline"},
+		results[0].Properties,
+	)
+	assert.Empty(t, results[1].Properties)
+	assert.Equal(
+		t,
+		map[string]interface{}{"foo": "bar"},
+		queryResultsList[0].Failures[0].Metadata,
+		"should not modify the result metadata",
+	)
+}
+
+func Test_SARIF_ruleID(t *testing.T) {
+	queryResultsList := testQueryResults()
+	// same rule name from different namespaces
+	queryResultsList[0].Failures[0].Rule.Name = "privileged"
+	queryResultsList[0].Failures[0].Rule.Namespace = "kubernetes.pss.baseline"
+	queryResultsList[0].Failures[1].Rule.Name = "privileged"
+	queryResultsList[0].Failures[1].Rule.Namespace = "main"
+	// same rule from different packages
+	queryResultsList[0].Warnings[0].Package = "fs:policies/a"
+	queryResultsList[0].Warnings[1].Rule.Name = "001-rule"
+	queryResultsList[0].Warnings[1].Package = "fs:policies/b"
+
+	presenter := SARIF(queryResultsList)
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed sarifLog
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	rules := parsed.Runs[0].Tool.Driver.Rules
+	results := parsed.Runs[0].Results
+	if !assert.Len(t, rules, 5) {
+		return
+	}
+
+	assert.Equal(t, "data.kubernetes.pss.baseline.deny_privileged", rules[0].ID)
+	assert.Equal(t, "data.main.deny_privileged", rules[1].ID)
+	assert.Equal(t, rules[0].ID, results[0].RuleID)
+	assert.Equal(t, 0, results[0].RuleIndex)
+	assert.Equal(t, rules[1].ID, results[1].RuleID)
+	assert.Equal(t, 1, results[1].RuleIndex)
+
+	assert.Equal(t, "data.main.warn_001-rule", rules[2].ID, "package should not be part of the rule id")
+	assert.Equal(t, "data.main.warn_001-rule", rules[3].ID)
+	assert.Equal(t, map[string]interface{}{"package": "fs:policies/a"}, rules[2].Properties)
+	assert.Equal(t, map[string]interface{}{"package": "fs:policies/b"}, rules[3].Properties)
+	assert.Equal(t, 2, results[2].RuleIndex)
+	assert.Equal(t, 3, results[3].RuleIndex)
+	assert.Equal(t, "fs:policies/b", results[3].Properties["package"])
+}
+
+func Test_SARIF_waiver(t *testing.T) {
+	queryResultsList := testQueryResults()
+	queryResultsList[0].Exceptions[0].Waiver = &result.Waiver{
+		Owner:   "team-a",
+		Reason:  "required by device plugin",
+		Expires: "2999-12-31",
+	}
+
+	presenter := SARIF(queryResultsList)
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed sarifLog
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	results := parsed.Runs[0].Results
+	suppressed := results[len(results)-1]
+	assert.Equal(
+		t,
+		[]sarifSuppression{{Kind: "external", Justification: "required by device plugin"}},
+		suppressed.Suppressions,
+	)
+}
+
+func Test_SARIF_baselined(t *testing.T) {
+	queryResultsList := testQueryResults()
+	queryResultsList[0].Baselined = []result.Result{
+		{
+			Message: "fail message5",
+			Rule:    policy.Rule{Kind: policy.QueryKindDeny, Name: "005-rule"},
+		},
+	}
+
+	presenter := SARIF(queryResultsList)
+	output := new(bytes.Buffer)
+	assert.NoError(t, presenter.WriteQueryResultTo(output))
+
+	var parsed sarifLog
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &parsed))
+	results := parsed.Runs[0].Results
+	baselined := results[len(results)-1]
+	assert.Equal(t, "data.main.deny_005-rule", baselined.RuleID)
+	assert.Equal(t, "error", baselined.Level)
+	assert.Equal(t, "unchanged", baselined.BaselineState)
+	assert.Empty(t, baselined.Suppressions)
+	assert.Empty(t, results[0].BaselineState, "should not set baseline state for other results")
+}
@@ -1,9 +1,13 @@
 package presenter
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
 	"github.com/Azure/ShieldGuard/sg/internal/result"
 	"github.com/b4fun/ci"
 	"github.com/b4fun/ci/cilog"
@@ -15,6 +19,7 @@ func Text(queryResultsList []result.QueryResults) WriteQueryResultTo {
 		categoryFAIL      = "FAIL"
 		categoryWARNING   = "WARNING"
 		categoryEXCEPTION = "EXCEPTION"
+		categoryERROR     = "ERROR"
 	)
 
 	queryResultsObjList := asQueryResultsObjList(queryResultsList)
@@ -34,22 +39,23 @@ func Text(queryResultsList []result.QueryResults) WriteQueryResultTo {
 		filename string,
 		o resultObj,
 	) {
-		var messageDetails string
-		if o.Message == "" {
-			messageDetails = fmt.Sprintf("(%s)", o.Rule.Name)
-		} else {
-			messageDetails = fmt.Sprintf("(%s) %s", o.Rule.Name, o.Message)
+		messageDetails := fmt.Sprintf("(%s)", o.Rule.Name)
+		if o.Severity != "" {
+			messageDetails += fmt.Sprintf(" [%s]", o.Severity)
+		}
+		if o.Message != "" {
+			messageDetails += " " + o.Message
 		}
 
 		println := logger.Log
 		switch category {
-		case categoryFAIL:
+		case categoryFAIL, categoryERROR:
 			println = func(s string) { cilog.Error(logger, s) }
 		case categoryWARNING:
 			println = func(s string) { cilog.Warning(logger, s) }
 		}
 
-		println(fmt.Sprintf("%s - %s - %s", category, filename, messageDetails))
+		println(fmt.Sprintf("%s - %s - %s", category, o.locationString(filename), messageDetails))
 	}
 
 	printDocumentLink := func(logger cilog.Logger, docLink string) {
@@ -59,26 +65,99 @@ func Text(queryResultsList []result.QueryResults) WriteQueryResultTo {
 		logger.Log(fmt.Sprintf("Document: %s", docLink))
 	}
 
+	printRuleAnnotations := func(logger cilog.Logger, rule policyRuleObj) {
+		if rule.Title != "" {
+			logger.Log(fmt.Sprintf("Rule: %s", rule.Title))
+		}
+		if rule.Description != "" {
+			logger.Log(fmt.Sprintf("Description: %s", rule.Description))
+		}
+		for _, r := range rule.RelatedResources {
+			if r.Ref == rule.DocLink {
+				// printed as the document link
+				continue
+			}
+			if r.Description == "" {
+				logger.Log(fmt.Sprintf("Related: %s", r.Ref))
+			} else {
+				logger.Log(fmt.Sprintf("Related: %s (%s)", r.Ref, r.Description))
+			}
+		}
+	}
+
+	printWaiver := func(logger cilog.Logger, waiver *waiverObj) {
+		if waiver == nil {
+			return
+		}
+		state := "expires"
+		if waiver.Expired {
+			state = "expired"
+		}
+		logger.Log(fmt.Sprintf("Waiver: %s (owner: %s, %s: %s)", waiver.Reason, waiver.Owner, state, waiver.Expires))
+	}
+
+	printExplanation := func(logger cilog.Logger, explanation *explanationObj) {
+		if explanation == nil {
+			return
+		}
+		logger.Log("Explanation:")
+		for _, step := range explanation.Steps {
+			logger.Log(fmt.Sprintf("  %s:%d: %s", step.File, step.Line, step.Expression))
+			for _, values := range []map[string]interface{}{step.Input, step.Bindings} {
+				keys := make([]string, 0, len(values))
+				for k := range values {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				for _, k := range keys {
+					b, err := json.Marshal(values[k])
+					if err != nil {
+						continue
+					}
+					logger.Log(fmt.Sprintf("    %s = %s", k, b))
+				}
+			}
+		}
+	}
+
 	return writeQueryResultToFunc(func(w io.Writer) error {
 		logger.SetOutput(w)
 
 		var (
-			totalTests  int
-			totalPasses int
+			totalTests     int
+			totalPasses    int
+			totalSkipped   int
+			totalBaselined int
 
 			failures   []func(cilog.Logger)
 			warnings   []func(cilog.Logger)
 			exceptions []func(cilog.Logger)
+			errors     []func(cilog.Logger)
+
+			severityCounts = map[string]int{}
 		)
 
 		for _, queryResultObj := range queryResultsObjList {
 			totalPasses += queryResultObj.Success
+			totalSkipped += len(queryResultObj.Skipped)
+			totalBaselined += len(queryResultObj.Baselined)
+
+			for _, results := range [][]resultObj{queryResultObj.Failures, queryResultObj.Warnings} {
+				for _, o := range results {
+					if o.Severity != "" {
+						severityCounts[o.Severity] += 1
+					}
+				}
+			}
 
 			for _, o := range queryResultObj.Failures {
 				o := o
 				fileName := queryResultObj.Filename
 				failures = append(failures, func(l cilog.Logger) {
 					printResultObj(logger, categoryFAIL, fileName, o)
+					printWaiver(logger, o.Waiver)
+					printRuleAnnotations(logger, o.Rule)
+					printExplanation(logger, o.Explanation)
 					printDocumentLink(logger, o.Rule.DocLink)
 				})
 			}
@@ -87,6 +166,9 @@ func Text(queryResultsList []result.QueryResults) WriteQueryResultTo {
 				fileName := queryResultObj.Filename
 				warnings = append(warnings, func(l cilog.Logger) {
 					printResultObj(logger, categoryWARNING, fileName, o)
+					printWaiver(logger, o.Waiver)
+					printRuleAnnotations(logger, o.Rule)
+					printExplanation(logger, o.Explanation)
 					printDocumentLink(logger, o.Rule.DocLink)
 				})
 			}
@@ -95,10 +177,22 @@ func Text(queryResultsList []result.QueryResults) WriteQueryResultTo {
 				fileName := queryResultObj.Filename
 				exceptions = append(exceptions, func(l cilog.Logger) {
 					printResultObj(logger, categoryEXCEPTION, fileName, o)
+					printWaiver(logger, o.Waiver)
+				})
+			}
+			for _, o := range queryResultObj.Errors {
+				o := o
+				fileName := queryResultObj.Filename
+				errors = append(errors, func(l cilog.Logger) {
+					printResultObj(logger, categoryERROR, fileName, o)
+					printDocumentLink(logger, o.Rule.DocLink)
 				})
 			}
 		}
 
+		for _, cb := range errors {
+			cb(logger)
+		}
 		for _, cb := range failures {
 			cb(logger)
 		}
@@ -115,11 +209,26 @@ func Text(queryResultsList []result.QueryResults) WriteQueryResultTo {
 			endExc()
 		}
 
-		totalTests = totalPasses + len(failures) + len(warnings) + len(exceptions)
-		logger.Log(fmt.Sprintf(
-			"%d test(s), %d passed, %d failure(s) %d warning(s), %d exception(s)",
-			totalTests, totalPasses, len(failures), len(warnings), len(exceptions),
-		))
+		totalTests = totalPasses + len(failures) + len(warnings) + len(exceptions) + len(errors) + totalBaselined
+		summary := fmt.Sprintf(
+			"%d test(s), %d passed, %d failure(s) %d warning(s), %d exception(s), %d error(s)",
+			totalTests, totalPasses, len(failures), len(warnings), len(exceptions), len(errors),
+		)
+		if totalSkipped > 0 {
+			// NOTE: skipped rules are not evaluated, so they are not counted as tests
+			summary += fmt.Sprintf(", %d skipped", totalSkipped)
+		}
+		if totalBaselined > 0 {
+			summary += fmt.Sprintf(", %d baselined", totalBaselined)
+		}
+		if len(severityCounts) > 0 {
+			counts := make([]string, 0, len(policy.Severities))
+			for _, severity := range policy.Severities {
+				counts = append(counts, fmt.Sprintf("%d %s", severityCounts[string(severity)], severity))
+			}
+			summary += fmt.Sprintf(" (%s)", strings.Join(counts, ", "))
+		}
+		logger.Log(summary)
 
 		return nil
 	})
@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+	"github.com/Azure/ShieldGuard/sg/internal/source/testsource"
 	"github.com/b4fun/ci"
 	"github.com/stretchr/testify/assert"
 )
@@ -28,7 +31,7 @@ Document: https://github.com/Azure/ShieldGuard/docs/001-rego.md
 WARNING - file name - (002-rule) warn message2
 Document: https://github.com/Azure/ShieldGuard/docs/002-rego.md
 EXCEPTION - file name - (003-rule)
-7 test(s), 2 passed, 2 failure(s) 2 warning(s), 1 exception(s)
+7 test(s), 2 passed, 2 failure(s) 2 warning(s), 1 exception(s), 0 error(s)
 `,
 			output.String(),
 		)
@@ -55,7 +58,7 @@ Document: https://github.com/Azure/ShieldGuard/docs/002-rego.md
 ##[group]EXCEPTIONS (1)
 EXCEPTION - file name - (003-rule)
 ##[endgroup]
-7 test(s), 2 passed, 2 failure(s) 2 warning(s), 1 exception(s)
+7 test(s), 2 passed, 2 failure(s) 2 warning(s), 1 exception(s), 0 error(s)
 `,
 			output.String(),
 		)
@@ -82,9 +85,243 @@ Document: https://github.com/Azure/ShieldGuard/docs/002-rego.md
 ::group::EXCEPTIONS (1)
 EXCEPTION - file name - (003-rule)
 ::endgroup::
-7 test(s), 2 passed, 2 failure(s) 2 warning(s), 1 exception(s)
+7 test(s), 2 passed, 2 failure(s) 2 warning(s), 1 exception(s), 0 error(s)
 `,
 			output.String(),
 		)
 	})
+
+	t.Run("with location", func(t *testing.T) {
+		t.Setenv("CI_NAME", "CUSTOM")
+
+		queryResults := []result.QueryResults{
+			{
+				Source: &testsource.TestSource{NameFunc: func() string {
+					return "file name"
+				}},
+				Failures: []result.Result{
+					{
+						Message: "fail message1",
+						Rule: policy.Rule{
+							Kind: policy.QueryKindDeny,
+							Name: "001-rule",
+						},
+						Location: result.Location{
+							File:     "file name",
+							Document: 1,
+							Line:     3,
+							Column:   5,
+						},
+					},
+					{
+						Message: "fail message2",
+						Rule: policy.Rule{
+							Kind: policy.QueryKindDeny,
+							Name: "002-rule",
+						},
+						Location: result.Location{
+							File:     "file name",
+							Document: 2,
+							Identity: "Deployment/default/foo",
+						},
+					},
+					{
+						Message: "fail message3",
+						Rule: policy.Rule{
+							Kind: policy.QueryKindDeny,
+							Name: "003-rule",
+						},
+						Location: result.Location{
+							File:     "file name",
+							Document: 0,
+						},
+					},
+				},
+			},
+		}
+
+		presenter := Text(queryResults)
+		output := new(bytes.Buffer)
+		err := presenter.WriteQueryResultTo(output)
+		assert.NoError(t, err)
+		t.Log("\n" + output.String())
+		assert.Equal(
+			t,
+			`FAIL - file name:3:5 [#1] - (001-rule) fail message1
+FAIL - file name [#2 Deployment/default/foo] - (002-rule) fail message2
+FAIL - file name - (003-rule) fail message3
+3 test(s), 0 passed, 3 failure(s) 0 warning(s), 0 exception(s), 0 error(s)
+`,
+			output.String(),
+		)
+	})
+}
+
+func Test_Text_errors(t *testing.T) {
+	t.Setenv("CI_NAME", "CUSTOM")
+
+	presenter := Text(testQueryResultsWithErrors())
+	output := new(bytes.Buffer)
+	err := presenter.WriteQueryResultTo(output)
+	assert.NoError(t, err)
+	t.Log("\n" + output.String())
+	assert.Equal(
+		t,
+		`ERROR - file name [#1] - (004-rule) eval_conflict_error: complete rules must not produce multiple outputs
+Document: https://github.com/Azure/ShieldGuard/docs/004-rego.md
+2 test(s), 1 passed, 0 failure(s) 0 warning(s), 0 exception(s), 1 error(s)
+`,
+		output.String(),
+	)
+}
+
+func Test_Text_explanation(t *testing.T) {
+	t.Setenv("CI_NAME", "CUSTOM")
+
+	presenter := Text(testQueryResultsWithExplanation())
+	output := new(bytes.Buffer)
+	err := presenter.WriteQueryResultTo(output)
+	assert.NoError(t, err)
+	t.Log("\n" + output.String())
+	assert.Equal(
+		t,
+		`FAIL - file name - (001-rule) name cannot be foo
+Explanation:
+  policy/001-rule.rego:4: input.metadata.name == "foo"
+    input.metadata.name = "foo"
+  policy/001-rule.rego:6: msg := "name cannot be foo"
+    msg = "name cannot be foo"
+Document: https://github.com/Azure/ShieldGuard/docs/001-rego.md
+1 test(s), 0 passed, 1 failure(s) 0 warning(s), 0 exception(s), 0 error(s)
+`,
+		output.String(),
+	)
+}
+
+func Test_Text_annotations(t *testing.T) {
+	t.Setenv("CI_NAME", "CUSTOM")
+
+	presenter := Text(testQueryResultsWithAnnotations())
+	output := new(bytes.Buffer)
+	err := presenter.WriteQueryResultTo(output)
+	assert.NoError(t, err)
+	t.Log("\n" + output.String())
+	assert.Equal(
+		t,
+		`FAIL - file name - (latest_tag) image app:latest uses latest tag
+Rule: No latest tag
+Description: Container images must be pinned to a version.
+Related: https://kubernetes.io/docs/concepts/containers/images/ (Images)
+Document: https://github.com/Azure/ShieldGuard/docs/001-rego.md
+1 test(s), 0 passed, 1 failure(s) 0 warning(s), 0 exception(s), 0 error(s)
+`,
+		output.String(),
+	)
+}
+
+func Test_Text_severity(t *testing.T) {
+	t.Setenv("CI_NAME", "CUSTOM")
+
+	presenter := Text(testQueryResultsWithSeverity())
+	output := new(bytes.Buffer)
+	err := presenter.WriteQueryResultTo(output)
+	assert.NoError(t, err)
+	t.Log("\n" + output.String())
+	assert.Equal(
+		t,
+		`FAIL - file name - (001-rule) [high] fail message1
+FAIL - file name - (002-rule) fail message2
+WARNING - file name - (003-rule) [low] warn message1
+3 test(s), 0 passed, 2 failure(s) 1 warning(s), 0 exception(s), 0 error(s) (0 critical, 1 high, 0 medium, 1 low, 0 info)
+`,
+		output.String(),
+	)
+}
+
+func Test_Text_skipped(t *testing.T) {
+	t.Setenv("CI_NAME", "CUSTOM")
+
+	queryResultsList := testQueryResultsWithErrors()
+	queryResultsList[0].Skipped = []result.Result{
+		{Rule: policy.Rule{Kind: policy.QueryKindWarn, Name: "005-rule"}},
+	}
+
+	presenter := Text(queryResultsList)
+	output := new(bytes.Buffer)
+	err := presenter.WriteQueryResultTo(output)
+	assert.NoError(t, err)
+	t.Log("\n" + output.String())
+	assert.Equal(
+		t,
+		`ERROR - file name [#1] - (004-rule) eval_conflict_error: complete rules must not produce multiple outputs
+Document: https://github.com/Azure/ShieldGuard/docs/004-rego.md
+2 test(s), 1 passed, 0 failure(s) 0 warning(s), 0 exception(s), 1 error(s), 1 skipped
+`,
+		output.String(),
+	)
+}
+
+func Test_Text_waiver(t *testing.T) {
+	t.Setenv("CI_NAME", "CUSTOM")
+
+	queryResultsList := []result.QueryResults{
+		{
+			Source: &testsource.TestSource{NameFunc: func() string {
+				return "file name"
+			}},
+			Failures: []result.Result{
+				{
+					Message: "fail message1",
+					Rule:    policy.Rule{Kind: policy.QueryKindDeny, Name: "001-rule"},
+					Waiver:  &result.Waiver{Owner: "team-b", Reason: "debugging", Expires: "2000-01-01", Expired: true},
+				},
+			},
+			Exceptions: []result.Result{
+				{
+					Message: "fail message2",
+					Rule:    policy.Rule{Kind: policy.QueryKindDeny, Name: "002-rule"},
+					Waiver:  &result.Waiver{Owner: "team-a", Reason: "required by device plugin", Expires: "2999-12-31"},
+				},
+			},
+		},
+	}
+
+	presenter := Text(queryResultsList)
+	output := new(bytes.Buffer)
+	err := presenter.WriteQueryResultTo(output)
+	assert.NoError(t, err)
+	t.Log("\n" + output.String())
+	assert.Equal(
+		t,
+		`FAIL - file name - (001-rule) fail message1
+Waiver: debugging (owner: team-b, expired: 2000-01-01)
+EXCEPTION - file name - (002-rule) fail message2
+Waiver: required by device plugin (owner: team-a, expires: 2999-12-31)
+2 test(s), 0 passed, 1 failure(s) 0 warning(s), 1 exception(s), 0 error(s)
+`,
+		output.String(),
+	)
+}
+
+func Test_Text_baselined(t *testing.T) {
+	t.Setenv("CI_NAME", "CUSTOM")
+
+	queryResultsList := testQueryResultsWithErrors()
+	queryResultsList[0].Baselined = []result.Result{
+		{Message: "fail message1", Rule: policy.Rule{Kind: policy.QueryKindDeny, Name: "005-rule"}},
+	}
+
+	presenter := Text(queryResultsList)
+	output := new(bytes.Buffer)
+	err := presenter.WriteQueryResultTo(output)
+	assert.NoError(t, err)
+	t.Log("\n" + output.String())
+	assert.Equal(
+		t,
+		`ERROR - file name [#1] - (004-rule) eval_conflict_error: complete rules must not produce multiple outputs
+Document: https://github.com/Azure/ShieldGuard/docs/004-rego.md
+3 test(s), 1 passed, 0 failure(s) 0 warning(s), 0 exception(s), 1 error(s), 1 baselined
+`,
+		output.String(),
+	)
 }
@@ -2,8 +2,11 @@ package result
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/open-policy-agent/opa/rego"
+
+	"github.com/Azure/ShieldGuard/sg/internal/utils"
 )
 
 func empty(query string) Result {
@@ -78,19 +81,115 @@ func FromRegoExpression(
 	return rv, nil
 }
 
+// String returns the location in `file[:line:col]` form.
+func (l Location) String() string {
+	if l.Line < 1 {
+		return l.File
+	}
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+}
+
 // Passed tells if the result is passed.
 func (r Result) Passed() bool {
 	return r.Message == ""
 }
 
 // Merge merges two results into a new one.
-// The new result uses Source from the first result.
+// The new result uses Source and Namespace from the first result,
+// and CompilerKey from the first result with non-empty value.
 func (qr QueryResults) Merge(other QueryResults) QueryResults {
+	compilerKey := qr.CompilerKey
+	if compilerKey == "" {
+		compilerKey = other.CompilerKey
+	}
+
+	var successesByNamespace map[string]int
+	if len(qr.SuccessesByNamespace) > 0 || len(other.SuccessesByNamespace) > 0 {
+		successesByNamespace = make(map[string]int, len(qr.SuccessesByNamespace)+len(other.SuccessesByNamespace))
+		for namespace, successes := range qr.SuccessesByNamespace {
+			successesByNamespace[namespace] += successes
+		}
+		for namespace, successes := range other.SuccessesByNamespace {
+			successesByNamespace[namespace] += successes
+		}
+	}
+
 	return QueryResults{
-		Source:     qr.Source,
-		Successes:  qr.Successes + other.Successes,
-		Failures:   append(qr.Failures, other.Failures...),
-		Warnings:   append(qr.Warnings, other.Warnings...),
-		Exceptions: append(qr.Exceptions, other.Exceptions...),
+		Source:               qr.Source,
+		Namespace:            qr.Namespace,
+		Successes:            qr.Successes + other.Successes,
+		SuccessesByNamespace: successesByNamespace,
+		Passes:               append(qr.Passes, other.Passes...),
+		Failures:             append(qr.Failures, other.Failures...),
+		Warnings:             append(qr.Warnings, other.Warnings...),
+		Exceptions:           append(qr.Exceptions, other.Exceptions...),
+		Skipped:              append(qr.Skipped, other.Skipped...),
+		Baselined:            append(qr.Baselined, other.Baselined...),
+		Errors:               append(qr.Errors, other.Errors...),
+		Documents:            append(qr.Documents, other.Documents...),
+		CompilerKey:          compilerKey,
+	}
+}
+
+// ByNamespace splits the results by the rego namespace of the rules, ordered by the namespace.
+// Successes not attributed to a namespace are reported in the results with empty namespace.
+// Documents and CompilerKey are kept in every split results.
+func (qr QueryResults) ByNamespace() []QueryResults {
+	successesByNamespace := make(map[string]int, len(qr.SuccessesByNamespace))
+	attributedSuccesses := 0
+	for namespace, successes := range qr.SuccessesByNamespace {
+		successesByNamespace[namespace] += successes
+		attributedSuccesses += successes
+	}
+	if unattributedSuccesses := qr.Successes - attributedSuccesses; unattributedSuccesses > 0 {
+		successesByNamespace[""] += unattributedSuccesses
 	}
+
+	namespacesSet := make(map[string]struct{})
+	for namespace := range successesByNamespace {
+		namespacesSet[namespace] = struct{}{}
+	}
+	for _, results := range [][]Result{qr.Passes, qr.Failures, qr.Warnings, qr.Exceptions, qr.Skipped, qr.Baselined, qr.Errors} {
+		for _, r := range results {
+			namespacesSet[r.Rule.Namespace] = struct{}{}
+		}
+	}
+	if len(namespacesSet) < 2 {
+		rv := qr
+		for namespace := range namespacesSet {
+			rv.Namespace = namespace
+		}
+		return []QueryResults{rv}
+	}
+
+	namespaces := make([]string, 0, len(namespacesSet))
+	for namespace := range namespacesSet {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	inNamespace := func(namespace string) func(Result) bool {
+		return func(r Result) bool { return r.Rule.Namespace == namespace }
+	}
+
+	rv := make([]QueryResults, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		rv = append(rv, QueryResults{
+			Source:               qr.Source,
+			Namespace:            namespace,
+			Successes:            successesByNamespace[namespace],
+			SuccessesByNamespace: map[string]int{namespace: successesByNamespace[namespace]},
+			Passes:               utils.Filter(qr.Passes, inNamespace(namespace)),
+			Failures:             utils.Filter(qr.Failures, inNamespace(namespace)),
+			Warnings:             utils.Filter(qr.Warnings, inNamespace(namespace)),
+			Exceptions:           utils.Filter(qr.Exceptions, inNamespace(namespace)),
+			Skipped:              utils.Filter(qr.Skipped, inNamespace(namespace)),
+			Baselined:            utils.Filter(qr.Baselined, inNamespace(namespace)),
+			Errors:               utils.Filter(qr.Errors, inNamespace(namespace)),
+			Documents:            qr.Documents,
+			CompilerKey:          qr.CompilerKey,
+		})
+	}
+
+	return rv
 }
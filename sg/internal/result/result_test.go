@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
 	"github.com/Azure/ShieldGuard/sg/internal/source/testsource"
 	"github.com/open-policy-agent/opa/rego"
 	"github.com/stretchr/testify/assert"
@@ -160,6 +161,9 @@ func Test_QueryResults_Merge(t *testing.T) {
 		Exceptions: []Result{
 			{Message: "exc-left-1"},
 		},
+		Documents: []Document{
+			{Index: 0},
+		},
 	}
 	right := QueryResults{
 		Source: &testsource.TestSource{
@@ -178,12 +182,91 @@ func Test_QueryResults_Merge(t *testing.T) {
 		Exceptions: []Result{
 			{Message: "exc-right-1"},
 		},
+		Errors: []Result{
+			{Message: "error-right-1"},
+		},
+		Documents: []Document{
+			{Index: 1, Identity: "Pod/foo"},
+		},
 	}
 
+	left.SuccessesByNamespace = map[string]int{"main": 10}
+	right.SuccessesByNamespace = map[string]int{"main": 1, "foo": 2}
+
 	merged := left.Merge(right)
 	assert.Equal(t, "left", merged.Source.Name())
+	assert.Equal(t, map[string]int{"main": 11, "foo": 2}, merged.SuccessesByNamespace)
 	assert.Equal(t, 13, merged.Successes)
 	assert.Len(t, merged.Failures, 3)
 	assert.Len(t, merged.Warnings, 2)
 	assert.Len(t, merged.Exceptions, 2)
+	assert.Len(t, merged.Documents, 2)
+	assert.Len(t, merged.Errors, 1)
+	assert.Empty(t, merged.CompilerKey)
+
+	right.CompilerKey = "compiler-key"
+	merged = left.Merge(right)
+	assert.Equal(t, "compiler-key", merged.CompilerKey, "should use the first non-empty compiler key")
+}
+
+func Test_QueryResults_ByNamespace(t *testing.T) {
+	source := &testsource.TestSource{
+		NameFunc: func() string {
+			return "file"
+		},
+	}
+
+	qr := QueryResults{
+		Source:    source,
+		Successes: 3,
+		Failures: []Result{
+			{Message: "failed-main", Rule: policy.Rule{Namespace: "main"}},
+			{Message: "failed-foo", Rule: policy.Rule{Namespace: "foo"}},
+		},
+		Warnings: []Result{
+			{Message: "warn-foo", Rule: policy.Rule{Namespace: "foo"}},
+		},
+		Documents:   []Document{{Index: 0}},
+		CompilerKey: "compiler-key",
+	}
+
+	// single namespace
+	single := QueryResults{Source: source, Failures: qr.Failures[:1]}.ByNamespace()
+	assert.Len(t, single, 1)
+	assert.Equal(t, "main", single[0].Namespace)
+
+	// no results
+	empty := QueryResults{Source: source}.ByNamespace()
+	assert.Len(t, empty, 1)
+	assert.Empty(t, empty[0].Namespace)
+
+	qr.SuccessesByNamespace = map[string]int{"foo": 1, "main": 2}
+	split := qr.ByNamespace()
+	assert.Len(t, split, 2)
+	assert.Equal(t, "foo", split[0].Namespace)
+	assert.Equal(t, 1, split[0].Successes)
+	assert.Len(t, split[0].Failures, 1)
+	assert.Len(t, split[0].Warnings, 1)
+	assert.Equal(t, "main", split[1].Namespace)
+	assert.Equal(t, 2, split[1].Successes)
+	assert.Len(t, split[1].Failures, 1)
+	assert.Empty(t, split[1].Warnings)
+	for _, r := range split {
+		assert.Equal(t, "file", r.Source.Name())
+		assert.Equal(t, qr.Documents, r.Documents)
+		assert.Equal(t, "compiler-key", r.CompilerKey)
+	}
+
+	// unattributed successes
+	qr.SuccessesByNamespace = map[string]int{"foo": 1}
+	split = qr.ByNamespace()
+	assert.Len(t, split, 3)
+	assert.Empty(t, split[0].Namespace)
+	assert.Equal(t, 2, split[0].Successes)
+}
+
+func Test_Location_String(t *testing.T) {
+	assert.Equal(t, "foo.yaml", Location{File: "foo.yaml"}.String())
+	assert.Equal(t, "foo.yaml", Location{File: "foo.yaml", Document: 1}.String())
+	assert.Equal(t, "foo.yaml:3:5", Location{File: "foo.yaml", Document: 1, Line: 3, Column: 5}.String())
 }
@@ -13,22 +13,115 @@ type Result struct {
 	Rule policy.Rule
 	// RuleDocLink is the link to the documentation of the rule.
 	RuleDocLink string
+	// Package is the qualified id of the policy package defining the rule (e.g. `builtin:pss/baseline`).
+	Package string
 	// Message is the message that was returned by the rule.
 	Message string
 	// Metadata is the extra metadata that was returned by the rule.
 	Metadata map[string]interface{}
+	// Location is the location of the result in the source.
+	Location Location
+	// Severity is the severity level of the result, resolved from the result metadata or the rule annotations.
+	// Empty value means the severity is not specified.
+	Severity policy.Severity
+	// Waiver is the waiver matching the result. Nil if the result is not waived.
+	// Results waived by a valid waiver are reported as exceptions.
+	Waiver *Waiver
+	// Explanation describes how the rule produced the result.
+	// It's only available when the rule is explained (see engine.QueryOptions).
+	Explanation *Explanation
+}
+
+// Waiver specifies the waiver applied to a result.
+type Waiver struct {
+	// Owner is the owner of the waiver.
+	Owner string
+	// Reason is the justification of the waiver.
+	Reason string
+	// Expires is the last date (in YYYY-MM-DD) the waiver is valid.
+	Expires string
+	// Expired tells if the waiver is expired. Expired waivers don't waive the results.
+	Expired bool
+}
+
+// Location specifies the location of a result in the source.
+type Location struct {
+	// File is the name of the source file.
+	File string
+	// Document is the (0-based) index of the document in the source file.
+	Document int
+	// Line is the (1-based) line number of the node reported by the rule.
+	// Zero value means the line is unknown.
+	Line int
+	// Column is the (1-based) column number of the node reported by the rule.
+	// Zero value means the column is unknown.
+	Column int
+	// Identity is the stable identity of the document (e.g. Kubernetes `kind/namespace/name`).
+	// Empty value means the identity is unknown.
+	Identity string
+}
+
+// Document specifies a document in the source.
+type Document struct {
+	// Index is the (0-based) index of the document in the source file.
+	Index int
+	// Identity is the stable identity of the document (e.g. Kubernetes `kind/namespace/name`).
+	// Empty value means the identity is unknown.
+	Identity string
 }
 
 // QueryResults specifies the results against a target.
 type QueryResults struct {
 	// Source specifies the target that was tested.
 	Source source.Source
+	// Namespace is the rego namespace of the results.
+	// Empty value means the results are not split by namespace (see ByNamespace).
+	Namespace string
 	// Successes is the number of successes queries.
 	Successes int
+	// SuccessesByNamespace is the number of successes queries keyed by the rego namespace.
+	SuccessesByNamespace map[string]int
+	// Passes is the list of the passed rules, one per success (see Successes).
+	// The Message and the RuleDocLink of the result are always empty.
+	Passes []Result
 	// Failures is the list of failed queries.
 	Failures []Result
 	// Warnings is the list of warning queries.
 	Warnings []Result
 	// Exceptions is the list of exception queries.
 	Exceptions []Result
+	// Skipped is the list of queries skipped by the rule selection (see policy.RuleSelector).
+	Skipped []Result
+	// Baselined is the list of failures and warnings recorded in the baseline (see baseline.Apply).
+	// Baselined results don't fail the test.
+	Baselined []Result
+	// Errors is the list of queries failed to evaluate (e.g. rego runtime errors).
+	// The Message of the result is the error message.
+	Errors []Result
+	// Documents is the list of documents that were tested in the source.
+	Documents []Document
+	// CompilerKey identifies the policies (and data) which produced the results.
+	// Empty value means the key is unknown.
+	CompilerKey string
+}
+
+// Explanation describes how a rule produced the result.
+type Explanation struct {
+	// Steps is the list of the rule body expressions evaluated to produce the result, in evaluation order.
+	Steps []ExplanationStep
+}
+
+// ExplanationStep specifies an evaluated expression in the rule body.
+type ExplanationStep struct {
+	// File is the policy file defining the expression.
+	File string
+	// Line is the (1-based) line number of the expression in the policy file.
+	Line int
+	// Expression is the source text of the expression.
+	Expression string
+	// Bindings is the values of the variables referenced by the expression, keyed by the variable name.
+	Bindings map[string]interface{}
+	// Input is the values of the input paths referenced by the expression, keyed by the path
+	// (e.g. `input.spec.containers[0].image`).
+	Input map[string]interface{}
 }
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/ShieldGuard/sg/internal/engine"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+	"github.com/Azure/ShieldGuard/sg/internal/source"
+)
+
+// AdmissionOptions controls the admission webhook behavior.
+type AdmissionOptions struct {
+	// DenyOnErrors denies the request if any rule fails to evaluate.
+	// Otherwise, the evaluation errors are returned as warnings.
+	DenyOnErrors bool
+}
+
+type admissionHandler struct {
+	queryer engine.Queryer
+	opts    AdmissionOptions
+}
+
+// Admission creates the handler for the Kubernetes ValidatingAdmissionWebhook.
+// The object of each AdmissionReview request is queried with the queryer: failures deny the request,
+// and warnings are returned as the admission warnings.
+func Admission(queryer engine.Queryer, opts AdmissionOptions) http.Handler {
+	return &admissionHandler{
+		queryer: queryer,
+		opts:    opts,
+	}
+}
+
+func (h *admissionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var review admissionReview
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(&review); err != nil {
+		http.Error(w, fmt.Sprintf("decode admission review: %s", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "admission review request is required", http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.review(r.Context(), review.Request)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("review %s: %s", review.Request.UID, err), http.StatusInternalServerError)
+		return
+	}
+
+	apiVersion := review.APIVersion
+	if apiVersion == "" {
+		apiVersion = admissionReviewAPIVersion
+	}
+	writeJSON(w, http.StatusOK, admissionReview{
+		APIVersion: apiVersion,
+		Kind:       admissionReviewKind,
+		Response:   response,
+	})
+}
+
+func (h *admissionHandler) review(ctx context.Context, req *admissionRequest) (*admissionResponse, error) {
+	rv := &admissionResponse{
+		UID:     req.UID,
+		Allowed: true,
+	}
+
+	object := bytes.TrimSpace(req.Object)
+	if len(object) < 1 || bytes.Equal(object, []byte("null")) {
+		// no object to validate (e.g. DELETE requests)
+		return rv, nil
+	}
+
+	var configuration any
+	if err := json.Unmarshal(object, &configuration); err != nil {
+		return nil, fmt.Errorf("decode object: %w", err)
+	}
+	src, err := source.FromConfigurations(admissionSourceName(req), configuration)
+	if err != nil {
+		return nil, fmt.Errorf("load object: %w", err)
+	}
+
+	queryResults, err := h.queryer.Query(ctx, src)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	var denials []string
+	for _, r := range queryResults.Failures {
+		denials = append(denials, admissionResultMessage(r))
+	}
+	for _, r := range queryResults.Warnings {
+		rv.Warnings = append(rv.Warnings, admissionResultMessage(r))
+	}
+	for _, r := range queryResults.Errors {
+		message := fmt.Sprintf("[%s] failed to evaluate: %s", r.Rule.Query(), r.Message)
+		if h.opts.DenyOnErrors {
+			denials = append(denials, message)
+		} else {
+			rv.Warnings = append(rv.Warnings, message)
+		}
+	}
+
+	if len(denials) > 0 {
+		rv.Allowed = false
+		rv.Status = &admissionStatus{
+			Code:    http.StatusForbidden,
+			Reason:  "Forbidden",
+			Message: fmt.Sprintf("denied by ShieldGuard: %s", strings.Join(denials, "; ")),
+		}
+	}
+
+	return rv, nil
+}
+
+// admissionSourceName returns the source name of the request in `<kind>/[<namespace>/]<name>` form.
+// The request uid is used for objects without name (e.g. with generateName).
+func admissionSourceName(req *admissionRequest) string {
+	parts := []string{req.Kind.Kind}
+	if req.Namespace != "" {
+		parts = append(parts, req.Namespace)
+	}
+	if req.Name != "" {
+		parts = append(parts, req.Name)
+	} else {
+		parts = append(parts, req.UID)
+	}
+	return strings.Join(parts, "/")
+}
+
+func admissionResultMessage(r result.Result) string {
+	message := r.Message
+	if message == "" {
+		message = r.Rule.Name
+	}
+	return fmt.Sprintf("[%s] %s", r.Rule.Query(), message)
+}
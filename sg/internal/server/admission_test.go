@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/ShieldGuard/sg/internal/engine"
+)
+
+func testQueryer(t *testing.T) engine.Queryer {
+	queryer, err := engine.QueryWithPolicy([]string{"./testdata/policy"}).Complete()
+	assert.NoError(t, err)
+	return queryer
+}
+
+func postAdmissionReview(t *testing.T, server *httptest.Server, fixture string) admissionReview {
+	b, err := os.ReadFile(filepath.Join("testdata", "admission", fixture))
+	assert.NoError(t, err)
+
+	resp, err := server.Client().Post(server.URL, "application/json", bytes.NewReader(b))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var review admissionReview
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&review))
+	assert.Equal(t, admissionReviewAPIVersion, review.APIVersion)
+	assert.Equal(t, admissionReviewKind, review.Kind)
+	assert.NotNil(t, review.Response)
+	return review
+}
+
+func Test_Admission(t *testing.T) {
+	server := httptest.NewServer(Admission(testQueryer(t), AdmissionOptions{}))
+	defer server.Close()
+
+	t.Run("allowed", func(t *testing.T) {
+		response := postAdmissionReview(t, server, "pod-allowed.json").Response
+		assert.Equal(t, "705ab4f5-6393-11e8-b7cc-42010a800002", response.UID)
+		assert.True(t, response.Allowed)
+		assert.Nil(t, response.Status)
+		assert.Empty(t, response.Warnings)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		response := postAdmissionReview(t, server, "pod-denied.json").Response
+		assert.Equal(t, "705ab4f5-6393-11e8-b7cc-42010a800003", response.UID)
+		assert.False(t, response.Allowed)
+		if assert.NotNil(t, response.Status) {
+			assert.Equal(t, int32(http.StatusForbidden), response.Status.Code)
+			assert.Equal(
+				t,
+				"denied by ShieldGuard: [deny_privileged] container debug must not run in privileged mode",
+				response.Status.Message,
+			)
+		}
+		assert.Equal(t, []string{"[warn_latest_tag] container debug uses latest tag"}, response.Warnings)
+	})
+
+	t.Run("warned", func(t *testing.T) {
+		response := postAdmissionReview(t, server, "pod-warned.json").Response
+		assert.True(t, response.Allowed)
+		assert.Equal(t, []string{"[warn_latest_tag] container app uses latest tag"}, response.Warnings)
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		response := postAdmissionReview(t, server, "pod-deleted.json").Response
+		assert.Equal(t, "705ab4f5-6393-11e8-b7cc-42010a800005", response.UID)
+		assert.True(t, response.Allowed, "should not validate deleted objects")
+	})
+
+	t.Run("evaluation errors", func(t *testing.T) {
+		response := postAdmissionReview(t, server, "pod-error.json").Response
+		assert.True(t, response.Allowed)
+		if assert.Len(t, response.Warnings, 1) {
+			assert.True(t, strings.HasPrefix(response.Warnings[0], "[deny_conflict] failed to evaluate: "))
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		resp, err := server.Client().Get(server.URL)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+		for _, body := range []string{"not json", `{"apiVersion": "admission.k8s.io/v1", "kind": "AdmissionReview"}`} {
+			resp, err := server.Client().Post(server.URL, "application/json", strings.NewReader(body))
+			assert.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+}
+
+func Test_Admission_denyOnErrors(t *testing.T) {
+	server := httptest.NewServer(Admission(testQueryer(t), AdmissionOptions{DenyOnErrors: true}))
+	defer server.Close()
+
+	response := postAdmissionReview(t, server, "pod-error.json").Response
+	assert.False(t, response.Allowed)
+	if assert.NotNil(t, response.Status) {
+		assert.Contains(t, response.Status.Message, "[deny_conflict] failed to evaluate: ")
+	}
+	assert.Empty(t, response.Warnings)
+}
+
+func Test_admissionSourceName(t *testing.T) {
+	assert.Equal(t, "Pod/default/app", admissionSourceName(&admissionRequest{
+		UID:       "uid",
+		Kind:      groupVersionKind{Kind: "Pod"},
+		Name:      "app",
+		Namespace: "default",
+	}))
+	assert.Equal(t, "Namespace/foo", admissionSourceName(&admissionRequest{
+		UID:  "uid",
+		Kind: groupVersionKind{Kind: "Namespace"},
+		Name: "foo",
+	}))
+	assert.Equal(t, "Pod/default/uid", admissionSourceName(&admissionRequest{
+		UID:       "uid",
+		Kind:      groupVersionKind{Kind: "Pod"},
+		Namespace: "default",
+	}))
+}
@@ -0,0 +1,48 @@
+package server
+
+import "encoding/json"
+
+// Minimal types of the admission.k8s.io/v1 AdmissionReview API.
+// ref: https://kubernetes.io/docs/reference/access-authn-authz/extensible-admission-controllers/#request
+
+const (
+	admissionReviewAPIVersion = "admission.k8s.io/v1"
+	admissionReviewKind       = "AdmissionReview"
+)
+
+type admissionReview struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Request    *admissionRequest  `json:"request,omitempty"`
+	Response   *admissionResponse `json:"response,omitempty"`
+}
+
+type groupVersionKind struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+type admissionRequest struct {
+	UID       string           `json:"uid"`
+	Kind      groupVersionKind `json:"kind"`
+	Name      string           `json:"name,omitempty"`
+	Namespace string           `json:"namespace,omitempty"`
+	Operation string           `json:"operation"`
+	Object    json.RawMessage  `json:"object,omitempty"`
+	OldObject json.RawMessage  `json:"oldObject,omitempty"`
+	DryRun    *bool            `json:"dryRun,omitempty"`
+}
+
+type admissionStatus struct {
+	Code    int32  `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type admissionResponse struct {
+	UID      string           `json:"uid"`
+	Allowed  bool             `json:"allowed"`
+	Status   *admissionStatus `json:"status,omitempty"`
+	Warnings []string         `json:"warnings,omitempty"`
+}
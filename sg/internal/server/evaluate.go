@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+
+	"github.com/Azure/ShieldGuard/sg/internal/engine"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+	"github.com/Azure/ShieldGuard/sg/internal/result/presenter"
+	"github.com/Azure/ShieldGuard/sg/internal/source"
+)
+
+const (
+	// evaluateTargetParam is the query parameter (or multipart form field) of the target name.
+	evaluateTargetParam = "target"
+	// evaluateNameParam is the query parameter of the source name for non-multipart requests.
+	evaluateNameParam = "name"
+)
+
+// errUnsupportedMediaType is returned when the request content type is not supported.
+var errUnsupportedMediaType = errors.New("unsupported media type")
+
+type evaluateHandler struct {
+	queryers map[string]engine.Queryer
+}
+
+// Evaluate creates the handler for evaluating configurations with the queryers keyed by the target name.
+//
+// The target is specified by the `target` query parameter (or multipart form field). The configurations
+// are read from:
+//
+//   - the request body in JSON (`application/json`) or YAML (`application/yaml`), which can contain
+//     multiple documents. The source name can be specified by the `name` query parameter;
+//   - the files of a `multipart/form-data` request, each file is a source named by the file name.
+//
+// The results are written in the JSON presenter format.
+func Evaluate(queryers map[string]engine.Queryer) http.Handler {
+	return &evaluateHandler{queryers: queryers}
+}
+
+func (h *evaluateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+	sources, err := readEvaluateSources(r)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, errUnsupportedMediaType) {
+			statusCode = http.StatusUnsupportedMediaType
+		}
+		http.Error(w, fmt.Sprintf("read configurations: %s", err), statusCode)
+		return
+	}
+
+	targetName := r.FormValue(evaluateTargetParam)
+	if targetName == "" {
+		http.Error(w, "target is required", http.StatusBadRequest)
+		return
+	}
+	queryer, ok := h.queryers[targetName]
+	if !ok {
+		http.Error(w, fmt.Sprintf("target %q is not found", targetName), http.StatusNotFound)
+		return
+	}
+
+	queryResultsList, err := evaluateSources(r.Context(), queryer, sources)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	// NOTE: the status code has been sent, nothing to do if writing fails
+	_ = presenter.JSON(queryResultsList).WriteQueryResultTo(w)
+}
+
+func evaluateSources(ctx context.Context, queryer engine.Queryer, sources []source.Source) ([]result.QueryResults, error) {
+	rv := make([]result.QueryResults, 0, len(sources))
+	for _, s := range sources {
+		queryResults, err := queryer.Query(ctx, s)
+		if err != nil {
+			return nil, fmt.Errorf("query %s: %w", s.Name(), err)
+		}
+		rv = append(rv, queryResults)
+	}
+	return rv, nil
+}
+
+func readEvaluateSources(r *http.Request) ([]source.Source, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedMediaType, err)
+	}
+
+	var defaultName string
+	switch mediaType {
+	case "multipart/form-data":
+		return readMultipartSources(r)
+	case "application/json":
+		defaultName = "request.json"
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		defaultName = "request.yaml"
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedMediaType, mediaType)
+	}
+
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	name := r.URL.Query().Get(evaluateNameParam)
+	if name == "" {
+		name = defaultName
+	}
+	s, err := source.FromContent(name, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return []source.Source{s}, nil
+}
+
+func readMultipartSources(r *http.Request) ([]source.Source, error) {
+	if err := r.ParseMultipartForm(maxRequestBodySize); err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(r.MultipartForm.File))
+	for field := range r.MultipartForm.File {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var rv []source.Source
+	for _, field := range fields {
+		for _, fh := range r.MultipartForm.File[field] {
+			f, err := fh.Open()
+			if err != nil {
+				return nil, fmt.Errorf("open %q: %w", fh.Filename, err)
+			}
+			content, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("read %q: %w", fh.Filename, err)
+			}
+
+			s, err := source.FromContent(fh.Filename, content)
+			if err != nil {
+				return nil, err
+			}
+			rv = append(rv, s)
+		}
+	}
+	if len(rv) < 1 {
+		return nil, fmt.Errorf("no files found in the multipart form")
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/ShieldGuard/sg/internal/engine"
+)
+
+const testPrivilegedPodYAML = `apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+spec:
+  containers:
+  - name: debug
+    image: busybox:latest
+    securityContext:
+      privileged: true
+`
+
+type evaluateResultsObj struct {
+	Filename string `json:"filename"`
+	Success  int    `json:"success"`
+	Failures []struct {
+		Query   string `json:"query"`
+		Message string `json:"message"`
+	} `json:"failures"`
+	Warnings []struct {
+		Message string `json:"message"`
+	} `json:"warnings"`
+}
+
+func postEvaluate(
+	t *testing.T,
+	server *httptest.Server,
+	path string,
+	contentType string,
+	body []byte,
+) (int, []evaluateResultsObj) {
+	resp, err := server.Client().Post(server.URL+path, contentType, bytes.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var rv []evaluateResultsObj
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&rv))
+	return resp.StatusCode, rv
+}
+
+func Test_Evaluate(t *testing.T) {
+	server := httptest.NewServer(Evaluate(map[string]engine.Queryer{
+		"kubernetes": testQueryer(t),
+	}))
+	defer server.Close()
+
+	t.Run("yaml", func(t *testing.T) {
+		body := testPrivilegedPodYAML + "---\n" + strings.ReplaceAll(testPrivilegedPodYAML, "privileged: true", "privileged: false")
+		statusCode, results := postEvaluate(t, server, "/?target=kubernetes", "application/yaml", []byte(body))
+		assert.Equal(t, http.StatusOK, statusCode)
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, "request.yaml", results[0].Filename)
+			if assert.Len(t, results[0].Failures, 1, "only the first document is privileged") {
+				assert.Equal(t, "data.main.deny_privileged", results[0].Failures[0].Query)
+				assert.Equal(t, "container debug must not run in privileged mode", results[0].Failures[0].Message)
+			}
+			assert.Len(t, results[0].Warnings, 2)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		body := `{"kind": "Pod", "metadata": {"name": "app"}, "spec": {"containers": [{"name": "app", "image": "nginx:1.27"}]}}`
+		statusCode, results := postEvaluate(t, server, "/?target=kubernetes&name=app.json", "application/json", []byte(body))
+		assert.Equal(t, http.StatusOK, statusCode)
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, "app.json", results[0].Filename)
+			assert.Empty(t, results[0].Failures)
+			assert.Empty(t, results[0].Warnings)
+			assert.Positive(t, results[0].Success)
+		}
+	})
+
+	t.Run("multipart", func(t *testing.T) {
+		body := new(bytes.Buffer)
+		mw := multipart.NewWriter(body)
+		assert.NoError(t, mw.WriteField("target", "kubernetes"))
+		for name, content := range map[string]string{
+			"debug.yaml": testPrivilegedPodYAML,
+			"app.json":   `{"kind": "Pod", "metadata": {"name": "app"}, "spec": {"containers": []}}`,
+		} {
+			fw, err := mw.CreateFormFile("files", name)
+			assert.NoError(t, err)
+			_, err = fw.Write([]byte(content))
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, mw.Close())
+
+		statusCode, results := postEvaluate(t, server, "/", mw.FormDataContentType(), body.Bytes())
+		assert.Equal(t, http.StatusOK, statusCode)
+		if assert.Len(t, results, 2) {
+			filenames := []string{results[0].Filename, results[1].Filename}
+			assert.ElementsMatch(t, []string{"app.json", "debug.yaml"}, filenames)
+			for _, r := range results {
+				if r.Filename == "debug.yaml" {
+					assert.Len(t, r.Failures, 1)
+				} else {
+					assert.Empty(t, r.Failures)
+				}
+			}
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		resp, err := server.Client().Get(server.URL + "/?target=kubernetes")
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+		cases := []struct {
+			path               string
+			contentType        string
+			body               string
+			expectedStatusCode int
+		}{
+			{"/", "application/yaml", testPrivilegedPodYAML, http.StatusBadRequest},
+			{"/?target=foobar", "application/yaml", testPrivilegedPodYAML, http.StatusNotFound},
+			{"/?target=kubernetes", "text/plain", testPrivilegedPodYAML, http.StatusUnsupportedMediaType},
+			{"/?target=kubernetes", "", testPrivilegedPodYAML, http.StatusUnsupportedMediaType},
+			{"/?target=kubernetes", "application/json", "{", http.StatusBadRequest},
+		}
+		for _, c := range cases {
+			statusCode, _ := postEvaluate(t, server, c.path, c.contentType, []byte(c.body))
+			assert.Equal(t, c.expectedStatusCode, statusCode, "%s %s", c.path, c.contentType)
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// maxRequestBodySize is the size limit of the request body.
+const maxRequestBodySize = 10 << 20
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	// NOTE: the status code has been sent, nothing to do if encoding fails
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Healthz creates the handler for the health check.
+func Healthz() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Azure/ShieldGuard/sg/internal/engine"
+)
+
+type targetStatusObj struct {
+	CompilerKey     string     `json:"compiler_key"`
+	LoadedAt        time.Time  `json:"loaded_at"`
+	LastReloadAt    *time.Time `json:"last_reload_at,omitempty"`
+	LastReloadError string     `json:"last_reload_error,omitempty"`
+}
+
+type statusObj struct {
+	Targets map[string]targetStatusObj `json:"targets"`
+}
+
+func asTargetStatusObj(status engine.ReloadStatus) targetStatusObj {
+	rv := targetStatusObj{
+		CompilerKey: status.CompilerKey,
+		LoadedAt:    status.LoadedAt,
+	}
+	if !status.LastReloadAt.IsZero() {
+		rv.LastReloadAt = &status.LastReloadAt
+	}
+	if status.LastReloadError != nil {
+		rv.LastReloadError = status.LastReloadError.Error()
+	}
+	return rv
+}
+
+// Status creates the handler reporting the active policies of the queryers keyed by the target name.
+// Failed reloads are reported with the compile errors, while the previous policies keep serving.
+func Status(queryers map[string]*engine.ReloadableQueryer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		rv := statusObj{Targets: make(map[string]targetStatusObj, len(queryers))}
+		for name, q := range queryers {
+			rv.Targets[name] = asTargetStatusObj(q.Status())
+		}
+		writeJSON(w, http.StatusOK, rv)
+	})
+}
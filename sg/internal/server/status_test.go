@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/ShieldGuard/sg/internal/engine"
+)
+
+func Test_Status(t *testing.T) {
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "policy.rego")
+	assert.NoError(t, os.WriteFile(policyFile, []byte("package main\n\ndeny_foo[msg] { msg := \"foo\" }\n"), 0o644))
+
+	q, err := engine.NewReloadableQueryer(func() *engine.QueryerBuilder {
+		return engine.QueryWithPolicy([]string{dir})
+	}, []string{dir})
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(Status(map[string]*engine.ReloadableQueryer{"kubernetes": q}))
+	defer server.Close()
+
+	getStatus := func(t *testing.T) map[string]map[string]interface{} {
+		resp, err := server.Client().Get(server.URL)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var status struct {
+			Targets map[string]map[string]interface{} `json:"targets"`
+		}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+		return status.Targets
+	}
+
+	targets := getStatus(t)
+	if assert.Contains(t, targets, "kubernetes") {
+		assert.Equal(t, q.Status().CompilerKey, targets["kubernetes"]["compiler_key"])
+		assert.NotContains(t, targets["kubernetes"], "last_reload_at")
+		assert.NotContains(t, targets["kubernetes"], "last_reload_error")
+	}
+
+	assert.NoError(t, os.WriteFile(policyFile, []byte("package main\n\ndeny_foo[msg] {\n"), 0o644))
+	assert.Error(t, q.Reload())
+
+	targets = getStatus(t)
+	if assert.Contains(t, targets, "kubernetes") {
+		assert.Equal(t, q.Status().CompilerKey, targets["kubernetes"]["compiler_key"])
+		assert.Contains(t, targets["kubernetes"], "last_reload_at")
+		assert.NotEmpty(t, targets["kubernetes"]["last_reload_error"], "should expose the compile errors")
+	}
+
+	resp, err := server.Client().Post(server.URL, "application/json", nil)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
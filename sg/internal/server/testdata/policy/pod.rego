@@ -0,0 +1,25 @@
+package main
+
+deny_privileged[msg] {
+	input.kind == "Pod"
+	container := input.spec.containers[_]
+	container.securityContext.privileged == true
+
+	msg := sprintf("container %s must not run in privileged mode", [container.name])
+}
+
+warn_latest_tag[msg] {
+	input.kind == "Pod"
+	container := input.spec.containers[_]
+	endswith(container.image, ":latest")
+
+	msg := sprintf("container %s uses latest tag", [container.name])
+}
+
+deny_conflict = "conflict" {
+	input.metadata.labels.conflict == "true"
+}
+
+deny_conflict = "other conflict" {
+	input.metadata.labels.conflict == "true"
+}
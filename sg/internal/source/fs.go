@@ -7,20 +7,31 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/open-policy-agent/conftest/parser"
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/util"
+	"gopkg.in/yaml.v3"
 )
 
 type fsSource struct {
-	// filePath is the full path of the read file.
+	// filePath is the path of the read file, relative to the context root if specified.
 	filePath string
+	// fullPath is the full path of the read file.
+	fullPath string
 	// configurations is the loaded configurations.
 	configurations []ast.Value
+
+	// documentNodesOnce guards the lazy parsing of documentNodes.
+	documentNodesOnce sync.Once
+	// documentNodes is the parsed document nodes with position information.
+	// It is nil if the file type doesn't support position resolving.
+	documentNodes []*yaml.Node
 }
 
 var _ Source = (*fsSource)(nil)
+var _ PositionResolver = (*fsSource)(nil)
 
 func (s *fsSource) Name() string {
 	return s.filePath
@@ -30,6 +41,37 @@ func (s *fsSource) ParsedConfigurations() ([]ast.Value, error) {
 	return s.configurations, nil
 }
 
+func (s *fsSource) ResolvePosition(documentIndex int, path []any) (Position, bool) {
+	s.documentNodesOnce.Do(func() {
+		nodes, err := parseDocumentNodesFromFile(s.fullPath)
+		if err != nil {
+			// position is best effort, ignore the error
+			return
+		}
+		if len(nodes) != len(s.configurations) {
+			// unexpected document layout, skip to avoid reporting wrong positions
+			return
+		}
+		s.documentNodes = nodes
+	})
+
+	if documentIndex < 0 || documentIndex >= len(s.documentNodes) {
+		return Position{}, false
+	}
+
+	node, ok := lookupNode(s.documentNodes[documentIndex], path)
+	if !ok {
+		return Position{}, false
+	}
+
+	return Position{Line: node.Line, Column: node.Column}, true
+}
+
+// FileSupported tells if the file can be loaded as a source by its extension.
+func FileSupported(path string) bool {
+	return parser.FileSupported(path)
+}
+
 func relativeToContextRootFn(contextRoot string) func(string) string {
 	if contextRoot == "" {
 		return func(path string) string {
@@ -60,6 +102,27 @@ func parseRawConfiguration(s any) (ast.Value, error) {
 	return rv, nil
 }
 
+// parseRawConfigurations parses the raw configurations of a file, which is a list for multi-document files.
+func parseRawConfigurations(c any) ([]ast.Value, error) {
+	var subConfigurations []any
+	if cc, ok := c.([]any); ok {
+		subConfigurations = cc
+	} else {
+		subConfigurations = []any{c}
+	}
+
+	var parsedConfigurations []ast.Value
+	for _, rawConfiguration := range subConfigurations {
+		parsedConfiguration, err := parseRawConfiguration(rawConfiguration)
+		if err != nil {
+			return nil, fmt.Errorf("parse raw configuration: %w", err)
+		}
+		parsedConfigurations = append(parsedConfigurations, parsedConfiguration)
+	}
+
+	return parsedConfigurations, nil
+}
+
 // ref: https://github.com/open-policy-agent/conftest/blob/f18b7bbde2fdbd766c8348dff3a0a24792eb98c7/runner/test.go#L99
 func loadSourceFromPaths(contextRoot string, paths []string) ([]Source, error) {
 	// when contextRoot specified, all paths must be relative to contextRoot.
@@ -96,7 +159,7 @@ func loadSourceFromPaths(contextRoot string, paths []string) ([]Source, error) {
 			return nil
 		}
 
-		if parser.FileSupported(path) {
+		if FileSupported(path) {
 			if strings.EqualFold(filepath.Ext(path)[1:], parser.JSON) {
 				jsonFiles = append(jsonFiles, path)
 			} else {
@@ -136,25 +199,14 @@ func loadSourceFromPaths(contextRoot string, paths []string) ([]Source, error) {
 
 	var rv []Source
 	for _, filePath := range filePathsSorted {
-		c := configurations[filePath]
-		var subConfigurations []any
-		if cc, ok := c.([]any); ok {
-			subConfigurations = cc
-		} else {
-			subConfigurations = []any{c}
-		}
-
-		var parsedConfigurations []ast.Value
-		for _, rawConfiguration := range subConfigurations {
-			parsedConfiguration, err := parseRawConfiguration(rawConfiguration)
-			if err != nil {
-				return nil, fmt.Errorf("parse raw configuration: %w", err)
-			}
-			parsedConfigurations = append(parsedConfigurations, parsedConfiguration)
+		parsedConfigurations, err := parseRawConfigurations(configurations[filePath])
+		if err != nil {
+			return nil, err
 		}
 
 		rv = append(rv, &fsSource{
 			filePath:       relativeToContextRoot(filePath),
+			fullPath:       filePath,
 			configurations: parsedConfigurations,
 		})
 	}
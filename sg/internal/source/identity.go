@@ -0,0 +1,50 @@
+package source
+
+import (
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func lookupString(v ast.Value, path ...string) (string, bool) {
+	for _, key := range path {
+		obj, ok := v.(ast.Object)
+		if !ok {
+			return "", false
+		}
+		term := obj.Get(ast.StringTerm(key))
+		if term == nil {
+			return "", false
+		}
+		v = term.Value
+	}
+
+	s, ok := v.(ast.String)
+	if !ok {
+		return "", false
+	}
+	return string(s), true
+}
+
+// DocumentIdentity resolves a stable identity of a parsed document.
+// For Kubernetes resources, the identity is in the form of `<kind>/<namespace>/<name>`,
+// or `<kind>/<name>` for cluster scoped resources.
+// It returns empty string if the identity cannot be resolved.
+func DocumentIdentity(configuration ast.Value) string {
+	kind, ok := lookupString(configuration, "kind")
+	if !ok || kind == "" {
+		return ""
+	}
+	name, ok := lookupString(configuration, "metadata", "name")
+	if !ok || name == "" {
+		return ""
+	}
+
+	parts := []string{kind}
+	if namespace, ok := lookupString(configuration, "metadata", "namespace"); ok && namespace != "" {
+		parts = append(parts, namespace)
+	}
+	parts = append(parts, name)
+
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,49 @@
+package source
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DocumentIdentity(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    `{"kind": "Deployment", "metadata": {"name": "foo", "namespace": "bar"}}`,
+			expected: "Deployment/bar/foo",
+		},
+		{
+			input:    `{"kind": "Namespace", "metadata": {"name": "foo"}}`,
+			expected: "Namespace/foo",
+		},
+		{
+			input:    `{"kind": "Deployment", "metadata": {"generateName": "foo-"}}`,
+			expected: "",
+		},
+		{
+			input:    `{"metadata": {"name": "foo"}}`,
+			expected: "",
+		},
+		{
+			input:    `{"kind": 1, "metadata": {"name": "foo"}}`,
+			expected: "",
+		},
+		{
+			input:    `["foo"]`,
+			expected: "",
+		},
+	}
+
+	for idx := range cases {
+		c := cases[idx]
+		t.Run(fmt.Sprintf("case #%d", idx), func(t *testing.T) {
+			v := ast.MustParseTerm(c.input).Value
+			assert.Equal(t, c.expected, DocumentIdentity(v))
+		})
+	}
+}
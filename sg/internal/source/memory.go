@@ -0,0 +1,76 @@
+package source
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/open-policy-agent/conftest/parser"
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// memorySource is a source with configurations loaded in memory (e.g. from a request body).
+type memorySource struct {
+	name           string
+	configurations []ast.Value
+}
+
+var _ Source = (*memorySource)(nil)
+
+func (s *memorySource) Name() string {
+	return s.name
+}
+
+func (s *memorySource) ParsedConfigurations() ([]ast.Value, error) {
+	return s.configurations, nil
+}
+
+// FromConfigurations creates a source with the raw configurations, which are values
+// decoded from JSON / YAML documents.
+func FromConfigurations(name string, configurations ...any) (Source, error) {
+	rv := &memorySource{name: name}
+	for _, c := range configurations {
+		parsed, err := parseRawConfiguration(c)
+		if err != nil {
+			return nil, err
+		}
+		rv.configurations = append(rv.configurations, parsed)
+	}
+
+	return rv, nil
+}
+
+// FromContent creates a source by parsing the content of a file. The parser is resolved from
+// the name (e.g. `deployment.yaml`), and names without extension are parsed as YAML.
+func FromContent(name string, content []byte) (Source, error) {
+	var (
+		p   parser.Parser
+		err error
+	)
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case "":
+		p, err = parser.New(parser.YAML)
+	case "." + parser.JSON:
+		// parse json files with jsonc to allow comments
+		p, err = parser.New(parser.JSONC)
+	default:
+		p, err = parser.NewFromPath(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolve parser for %q: %w", name, err)
+	}
+
+	var c any
+	if err := p.Unmarshal(content, &c); err != nil {
+		return nil, fmt.Errorf("parse %q: %w", name, err)
+	}
+	configurations, err := parseRawConfigurations(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memorySource{
+		name:           name,
+		configurations: configurations,
+	}, nil
+}
@@ -0,0 +1,97 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FromConfigurations(t *testing.T) {
+	s, err := FromConfigurations(
+		"Pod/default/foo",
+		map[string]any{
+			"kind":     "Pod",
+			"metadata": map[string]any{"name": "foo", "namespace": "default"},
+		},
+		map[string]any{"name": "bar"},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "Pod/default/foo", s.Name())
+
+	configurations, err := s.ParsedConfigurations()
+	assert.NoError(t, err)
+	if assert.Len(t, configurations, 2) {
+		assert.Equal(t, "Pod/default/foo", DocumentIdentity(configurations[0]))
+		name, _ := lookupString(configurations[1], "name")
+		assert.Equal(t, "bar", name)
+	}
+
+	_, err = FromConfigurations("invalid", func() {})
+	assert.Error(t, err)
+}
+
+func Test_FromContent(t *testing.T) {
+	cases := []struct {
+		name                string
+		content             string
+		expectedIdentities  []string
+		expectedErrContains string
+	}{
+		{
+			name: "deployment+service.yaml",
+			content: `kind: Deployment
+metadata:
+  name: foo
+---
+kind: Service
+metadata:
+  name: foo
+`,
+			expectedIdentities: []string{"Deployment/foo", "Service/foo"},
+		},
+		{
+			name:               "request",
+			content:            "kind: Pod\nmetadata:\n  name: foo\n",
+			expectedIdentities: []string{"Pod/foo"},
+		},
+		{
+			name: "pod.json",
+			content: `{
+  // comments are allowed
+  "kind": "Pod",
+  "metadata": {"name": "foo"}
+}`,
+			expectedIdentities: []string{"Pod/foo"},
+		},
+		{
+			name:                "invalid.json",
+			content:             "{",
+			expectedErrContains: "parse",
+		},
+		{
+			name:                "pod.unknown",
+			content:             "kind: Pod",
+			expectedErrContains: "resolve parser",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := FromContent(c.name, []byte(c.content))
+			if c.expectedErrContains != "" {
+				assert.ErrorContains(t, err, c.expectedErrContains)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.name, s.Name())
+
+			configurations, err := s.ParsedConfigurations()
+			assert.NoError(t, err)
+			var identities []string
+			for _, configuration := range configurations {
+				identities = append(identities, DocumentIdentity(configuration))
+			}
+			assert.Equal(t, c.expectedIdentities, identities)
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package source
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position specifies a position in the source file.
+type Position struct {
+	// Line is the line number (1-based) of the position.
+	Line int
+	// Column is the column number (1-based) of the position.
+	Column int
+}
+
+// PositionResolver is implemented by sources which can resolve the position of a node.
+type PositionResolver interface {
+	// ResolvePosition resolves the position of the node specified by the path
+	// in the document at the given index. The path segments are either string keys or int indexes.
+	// It returns false if the position cannot be resolved.
+	ResolvePosition(documentIndex int, path []any) (Position, bool)
+}
+
+// ParsePath parses a JSON path to path segments.
+// Following forms are supported:
+//
+//   - dotted string: "spec.containers[0].image", `metadata.labels["app/name"]`.
+//     The leading "$." or "input." is optional.
+//   - list of string keys and int indexes: ["spec", "containers", 0, "image"]
+func ParsePath(v interface{}) ([]any, error) {
+	switch vv := v.(type) {
+	case string:
+		return parsePathString(vv)
+	case []interface{}:
+		rv := make([]any, 0, len(vv))
+		for _, segment := range vv {
+			switch s := segment.(type) {
+			case string:
+				rv = append(rv, s)
+			case json.Number:
+				idx, err := strconv.Atoi(s.String())
+				if err != nil {
+					return nil, fmt.Errorf("invalid path index %q: %w", s, err)
+				}
+				rv = append(rv, idx)
+			case float64:
+				rv = append(rv, int(s))
+			case int:
+				rv = append(rv, s)
+			default:
+				return nil, fmt.Errorf("unsupported path segment %v (%T)", segment, segment)
+			}
+		}
+		return rv, nil
+	default:
+		return nil, fmt.Errorf("unsupported path %v (%T)", v, v)
+	}
+}
+
+func parsePathString(s string) ([]any, error) {
+	for _, prefix := range []string{"$.", "input."} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+
+	var rv []any
+	var key strings.Builder
+	flushKey := func() {
+		if key.Len() > 0 {
+			rv = append(rv, key.String())
+			key.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '.':
+			flushKey()
+		case '[':
+			flushKey()
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unclosed bracket", s)
+			}
+			inner := s[i+1 : i+end]
+			i += end
+			if unquoted, err := strconv.Unquote(inner); err == nil {
+				rv = append(rv, unquoted)
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: invalid index %q", s, inner)
+			}
+			rv = append(rv, idx)
+		default:
+			key.WriteByte(c)
+		}
+	}
+	flushKey()
+
+	if len(rv) == 0 {
+		return nil, fmt.Errorf("invalid path %q: empty path", s)
+	}
+
+	return rv, nil
+}
+
+// lookupNode looks up the node by path. For mapping entries, the key node is returned.
+func lookupNode(n *yaml.Node, path []any) (*yaml.Node, bool) {
+	for idx, segment := range path {
+		if n == nil {
+			return nil, false
+		}
+		if n.Kind == yaml.AliasNode {
+			n = n.Alias
+		}
+
+		switch s := segment.(type) {
+		case string:
+			if n.Kind != yaml.MappingNode {
+				return nil, false
+			}
+			var found *yaml.Node
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value != s {
+					continue
+				}
+				if idx == len(path)-1 {
+					return n.Content[i], true
+				}
+				found = n.Content[i+1]
+				break
+			}
+			if found == nil {
+				return nil, false
+			}
+			n = found
+		case int:
+			if n.Kind != yaml.SequenceNode || s < 0 || s >= len(n.Content) {
+				return nil, false
+			}
+			n = n.Content[s]
+		default:
+			return nil, false
+		}
+	}
+
+	return n, n != nil
+}
+
+func shiftNodeLines(n *yaml.Node, offset int) {
+	if n == nil || offset == 0 {
+		return
+	}
+	n.Line += offset
+	for _, c := range n.Content {
+		shiftNodeLines(c, offset)
+	}
+}
+
+func parseDocumentNode(content []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		// empty document
+		return nil, nil
+	}
+	return doc.Content[0], nil
+}
+
+// flattenDocumentNodes flattens the top level sequence as documents,
+// which matches the behavior of loadSourceFromPaths.
+func flattenDocumentNodes(root *yaml.Node) []*yaml.Node {
+	if root != nil && root.Kind == yaml.SequenceNode {
+		return root.Content
+	}
+	return []*yaml.Node{root}
+}
+
+// parseYAMLDocumentNodes parses the YAML content into document nodes.
+// The sub documents are separated in the same way as conftest's YAML parser.
+// ref: https://github.com/open-policy-agent/conftest/blob/v0.55.0/parser/yaml/yaml.go
+func parseYAMLDocumentNodes(content []byte) ([]*yaml.Node, error) {
+	linebreak := "\n"
+	if bytes.Contains(content, []byte("\r\n---\r\n")) {
+		linebreak = "\r\n"
+	}
+	separator := []byte(linebreak + "---" + linebreak)
+
+	subDocuments := bytes.Split(content, separator)
+	if len(subDocuments) == 1 {
+		root, err := parseDocumentNode(content)
+		if err != nil {
+			return nil, err
+		}
+		return flattenDocumentNodes(root), nil
+	}
+
+	var rv []*yaml.Node
+	lineOffset := 0
+	for _, subDocument := range subDocuments {
+		root, err := parseDocumentNode(subDocument)
+		if err != nil {
+			return nil, err
+		}
+		shiftNodeLines(root, lineOffset)
+		rv = append(rv, root)
+
+		lineOffset += bytes.Count(subDocument, []byte("\n")) + bytes.Count(separator, []byte("\n"))
+	}
+
+	return rv, nil
+}
+
+// blankJSONComments replaces the comments in JSONC content with spaces,
+// so the content can be parsed as JSON (YAML) while keeping the positions.
+func blankJSONComments(content []byte) []byte {
+	rv := make([]byte, len(content))
+	copy(rv, content)
+
+	inString := false
+	for i := 0; i < len(rv); i++ {
+		c := rv[i]
+		if inString {
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(rv) && rv[i+1] == '/':
+			for ; i < len(rv) && rv[i] != '\n'; i++ {
+				rv[i] = ' '
+			}
+		case c == '/' && i+1 < len(rv) && rv[i+1] == '*':
+			rv[i], rv[i+1] = ' ', ' '
+			for i += 2; i < len(rv); i++ {
+				if rv[i] == '*' && i+1 < len(rv) && rv[i+1] == '/' {
+					rv[i], rv[i+1] = ' ', ' '
+					i++
+					break
+				}
+				if rv[i] != '\n' {
+					rv[i] = ' '
+				}
+			}
+		}
+	}
+
+	return rv
+}
+
+// parseDocumentNodesFromFile parses the file into document nodes.
+// Only JSON and YAML files are supported.
+func parseDocumentNodesFromFile(filePath string) ([]*yaml.Node, error) {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+		return nil, fmt.Errorf("unsupported file type %q", ext)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if ext == ".json" {
+		// NOTE: raw tabs can only appear as whitespaces in valid JSON content,
+		//       we replace them with spaces as YAML parser doesn't allow tabs for indentation.
+		content = bytes.ReplaceAll(blankJSONComments(content), []byte("\t"), []byte(" "))
+		root, err := parseDocumentNode(content)
+		if err != nil {
+			return nil, err
+		}
+		return flattenDocumentNodes(root), nil
+	}
+
+	return parseYAMLDocumentNodes(content)
+}
@@ -0,0 +1,149 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParsePath(t *testing.T) {
+	cases := []struct {
+		input     interface{}
+		expected  []any
+		expectErr bool
+	}{
+		{
+			input:    "spec.containers[0].image",
+			expected: []any{"spec", "containers", 0, "image"},
+		},
+		{
+			input:    "$.spec.replicas",
+			expected: []any{"spec", "replicas"},
+		},
+		{
+			input:    `input.metadata.labels["app/name"]`,
+			expected: []any{"metadata", "labels", "app/name"},
+		},
+		{
+			input:    []interface{}{"spec", "containers", json.Number("1"), float64(2), 3},
+			expected: []any{"spec", "containers", 1, 2, 3},
+		},
+		{
+			input:     "spec.containers[0",
+			expectErr: true,
+		},
+		{
+			input:     "spec.containers[foo]",
+			expectErr: true,
+		},
+		{
+			input:     "",
+			expectErr: true,
+		},
+		{
+			input:     []interface{}{true},
+			expectErr: true,
+		},
+		{
+			input:     123,
+			expectErr: true,
+		},
+	}
+
+	for idx := range cases {
+		c := cases[idx]
+		t.Run(fmt.Sprintf("case #%d", idx), func(t *testing.T) {
+			actual, err := ParsePath(c.input)
+			if c.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_blankJSONComments(t *testing.T) {
+	input := "{\n  \"a\": \"//not a comment\", // comment\n  /* multi\nline */ \"b\": 1\n}"
+	expected := "{\n  \"a\": \"//not a comment\",           \n          \n        \"b\": 1\n}"
+	assert.Equal(t, expected, string(blankJSONComments([]byte(input))))
+}
+
+func Test_fsSource_ResolvePosition(t *testing.T) {
+	sources, err := loadSourceFromPaths("./testdata", []string{"./testdata/sample"})
+	assert.NoError(t, err)
+
+	sourcesByName := map[string]Source{}
+	for _, s := range sources {
+		sourcesByName[s.Name()] = s
+	}
+
+	cases := []struct {
+		source        string
+		documentIndex int
+		path          []any
+		expected      Position
+		expectFound   bool
+	}{
+		{
+			source:        "sample/deployment+service.yaml",
+			documentIndex: 0,
+			path:          []any{"spec", "template", "spec", "containers", 0, "image"},
+			expected:      Position{Line: 17, Column: 9},
+			expectFound:   true,
+		},
+		{
+			source:        "sample/deployment+service.yaml",
+			documentIndex: 1,
+			path:          []any{"spec", "ports", 0},
+			expected:      Position{Line: 28, Column: 5},
+			expectFound:   true,
+		},
+		{
+			source:        "sample/deployment+service.yaml",
+			documentIndex: 1,
+			path:          []any{"spec", "type"},
+			expected:      Position{Line: 26, Column: 3},
+			expectFound:   true,
+		},
+		{
+			source:        "sample/deployment+service.yaml",
+			documentIndex: 1,
+			path:          []any{"spec", "replicas"},
+			expectFound:   false,
+		},
+		{
+			source:        "sample/deployment+service.yaml",
+			documentIndex: 2,
+			path:          []any{"spec"},
+			expectFound:   false,
+		},
+		{
+			source:        "sample/template.json",
+			documentIndex: 0,
+			path:          []any{"resources"},
+			expected:      Position{Line: 6, Column: 5},
+			expectFound:   true,
+		},
+	}
+
+	for idx := range cases {
+		c := cases[idx]
+		t.Run(fmt.Sprintf("case #%d", idx), func(t *testing.T) {
+			s, ok := sourcesByName[c.source]
+			assert.True(t, ok)
+
+			positionResolver, ok := s.(PositionResolver)
+			assert.True(t, ok)
+
+			actual, found := positionResolver.ResolvePosition(c.documentIndex, c.path)
+			assert.Equal(t, c.expectFound, found)
+			if c.expectFound {
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package waiver
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// globSeparator is the separator of the segments in a glob pattern.
+const globSeparator = "/"
+
+// globAnySegments is the glob segment matching zero or more segments.
+const globAnySegments = "**"
+
+// validateGlob validates the glob pattern (see matchGlob).
+func validateGlob(pattern string) error {
+	for _, segment := range strings.Split(pattern, globSeparator) {
+		if segment == globAnySegments {
+			continue
+		}
+		if strings.Contains(segment, globAnySegments) {
+			return fmt.Errorf("invalid pattern %q: %s must be a whole path segment", pattern, globAnySegments)
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchGlob tells if the slash separated name matches the glob pattern.
+// Besides the path.Match syntax in each segment, a `**` segment matches zero or more segments,
+// e.g. `manifests/**/*.yaml` matches both `manifests/a.yaml` and `manifests/apps/b/c.yaml`.
+//
+// NOTE: the pattern is expected to be validated by validateGlob.
+func matchGlob(pattern string, name string) bool {
+	return matchGlobSegments(strings.Split(pattern, globSeparator), strings.Split(name, globSeparator))
+}
+
+func matchGlobSegments(patterns []string, names []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] != globAnySegments {
+			if len(names) < 1 {
+				return false
+			}
+			if matched, _ := path.Match(patterns[0], names[0]); !matched {
+				return false
+			}
+			patterns, names = patterns[1:], names[1:]
+			continue
+		}
+
+		// consecutive `**` segments are the same as one
+		for len(patterns) > 0 && patterns[0] == globAnySegments {
+			patterns = patterns[1:]
+		}
+		if len(patterns) < 1 {
+			return true
+		}
+		for idx := range names {
+			if matchGlobSegments(patterns, names[idx:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return len(names) < 1
+}
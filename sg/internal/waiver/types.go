@@ -0,0 +1,39 @@
+package waiver
+
+import "time"
+
+// ExpiresLayout is the date layout of the waiver expiry.
+const ExpiresLayout = "2006-01-02"
+
+// Waiver waives the results of a rule, which is recorded with an owner, a reason and an expiry date.
+//
+// NOTE: rule patterns are matched with path.Match, other patterns are matched by segments with path.Match,
+// where a `**` segment matches zero or more segments (e.g. `manifests/**/*.yaml`).
+type Waiver struct {
+	// Rule is the pattern of the rule query (e.g. `deny_foo` or `warn_*`) to waive.
+	// It can be qualified by the namespace (e.g. `kubernetes.pss.baseline.deny_foo` or `data.main.deny_*`).
+	Rule string `json:"rule" yaml:"rule"`
+	// Package is the pattern of the qualified id of the policy package defining the rule
+	// (e.g. `builtin:pss/*` or `fs:policies/my-package`) to waive. Empty value matches all packages.
+	Package string `json:"package,omitempty" yaml:"package,omitempty"`
+	// Path is the pattern of the source file path (relative to the context root) to waive.
+	// Empty value matches all files.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+	// Resource is the pattern of the document identity (e.g. `Deployment/default/*`) to waive.
+	// Empty value matches all documents.
+	Resource string `json:"resource,omitempty" yaml:"resource,omitempty"`
+	// Owner is the owner of the waiver.
+	Owner string `json:"owner" yaml:"owner"`
+	// Reason is the justification of the waiver.
+	Reason string `json:"reason" yaml:"reason"`
+	// Expires is the last date (in YYYY-MM-DD, UTC) the waiver is valid.
+	Expires string `json:"expires" yaml:"expires"`
+
+	expiresAt time.Time
+}
+
+// File specifies the waivers file.
+type File struct {
+	// Waivers is the list of waivers.
+	Waivers []Waiver `json:"waivers" yaml:"waivers"`
+}
@@ -0,0 +1,228 @@
+package waiver
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+)
+
+// ReadFromYAML reads the waivers from YAML.
+func ReadFromYAML(src io.Reader) ([]Waiver, error) {
+	var f File
+	if err := yaml.NewDecoder(src).Decode(&f); err != nil {
+		if err == io.EOF {
+			// empty file
+			return nil, nil
+		}
+		return nil, fmt.Errorf("decode yaml: %w", err)
+	}
+
+	for idx := range f.Waivers {
+		if err := f.Waivers[idx].complete(); err != nil {
+			return nil, fmt.Errorf("invalid waiver #%d: %w", idx, err)
+		}
+	}
+
+	return f.Waivers, nil
+}
+
+// ReadFromFile reads the waivers from a file.
+func ReadFromFile(p string) ([]Waiver, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("read file %q: %w", p, err)
+	}
+	defer f.Close()
+
+	return ReadFromYAML(f)
+}
+
+// complete validates the waiver and resolves the expiry date.
+func (w *Waiver) complete() error {
+	if w.Rule == "" {
+		return fmt.Errorf("rule is required")
+	}
+	namespacePattern, queryPattern := w.rulePatterns()
+	for _, pattern := range []string{namespacePattern, queryPattern} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid rule pattern %q: %w", w.Rule, err)
+		}
+	}
+	for _, pattern := range []string{w.Package, w.Path, w.Resource} {
+		if err := validateGlob(pattern); err != nil {
+			return err
+		}
+	}
+	if w.Owner == "" {
+		return fmt.Errorf("owner is required")
+	}
+	if w.Reason == "" {
+		return fmt.Errorf("reason is required")
+	}
+	expiresAt, err := time.Parse(ExpiresLayout, w.Expires)
+	if err != nil {
+		return fmt.Errorf("invalid expires %q, expected format is YYYY-MM-DD: %w", w.Expires, err)
+	}
+	w.expiresAt = expiresAt
+
+	return nil
+}
+
+// Expired tells if the waiver is expired at the given time.
+// A waiver is valid through the end of the expiry date (in UTC).
+func (w Waiver) Expired(now time.Time) bool {
+	return !now.Before(w.expiresAt.AddDate(0, 0, 1))
+}
+
+// Matches tells if the waiver matches the result.
+func (w Waiver) Matches(r result.Result) bool {
+	// NOTE: patterns are validated when reading the waivers
+	namespacePattern, queryPattern := w.rulePatterns()
+	if matched, _ := path.Match(queryPattern, r.Rule.Query()); !matched {
+		return false
+	}
+	if namespacePattern != "" {
+		namespace := r.Rule.Namespace
+		if namespace == "" {
+			namespace = policy.NamespaceMain
+		}
+		if matched, _ := path.Match(namespacePattern, namespace); !matched {
+			return false
+		}
+	}
+	if w.Package != "" && !matchGlob(w.Package, r.Package) {
+		return false
+	}
+	if w.Path != "" && !matchGlob(w.Path, filepath.ToSlash(r.Location.File)) {
+		return false
+	}
+	if w.Resource != "" && !matchGlob(w.Resource, r.Location.Identity) {
+		return false
+	}
+	return true
+}
+
+// rulePatterns returns the namespace pattern and the rule query pattern of the waiver.
+// The rule can be qualified by the namespace, with an optional `data.` prefix
+// (e.g. `kubernetes.pss.baseline.deny_foo` or `data.main.deny_*`), otherwise the namespace pattern is empty.
+func (w Waiver) rulePatterns() (string, string) {
+	idx := strings.LastIndex(w.Rule, ".")
+	if idx < 0 {
+		return "", w.Rule
+	}
+	return strings.TrimPrefix(w.Rule[:idx], "data."), w.Rule[idx+1:]
+}
+
+func (w Waiver) asResultWaiver(now time.Time) *result.Waiver {
+	return &result.Waiver{
+		Owner:   w.Owner,
+		Reason:  w.Reason,
+		Expires: w.Expires,
+		Expired: w.Expired(now),
+	}
+}
+
+// ExpiredWaiverRuleName is the name of the rule reporting the expired waivers.
+const ExpiredWaiverRuleName = "expired_waiver"
+
+// Apply applies the waivers to the query results:
+//
+//   - failures and warnings matched by a valid waiver are moved to the exceptions, with the waiver attached;
+//   - failures and warnings matched by an expired waiver are kept, with the expired waiver attached.
+//     Each expired waiver is also reported as a warning of the source.
+func Apply(queryResultsList []result.QueryResults, waivers []Waiver, now time.Time) []result.QueryResults {
+	if len(waivers) < 1 {
+		return queryResultsList
+	}
+
+	rv := make([]result.QueryResults, 0, len(queryResultsList))
+	for _, queryResults := range queryResultsList {
+		rv = append(rv, applyToQueryResults(queryResults, waivers, now))
+	}
+	return rv
+}
+
+func applyToQueryResults(queryResults result.QueryResults, waivers []Waiver, now time.Time) result.QueryResults {
+	var expiredWarnings []result.Result
+	reportedExpiredWaivers := map[int]struct{}{}
+
+	// apply returns the results not waived.
+	apply := func(results []result.Result) []result.Result {
+		var rv []result.Result
+		for _, r := range results {
+			idx := matchWaiver(waivers, r, now)
+			if idx < 0 {
+				rv = append(rv, r)
+				continue
+			}
+
+			w := waivers[idx]
+			r.Waiver = w.asResultWaiver(now)
+			if !r.Waiver.Expired {
+				queryResults.Exceptions = append(queryResults.Exceptions, r)
+				continue
+			}
+
+			rv = append(rv, r)
+			if _, reported := reportedExpiredWaivers[idx]; reported {
+				continue
+			}
+			reportedExpiredWaivers[idx] = struct{}{}
+			expiredWarnings = append(expiredWarnings, expiredWaiverResult(w, r))
+		}
+		return rv
+	}
+
+	queryResults.Failures = apply(queryResults.Failures)
+	queryResults.Warnings = append(apply(queryResults.Warnings), expiredWarnings...)
+
+	return queryResults
+}
+
+// matchWaiver returns the index of the waiver matching the result, or -1 if none matches.
+// Valid waivers take precedence over the expired ones.
+func matchWaiver(waivers []Waiver, r result.Result, now time.Time) int {
+	expiredIdx := -1
+	for idx, w := range waivers {
+		if !w.Matches(r) {
+			continue
+		}
+		if !w.Expired(now) {
+			return idx
+		}
+		if expiredIdx < 0 {
+			expiredIdx = idx
+		}
+	}
+	return expiredIdx
+}
+
+func expiredWaiverResult(w Waiver, r result.Result) result.Result {
+	return result.Result{
+		Query: fmt.Sprintf("waiver[%s]", w.Rule),
+		Rule: policy.Rule{
+			Kind:      policy.QueryKindWarn,
+			Name:      ExpiredWaiverRuleName,
+			Namespace: r.Rule.Namespace,
+		},
+		Message: fmt.Sprintf(
+			"waiver of %s expired on %s (owner: %s, reason: %s)",
+			w.Rule, w.Expires, w.Owner, w.Reason,
+		),
+		Location: result.Location{
+			File:     r.Location.File,
+			Document: r.Location.Document,
+			Identity: r.Location.Identity,
+		},
+		Waiver: r.Waiver,
+	}
+}
@@ -0,0 +1,224 @@
+package waiver
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Azure/ShieldGuard/sg/internal/policy"
+	"github.com/Azure/ShieldGuard/sg/internal/result"
+)
+
+func Test_ReadFromYAML(t *testing.T) {
+	waivers, err := ReadFromYAML(strings.NewReader(`
+waivers:
+- rule: deny_foo
+  path: configurations/*.yaml
+  resource: Pod/*
+  owner: team-a
+  reason: foo is required
+  expires: 2024-06-30
+`))
+	assert.NoError(t, err)
+	if assert.Len(t, waivers, 1) {
+		w := waivers[0]
+		assert.Equal(t, "deny_foo", w.Rule)
+		assert.Equal(t, "configurations/*.yaml", w.Path)
+		assert.Equal(t, "Pod/*", w.Resource)
+		assert.Equal(t, "team-a", w.Owner)
+		assert.Equal(t, "foo is required", w.Reason)
+		assert.Equal(t, "2024-06-30", w.Expires)
+	}
+
+	waivers, err = ReadFromYAML(strings.NewReader(""))
+	assert.NoError(t, err)
+	assert.Empty(t, waivers)
+
+	invalidWaivers := []string{
+		// missing rule
+		`{owner: a, reason: b, expires: 2024-06-30}`,
+		// missing owner
+		`{rule: deny_foo, reason: b, expires: 2024-06-30}`,
+		// missing reason
+		`{rule: deny_foo, owner: a, expires: 2024-06-30}`,
+		// missing expires
+		`{rule: deny_foo, owner: a, reason: b}`,
+		// invalid expires
+		`{rule: deny_foo, owner: a, reason: b, expires: 06/30/2024}`,
+		// invalid pattern
+		`{rule: "deny_[", owner: a, reason: b, expires: 2024-06-30}`,
+		// invalid namespace pattern
+		`{rule: "kubernetes.[.deny_foo", owner: a, reason: b, expires: 2024-06-30}`,
+		// invalid path pattern
+		`{rule: deny_foo, path: "configurations/[", owner: a, reason: b, expires: 2024-06-30}`,
+		// `**` not as a whole segment
+		`{rule: deny_foo, path: "configurations/**.yaml", owner: a, reason: b, expires: 2024-06-30}`,
+	}
+	for idx, w := range invalidWaivers {
+		t.Run(fmt.Sprintf("invalid waiver #%d", idx), func(t *testing.T) {
+			_, err := ReadFromYAML(strings.NewReader("waivers: [" + w + "]"))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func mustWaiver(t *testing.T, w Waiver) Waiver {
+	t.Helper()
+	assert.NoError(t, w.complete())
+	return w
+}
+
+func Test_Waiver_Expired(t *testing.T) {
+	w := mustWaiver(t, Waiver{Rule: "deny_foo", Owner: "a", Reason: "b", Expires: "2024-06-30"})
+
+	assert.False(t, w.Expired(time.Date(2024, 6, 29, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, w.Expired(time.Date(2024, 6, 30, 23, 59, 59, 0, time.UTC)), "valid through the expiry date")
+	assert.True(t, w.Expired(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func Test_Waiver_Matches(t *testing.T) {
+	r := result.Result{
+		Rule:    policy.Rule{Kind: policy.QueryKindDeny, Name: "foo", Namespace: "kubernetes.pss.baseline"},
+		Package: "builtin:pss/baseline",
+		Location: result.Location{
+			File:     "configurations/apps/pod.yaml",
+			Identity: "Pod/foo",
+		},
+	}
+
+	cases := []struct {
+		waiver   Waiver
+		expected bool
+	}{
+		{waiver: Waiver{Rule: "deny_foo"}, expected: true},
+		{waiver: Waiver{Rule: "deny_*"}, expected: true},
+		{waiver: Waiver{Rule: "warn_foo"}, expected: false},
+		{waiver: Waiver{Rule: "deny_foo", Package: "builtin:pss/*"}, expected: true},
+		{waiver: Waiver{Rule: "deny_foo", Package: "fs:policy"}, expected: false},
+		{waiver: Waiver{Rule: "kubernetes.pss.baseline.deny_foo"}, expected: true},
+		{waiver: Waiver{Rule: "data.kubernetes.pss.*.deny_*"}, expected: true},
+		{waiver: Waiver{Rule: "main.deny_foo"}, expected: false},
+		{waiver: Waiver{Rule: "data.main.deny_foo"}, expected: false},
+		{waiver: Waiver{Rule: "deny_foo", Path: "configurations/*/*.yaml"}, expected: true},
+		{waiver: Waiver{Rule: "deny_foo", Path: "configurations/*.yaml"}, expected: false},
+		{waiver: Waiver{Rule: "deny_foo", Path: "configurations/**/*.yaml"}, expected: true},
+		{waiver: Waiver{Rule: "deny_foo", Path: "**/pod.yaml"}, expected: true},
+		{waiver: Waiver{Rule: "deny_foo", Path: "configurations/**"}, expected: true},
+		{waiver: Waiver{Rule: "deny_foo", Path: "manifests/**"}, expected: false},
+		{waiver: Waiver{Rule: "deny_foo", Path: "manifests/*.yaml"}, expected: false},
+		{waiver: Waiver{Rule: "deny_foo", Resource: "Pod/*"}, expected: true},
+		{waiver: Waiver{Rule: "deny_foo", Resource: "Deployment/*"}, expected: false},
+		{waiver: Waiver{Rule: "deny_foo", Path: "configurations/apps/pod.yaml", Resource: "Pod/bar"}, expected: false},
+	}
+
+	for idx := range cases {
+		t.Run(fmt.Sprintf("case #%d", idx), func(t *testing.T) {
+			assert.Equal(t, cases[idx].expected, cases[idx].waiver.Matches(r))
+		})
+	}
+}
+
+func Test_matchGlob(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "a/*.yaml", name: "a/b.yaml", expected: true},
+		{pattern: "a/*.yaml", name: "a/b/c.yaml", expected: false},
+		{pattern: "a/**", name: "a", expected: true},
+		{pattern: "a/**", name: "a/b/c.yaml", expected: true},
+		{pattern: "a/**/c.yaml", name: "a/c.yaml", expected: true},
+		{pattern: "a/**/c.yaml", name: "a/b/d/c.yaml", expected: true},
+		{pattern: "a/**/c.yaml", name: "a/b/d/e.yaml", expected: false},
+		{pattern: "a/**/**/c.yaml", name: "a/b/c.yaml", expected: true},
+		{pattern: "**", name: "a/b", expected: true},
+		{pattern: "**/b/**/d", name: "a/b/c/d", expected: true},
+		{pattern: "**/b/**/d", name: "a/c/d", expected: false},
+		{pattern: "b/**", name: "a/b/c", expected: false},
+	}
+
+	for _, c := range cases {
+		assert.NoError(t, validateGlob(c.pattern))
+		assert.Equal(t, c.expected, matchGlob(c.pattern, c.name), "%s should match %s: %t", c.pattern, c.name, c.expected)
+	}
+}
+
+func Test_Apply(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	waivers := []Waiver{
+		mustWaiver(t, Waiver{Rule: "deny_foo", Resource: "Pod/a", Owner: "team-a", Reason: "valid", Expires: "2024-12-31"}),
+		mustWaiver(t, Waiver{Rule: "deny_foo", Resource: "Pod/b", Owner: "team-b", Reason: "expired", Expires: "2024-01-01"}),
+		mustWaiver(t, Waiver{Rule: "warn_*", Owner: "team-c", Reason: "expired", Expires: "2024-01-01"}),
+		mustWaiver(t, Waiver{Rule: "warn_bar", Owner: "team-c", Reason: "valid", Expires: "2024-12-31"}),
+	}
+
+	newResult := func(kind policy.QueryKind, name string, identity string) result.Result {
+		return result.Result{
+			Rule:     policy.Rule{Kind: kind, Name: name, Namespace: "main"},
+			Message:  name + " of " + identity,
+			Location: result.Location{File: "pods.yaml", Identity: identity},
+		}
+	}
+
+	queryResultsList := Apply([]result.QueryResults{
+		{
+			Failures: []result.Result{
+				newResult(policy.QueryKindDeny, "foo", "Pod/a"),
+				newResult(policy.QueryKindDeny, "foo", "Pod/b"),
+				newResult(policy.QueryKindDeny, "foo", "Pod/c"),
+			},
+			Warnings: []result.Result{
+				newResult(policy.QueryKindWarn, "bar", "Pod/a"),
+				newResult(policy.QueryKindWarn, "baz", "Pod/a"),
+				newResult(policy.QueryKindWarn, "baz", "Pod/b"),
+			},
+		},
+	}, waivers, now)
+	assert.Len(t, queryResultsList, 1)
+	queryResults := queryResultsList[0]
+
+	if assert.Len(t, queryResults.Exceptions, 2) {
+		assert.Equal(t, "foo of Pod/a", queryResults.Exceptions[0].Message)
+		assert.Equal(
+			t,
+			&result.Waiver{Owner: "team-a", Reason: "valid", Expires: "2024-12-31"},
+			queryResults.Exceptions[0].Waiver,
+		)
+		assert.Equal(t, "bar of Pod/a", queryResults.Exceptions[1].Message, "valid waiver takes precedence")
+	}
+
+	if assert.Len(t, queryResults.Failures, 2) {
+		assert.Equal(t, "foo of Pod/b", queryResults.Failures[0].Message)
+		assert.True(t, queryResults.Failures[0].Waiver.Expired)
+		assert.Equal(t, "foo of Pod/c", queryResults.Failures[1].Message)
+		assert.Nil(t, queryResults.Failures[1].Waiver)
+	}
+
+	if assert.Len(t, queryResults.Warnings, 4, "2 warnings + 2 expired waivers") {
+		assert.Equal(t, "baz of Pod/a", queryResults.Warnings[0].Message)
+		assert.Equal(t, "baz of Pod/b", queryResults.Warnings[1].Message)
+
+		expiredWarning := queryResults.Warnings[2]
+		assert.Equal(t, ExpiredWaiverRuleName, expiredWarning.Rule.Name)
+		assert.Equal(t, policy.QueryKindWarn, expiredWarning.Rule.Kind)
+		assert.Equal(
+			t,
+			"waiver of deny_foo expired on 2024-01-01 (owner: team-b, reason: expired)",
+			expiredWarning.Message,
+		)
+		assert.Equal(t, "Pod/b", expiredWarning.Location.Identity)
+
+		assert.Equal(
+			t,
+			"waiver of warn_* expired on 2024-01-01 (owner: team-c, reason: expired)",
+			queryResults.Warnings[3].Message,
+			"expired waiver should be reported once per source",
+		)
+	}
+
+	assert.Equal(t, []result.QueryResults{{}}, Apply([]result.QueryResults{{}}, nil, now))
+}
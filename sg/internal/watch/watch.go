@@ -0,0 +1,154 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is the default duration to wait for more changes before reporting them.
+// Editors usually write a file with several events (e.g. truncate then write, or write to a
+// temporary file then rename).
+const DefaultDebounce = 200 * time.Millisecond
+
+// Watcher watches files and directories (recursively) for changes.
+type Watcher struct {
+	watcher  *fsnotify.Watcher
+	debounce time.Duration
+	// dirs is the set of the directories watched recursively.
+	dirs []string
+	// files is the set of the files watched.
+	files map[string]struct{}
+}
+
+// New creates a Watcher for the paths. Directories are watched recursively.
+// Paths are resolved to absolute paths, and must exist.
+func New(paths []string, debounce time.Duration) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create watcher: %w", err)
+	}
+
+	w := &Watcher{
+		watcher:  fsWatcher,
+		debounce: debounce,
+		files:    map[string]struct{}{},
+	}
+	for _, p := range paths {
+		if err := w.add(p); err != nil {
+			fsWatcher.Close()
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+func (w *Watcher) add(p string) error {
+	p, err := filepath.Abs(p)
+	if err != nil {
+		return fmt.Errorf("resolve path %q: %w", p, err)
+	}
+	stat, err := os.Stat(p)
+	if err != nil {
+		return fmt.Errorf("stat %q: %w", p, err)
+	}
+
+	if !stat.IsDir() {
+		// NOTE: files are watched via the parent directory, so they can be replaced by renaming
+		w.files[p] = struct{}{}
+		if err := w.watcher.Add(filepath.Dir(p)); err != nil {
+			return fmt.Errorf("watch %q: %w", p, err)
+		}
+		return nil
+	}
+
+	w.dirs = append(w.dirs, p)
+	return w.addDir(p)
+}
+
+// addDir watches the directory and its sub-directories.
+func (w *Watcher) addDir(dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := w.watcher.Add(p); err != nil {
+			return fmt.Errorf("watch %q: %w", p, err)
+		}
+		return nil
+	})
+}
+
+// watched tells if the path is a watched file or under a watched directory.
+func (w *Watcher) watched(p string) bool {
+	if _, ok := w.files[p]; ok {
+		return true
+	}
+	for _, dir := range w.dirs {
+		if p == dir || strings.HasPrefix(p, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run watches the changes until the context is cancelled, and calls onChange with the changed paths
+// (sorted, absolute) after the changes settle for the debounce duration.
+// The watcher is closed when Run returns.
+func (w *Watcher) Run(ctx context.Context, onChange func(paths []string)) error {
+	defer w.watcher.Close()
+
+	changed := map[string]struct{}{}
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("watch: %w", err)
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod || !w.watched(event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if stat, err := os.Stat(event.Name); err == nil && stat.IsDir() {
+					// NOTE: the directory might be removed before we watch it, which is fine
+					_ = w.addDir(event.Name)
+				}
+			}
+			changed[event.Name] = struct{}{}
+			timer.Reset(w.debounce)
+		case <-timer.C:
+			if len(changed) < 1 {
+				continue
+			}
+			paths := make([]string, 0, len(changed))
+			for p := range changed {
+				paths = append(paths, p)
+			}
+			sort.Strings(paths)
+			changed = map[string]struct{}{}
+			onChange(paths)
+		}
+	}
+}
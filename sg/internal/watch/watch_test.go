@@ -0,0 +1,100 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDebounce = 50 * time.Millisecond
+
+func runWatcher(t *testing.T, paths []string) <-chan []string {
+	w, err := New(paths, testDebounce)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	changes := make(chan []string, 10)
+	go func() {
+		defer close(done)
+		assert.NoError(t, w.Run(ctx, func(paths []string) { changes <- paths }))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return changes
+}
+
+func expectChanges(t *testing.T, changes <-chan []string, expected []string) {
+	select {
+	case paths := <-changes:
+		assert.Equal(t, expected, paths)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for changes: %v", expected)
+	}
+}
+
+func expectNoChanges(t *testing.T, changes <-chan []string) {
+	select {
+	case paths := <-changes:
+		t.Fatalf("unexpected changes: %v", paths)
+	case <-time.After(10 * testDebounce):
+	}
+}
+
+func Test_Watcher(t *testing.T) {
+	root := t.TempDir()
+	policyDir := filepath.Join(root, "policy")
+	assert.NoError(t, os.MkdirAll(filepath.Join(policyDir, "lib"), 0o755))
+	projectFile := filepath.Join(root, "sg-project.yaml")
+	assert.NoError(t, os.WriteFile(projectFile, []byte("files: []"), 0o644))
+	otherFile := filepath.Join(root, "README.md")
+
+	changes := runWatcher(t, []string{policyDir, projectFile})
+
+	t.Run("file in nested directory", func(t *testing.T) {
+		p := filepath.Join(policyDir, "lib", "helpers.rego")
+		assert.NoError(t, os.WriteFile(p, []byte("package lib"), 0o644))
+		expectChanges(t, changes, []string{p})
+	})
+
+	t.Run("watched file", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(projectFile, []byte("files: [{}]"), 0o644))
+		expectChanges(t, changes, []string{projectFile})
+	})
+
+	t.Run("not watched file", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(otherFile, []byte("# README"), 0o644))
+		expectNoChanges(t, changes)
+	})
+
+	t.Run("new directory", func(t *testing.T) {
+		dir := filepath.Join(policyDir, "new")
+		assert.NoError(t, os.Mkdir(dir, 0o755))
+		expectChanges(t, changes, []string{dir})
+
+		p := filepath.Join(dir, "new.rego")
+		assert.NoError(t, os.WriteFile(p, []byte("package main"), 0o644))
+		expectChanges(t, changes, []string{p})
+	})
+
+	t.Run("debounce", func(t *testing.T) {
+		a := filepath.Join(policyDir, "a.rego")
+		b := filepath.Join(policyDir, "b.rego")
+		assert.NoError(t, os.WriteFile(a, []byte("package main"), 0o644))
+		assert.NoError(t, os.WriteFile(b, []byte("package main"), 0o644))
+		assert.NoError(t, os.WriteFile(a, []byte("package main\n"), 0o644))
+		expectChanges(t, changes, []string{a, b})
+	})
+}
+
+func Test_New_notFound(t *testing.T) {
+	_, err := New([]string{filepath.Join(t.TempDir(), "not-found")}, testDebounce)
+	assert.Error(t, err)
+}